@@ -7,7 +7,12 @@ import (
 	"github.com/Alkemic/webrss/repository"
 )
 
-var userCtxKey = struct{}{}
+type deviceCtxKeyType struct{}
+
+var (
+	userCtxKey   = struct{}{}
+	deviceCtxKey = deviceCtxKeyType{}
+)
 
 func SetUser(r *http.Request, user repository.User) {
 	ctx := r.Context()
@@ -20,3 +25,14 @@ func GetUser(r *http.Request) repository.User {
 	val := userRaw.(repository.User)
 	return val
 }
+
+func SetDevice(r *http.Request, device string) {
+	ctx := context.WithValue(r.Context(), deviceCtxKey, device)
+	(*r) = *(r.WithContext(ctx))
+}
+
+// GetDevice returns id of the device request was made from, empty when it's unknown.
+func GetDevice(ctx context.Context) string {
+	device, _ := ctx.Value(deviceCtxKey).(string)
+	return device
+}
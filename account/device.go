@@ -0,0 +1,30 @@
+package account
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const deviceCookieName = "device"
+
+// setDevice identifies the browser by long-lived cookie, the cookie is set when it's missing.
+func setDevice(rw http.ResponseWriter, req *http.Request) {
+	cookie, err := req.Cookie(deviceCookieName)
+	if err == nil {
+		if _, err := uuid.Parse(cookie.Value); err == nil {
+			SetDevice(req, cookie.Value)
+			return
+		}
+	}
+	device := uuid.New().String()
+	http.SetCookie(rw, &http.Cookie{
+		Name:     deviceCookieName,
+		Value:    device,
+		Path:     "/",
+		MaxAge:   60 * 60 * 24 * 365 * 10,
+		Secure:   req.TLS != nil,
+		HttpOnly: true,
+	})
+	SetDevice(req, device)
+}
@@ -104,6 +104,7 @@ func (m *Middleware) LoginRequiredMiddleware(f http.HandlerFunc) http.HandlerFun
 		}
 
 		SetUser(req, user)
+		setDevice(rw, req)
 
 		f(rw, req)
 	}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type entriesExporter interface {
+	ExportEntries(ctx context.Context, selection webrss.ExportSelection) ([]repository.Entry, error)
+}
+
+type epubGenerator interface {
+	Write(ctx context.Context, w io.Writer, title string, entries []repository.Entry) error
+}
+
+// exportEPUB writes selected entries as EPUB book to file.
+func exportEPUB(ctx context.Context, exporter entriesExporter, generator epubGenerator, args []string) error {
+	flags := flag.NewFlagSet("epub", flag.ExitOnError)
+	ids := flags.String("ids", "", "comma separated ids of entries")
+	starred := flags.Bool("starred", false, "export starred entries")
+	categoryID := flags.Int64("category", 0, "id of the category to export")
+	title := flags.String("title", "WebRSS "+time.Now().Format("2006-01-02"), "title of the book")
+	output := flags.String("o", "", "output file")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("cannot parse arguments: %w", err)
+	}
+	if *output == "" {
+		return errors.New("missing -o argument")
+	}
+
+	selection := webrss.ExportSelection{Starred: *starred, CategoryID: *categoryID}
+	if *ids != "" {
+		for _, rawID := range strings.Split(*ids, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(rawID), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid entry id '%s': %w", rawID, err)
+			}
+			selection.IDs = append(selection.IDs, id)
+		}
+	}
+	entries, err := exporter.ExportEntries(ctx, selection)
+	if err != nil {
+		return fmt.Errorf("cannot fetch entries: %w", err)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("cannot create output file: %w", err)
+	}
+	if err := generator.Write(ctx, f, *title, entries); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
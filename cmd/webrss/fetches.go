@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type fetchLogRepository interface {
+	ListForFeed(ctx context.Context, feedID int64, limit int) ([]repository.FetchLog, error)
+}
+
+// printFetches prints last fetch attempts of a feed, usage: webrss fetches -feed 12 -n 20
+func printFetches(ctx context.Context, fetchLogRepository fetchLogRepository, args []string) error {
+	flags := flag.NewFlagSet("fetches", flag.ExitOnError)
+	feedID := flags.Int64("feed", 0, "id of the feed")
+	limit := flags.Int("n", 20, "number of fetch attempts to print")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("cannot parse arguments: %w", err)
+	}
+	if *feedID == 0 {
+		return errors.New("missing -feed argument")
+	}
+
+	fetchLogs, err := fetchLogRepository.ListForFeed(ctx, *feedID, *limit)
+	if err != nil {
+		return fmt.Errorf("cannot fetch fetch logs: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FETCHED AT\tDURATION\tSTATUS\tBYTES\tITEMS\tNEW\tUPDATED\tERROR")
+	for _, fetchLog := range fetchLogs {
+		fmt.Fprintf(w, "%s\t%dms\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			fetchLog.FetchedAt.Format("2006-01-02 15:04:05"), fetchLog.DurationMs, fetchLog.StatusCode,
+			fetchLog.Bytes, fetchLog.Items, fetchLog.NewEntries, fetchLog.UpdatedEntries, fetchLog.Error.String)
+	}
+	return w.Flush()
+}
@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"time"
@@ -16,13 +17,33 @@ import (
 
 	"github.com/Alkemic/webrss/account"
 	"github.com/Alkemic/webrss/config"
+	"github.com/Alkemic/webrss/digest"
+	"github.com/Alkemic/webrss/epub"
+	"github.com/Alkemic/webrss/events"
 	"github.com/Alkemic/webrss/feed_fetcher"
 	"github.com/Alkemic/webrss/handler"
+	"github.com/Alkemic/webrss/httpclient"
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/readlater"
 	"github.com/Alkemic/webrss/repository"
 	"github.com/Alkemic/webrss/updater"
+	"github.com/Alkemic/webrss/webhook"
 	"github.com/Alkemic/webrss/webrss"
 )
 
+const (
+	// digestCheckInterval is how often it's checked whether digest is due.
+	digestCheckInterval = 10 * time.Minute
+	// webhookDeliveryInterval is how often queued webhook deliveries are attempted.
+	webhookDeliveryInterval = 15 * time.Second
+	// webhookMaxResponseSize limits size of webhook responses read.
+	webhookMaxResponseSize = 1 << 20
+	// epubMaxImageSize limits size of images embedded in EPUB exports.
+	epubMaxImageSize = 5 << 20
+	// readLaterMaxResponseSize limits size of read-later service responses read.
+	readLaterMaxResponseSize = 1 << 20
+)
+
 func main() {
 	flag.Parse()
 
@@ -35,27 +56,83 @@ func main() {
 	defer closeFn()
 
 	fp := gofeed.NewParser()
-	httpClient := &http.Client{}
-	feedFetcher := feed_fetcher.NewFeedParser(fp, httpClient)
+	httpClient := newHTTPClient(cfg, cfg.MaxFeedSize)
+	faviconClient := newHTTPClient(cfg, cfg.MaxIconSize)
+	feedFetcher := feed_fetcher.NewFeedParser(fp, httpClient, faviconClient)
 
 	//userRepository := repository.NewUserRepository(db)
 	settingsRepository := repository.NewSettingsRepository(db)
-	sessionRepository := repository.NewSessionRepository(28 * 24 * time.Hour)
-	authenticateHandler := account.NewAuthenticateHandler(logger, settingsRepository, sessionRepository)
-	authenticateMiddleware := account.NewAuthenticateMiddleware(logger, settingsRepository, sessionRepository)
-
 	categoryRepository := repository.NewCategoryRepository(db)
 	feedRepository := repository.NewFeedRepository(db)
 	entryRepository := repository.NewEntryRepository(db)
+	entryRevisionRepository := repository.NewEntryRevisionRepository(db)
 	transactionRepository := repository.NewTransactionRepository(db)
-	webrssService := webrss.NewService(logger, categoryRepository, feedRepository, entryRepository, transactionRepository, httpClient, feedFetcher)
+	fetchLogRepository := repository.NewFetchLogRepository(db)
+	ruleRepository := repository.NewRuleRepository(db)
+	userTagRepository := repository.NewUserTagRepository(db)
+	savedSearchRepository := repository.NewSavedSearchRepository(db)
+	annotationRepository := repository.NewAnnotationRepository(db)
+	lastSeenRepository := repository.NewLastSeenRepository(db)
+	shareRepository := repository.NewShareRepository(db)
+	webhookRepository := repository.NewWebhookRepository(db)
+	webhookDeliveryRepository := repository.NewWebhookDeliveryRepository(db)
+	eventBroker := events.New(logger)
+	readLaterService := readlater.New(logger, settingsRepository, newIntegrationHTTPClient(cfg, readLaterMaxResponseSize))
+	webrssService := webrss.NewService(logger, categoryRepository, feedRepository, entryRepository, entryRevisionRepository, transactionRepository,
+		fetchLogRepository, ruleRepository, userTagRepository, savedSearchRepository, annotationRepository,
+		lastSeenRepository, shareRepository, webhookRepository, webhookDeliveryRepository, eventBroker, readLaterService, faviconClient, feedFetcher)
+	epubGenerator := epub.New(logger, newHTTPClient(cfg, epubMaxImageSize))
+	switch flag.Arg(0) {
+	case "":
+	case "fetches":
+		if err := printFetches(context.Background(), fetchLogRepository, flag.Args()[1:]); err != nil {
+			logger.Fatalln("cannot print fetches: ", err)
+		}
+		return
+	case "epub":
+		if err := exportEPUB(context.Background(), webrssService, epubGenerator, flag.Args()[1:]); err != nil {
+			logger.Fatalln("cannot export epub: ", err)
+		}
+		return
+	case "normalize-links":
+		updated, err := webrssService.NormalizeLinks(context.Background())
+		if err != nil {
+			logger.Fatalln("cannot normalize links: ", err)
+		}
+		logger.Printf("normalized links of %d entries\n", updated)
+		return
+	default:
+		logger.Fatalf("unknown command: %s", flag.Arg(0))
+	}
+
+	sessionRepository := repository.NewSessionRepository(28 * 24 * time.Hour)
+	authenticateHandler := account.NewAuthenticateHandler(logger, settingsRepository, sessionRepository)
+	authenticateMiddleware := account.NewAuthenticateMiddleware(logger, settingsRepository, sessionRepository)
 	categoryHandler := handler.NewCategory(logger, webrssService)
 	entryHandler := handler.NewEntry(logger, webrssService, cfg.PerPage)
 	feedHandler := handler.NewFeed(logger, webrssService)
-	updateService := updater.New(feedRepository, webrssService, feedFetcher, logger)
-	app := webrss.New(logger, cfg, categoryHandler, feedHandler, entryHandler, authenticateHandler, authenticateMiddleware, updateService, time.Hour)
+	ruleHandler := handler.NewRule(logger, webrssService)
+	savedSearchHandler := handler.NewSavedSearch(logger, webrssService)
+	userTagHandler := handler.NewUserTag(logger, webrssService)
+	shareHandler := handler.NewShare(logger, webrssService)
+	digestService := digest.New(logger, settingsRepository, webrssService)
+	digestHandler := handler.NewDigest(logger, digestService)
+	webhookHandler := handler.NewWebhook(logger, webrssService)
+	webhookDispatcher := webhook.New(logger, webhookRepository, webhookDeliveryRepository,
+		newIntegrationHTTPClient(cfg, webhookMaxResponseSize))
+	eventsHandler := handler.NewEvents(logger, eventBroker)
+	exportHandler := handler.NewExport(logger, webrssService, epubGenerator)
+	readLaterHandler := handler.NewReadLater(logger, readLaterService)
+	appMetrics := metrics.New(logger, db, entryRepository)
+	updateService := updater.New(feedRepository, webrssService, feedFetcher, appMetrics, eventBroker, logger)
+	app := webrss.New(logger, cfg, categoryHandler, feedHandler, entryHandler, ruleHandler, savedSearchHandler, userTagHandler, shareHandler, digestHandler, webhookHandler, eventsHandler, exportHandler, readLaterHandler, authenticateHandler, authenticateMiddleware,
+		updateService, time.Hour, appMetrics)
 	app.AddOnExit(closeFn)
 	go app.Updater(context.Background())
+	if cfg.RunUpdater {
+		go digestService.Run(context.Background(), digestCheckInterval)
+		go webhookDispatcher.Run(context.Background(), webhookDeliveryInterval)
+	}
 	if err := app.Run(); err != nil {
 		logger.Fatalln("application exited with error: ", err)
 	}
@@ -73,3 +150,26 @@ func initDB(cfg *config.Config) (*sqlx.DB, error, func()) {
 		db.Close()
 	}
 }
+
+func newHTTPClient(cfg *config.Config, maxBodySize int64) *http.Client {
+	return httpclient.New(httpclient.Config{
+		ConnectTimeout:  cfg.FetchConnectTimeout,
+		HeaderTimeout:   cfg.FetchHeaderTimeout,
+		Timeout:         cfg.FetchTimeout,
+		MaxBodySize:     maxBodySize,
+		AllowedNetworks: cfg.FetchAllowedNetworks,
+	})
+}
+
+// newIntegrationHTTPClient returns client for calling user configured services, which may also run in
+// networks allowed only for integrations.
+func newIntegrationHTTPClient(cfg *config.Config, maxBodySize int64) *http.Client {
+	allowedNetworks := append([]*net.IPNet{}, cfg.FetchAllowedNetworks...)
+	return httpclient.New(httpclient.Config{
+		ConnectTimeout:  cfg.FetchConnectTimeout,
+		HeaderTimeout:   cfg.FetchHeaderTimeout,
+		Timeout:         cfg.FetchTimeout,
+		MaxBodySize:     maxBodySize,
+		AllowedNetworks: append(allowedNetworks, cfg.IntegrationAllowedNetworks...),
+	})
+}
@@ -1,17 +1,44 @@
 package config
 
 import (
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
-const defaultPerPage = 50
+const (
+	defaultPerPage = 50
+
+	defaultFetchConnectTimeout = 10 * time.Second
+	defaultFetchHeaderTimeout  = 20 * time.Second
+	defaultFetchTimeout        = time.Minute
+	defaultMaxFeedSize         = 10 << 20
+	defaultMaxIconSize         = 1 << 20
+)
 
 type Config struct {
 	DBDSN      string
 	BindAdr    string
 	PerPage    int
 	RunUpdater bool
+
+	FetchConnectTimeout time.Duration
+	FetchHeaderTimeout  time.Duration
+	FetchTimeout        time.Duration
+	MaxFeedSize         int64
+	MaxIconSize         int64
+	// FetchAllowedNetworks are internal networks that feeds may still be fetched from.
+	FetchAllowedNetworks []*net.IPNet
+	// IntegrationAllowedNetworks are internal networks that webhooks and read-later service may be called
+	// in, in addition to FetchAllowedNetworks.
+	IntegrationAllowedNetworks []*net.IPNet
+
+	// MetricsBindAddr is address of separate server exposing metrics, when empty metrics are
+	// served by the main server, but only when MetricsToken is set.
+	MetricsBindAddr string
+	MetricsToken    string
 }
 
 func LoadConfig() *Config {
@@ -29,5 +56,55 @@ func LoadConfig() *Config {
 		BindAdr:    os.Getenv("BIND_ADDR"),
 		PerPage:    perPage,
 		RunUpdater: runUpdaterRaw == "" || runUpdaterRaw == "true",
+
+		FetchConnectTimeout: durationEnv("FETCH_CONNECT_TIMEOUT", defaultFetchConnectTimeout),
+		FetchHeaderTimeout:  durationEnv("FETCH_HEADER_TIMEOUT", defaultFetchHeaderTimeout),
+		FetchTimeout:        durationEnv("FETCH_TIMEOUT", defaultFetchTimeout),
+		MaxFeedSize:         int64Env("MAX_FEED_SIZE", defaultMaxFeedSize),
+		MaxIconSize:         int64Env("MAX_ICON_SIZE", defaultMaxIconSize),
+
+		FetchAllowedNetworks:       networksEnv("FETCH_ALLOWED_NETWORKS"),
+		IntegrationAllowedNetworks: networksEnv("INTEGRATION_ALLOWED_NETWORKS"),
+
+		MetricsBindAddr: os.Getenv("METRICS_BIND_ADDR"),
+		MetricsToken:    os.Getenv("METRICS_TOKEN"),
+	}
+}
+
+func durationEnv(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func int64Env(key string, defaultValue int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// networksEnv parses comma separated list of CIDRs or IP addresses, invalid values are skipped.
+func networksEnv(key string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		if _, network, err := net.ParseCIDR(value); err == nil {
+			networks = append(networks, network)
+		}
 	}
+	return networks
 }
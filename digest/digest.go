@@ -0,0 +1,139 @@
+// Package digest sends periodic emails listing entries created since the previous one.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type digestBuilder interface {
+	BuildDigest(ctx context.Context, mode string, since time.Time) (webrss.Digest, error)
+}
+
+type Service struct {
+	nowFn              func() time.Time
+	logger             *log.Logger
+	settingsRepository settingsRepository
+	digestBuilder      digestBuilder
+}
+
+func New(logger *log.Logger, settingsRepository settingsRepository, digestBuilder digestBuilder) Service {
+	return Service{
+		nowFn:              time.Now,
+		logger:             logger,
+		settingsRepository: settingsRepository,
+		digestBuilder:      digestBuilder,
+	}
+}
+
+// Settings returns digest settings, without SMTP password.
+func (s Service) Settings(ctx context.Context) (Settings, error) {
+	settings, err := loadSettings(ctx, s.settingsRepository)
+	if err != nil {
+		return Settings{}, err
+	}
+	settings.SMTPPassword = ""
+	return settings, nil
+}
+
+// SaveSettings validates and saves settings, empty SMTP password keeps the current one.
+func (s Service) SaveSettings(ctx context.Context, settings Settings) error {
+	if err := settings.validate(); err != nil {
+		return err
+	}
+	if settings.SMTPPassword == "" {
+		current, err := loadSettings(ctx, s.settingsRepository)
+		if err != nil {
+			return err
+		}
+		settings.SMTPPassword = current.SMTPPassword
+	}
+	if err := s.settingsRepository.SetAll(ctx, settings.values()); err != nil {
+		return fmt.Errorf("cannot save digest settings: %w", err)
+	}
+	return nil
+}
+
+// SendTest sends digest for the last period right away, regardless of the schedule.
+func (s Service) SendTest(ctx context.Context) error {
+	settings, err := loadSettings(ctx, s.settingsRepository)
+	if err != nil {
+		return err
+	}
+	if !settings.configured() {
+		return ErrNotConfigured
+	}
+	now := s.nowFn()
+	return s.send(ctx, settings, now.Add(-settings.period()), true)
+}
+
+// RunDue sends digest when it's enabled and hasn't been sent since it was last scheduled. Digest covers
+// entries created since the previous digest, but no earlier than one period before schedule, empty
+// digests aren't sent.
+func (s Service) RunDue(ctx context.Context) error {
+	settings, err := loadSettings(ctx, s.settingsRepository)
+	if err != nil {
+		return err
+	}
+	now := s.nowFn()
+	scheduledAt := settings.scheduledAt(now)
+	if !settings.Enabled || !settings.LastSentAt.Before(scheduledAt) {
+		return nil
+	}
+	since := scheduledAt.Add(-settings.period())
+	if settings.LastSentAt.After(since) {
+		since = settings.LastSentAt
+	}
+	if err := s.send(ctx, settings, since, false); err != nil {
+		return err
+	}
+	values := map[string]string{lastSentAtKey: now.Format(time.RFC3339)}
+	if err := s.settingsRepository.SetAll(ctx, values); err != nil {
+		return fmt.Errorf("cannot save time digest was sent at: %w", err)
+	}
+	return nil
+}
+
+func (s Service) send(ctx context.Context, settings Settings, since time.Time, sendEmpty bool) error {
+	digest, err := s.digestBuilder.BuildDigest(ctx, settings.Mode, since)
+	if err != nil {
+		return fmt.Errorf("cannot build digest: %w", err)
+	}
+	if digest.Entries == 0 && !sendEmpty {
+		s.logger.Println("digest is empty, skipping")
+		return nil
+	}
+	text, html, err := render(digest)
+	if err != nil {
+		return fmt.Errorf("cannot render digest: %w", err)
+	}
+	subject := fmt.Sprintf("WebRSS digest: %d new entries", digest.Entries)
+	msg, err := message(settings, subject, text, html, digest.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("cannot prepare message: %w", err)
+	}
+	if err := sendMail(settings, msg); err != nil {
+		return fmt.Errorf("cannot send digest: %w", err)
+	}
+	return nil
+}
+
+// Run checks every interval whether digest is due.
+func (s Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RunDue(ctx); err != nil {
+			s.logger.Println("cannot send digest: ", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
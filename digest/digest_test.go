@@ -0,0 +1,395 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type settingsRepositoryMock struct {
+	values map[string]string
+}
+
+func (m *settingsRepositoryMock) Get(ctx context.Context, key string) (string, error) {
+	value, ok := m.values[key]
+	if !ok {
+		return "", fmt.Errorf("cannot fetch value of key %s: %w", key, sql.ErrNoRows)
+	}
+	return value, nil
+}
+
+func (m *settingsRepositoryMock) SetAll(ctx context.Context, values map[string]string) error {
+	for key, value := range values {
+		m.values[key] = value
+	}
+	return nil
+}
+
+type digestBuilderMock struct {
+	digest webrss.Digest
+	since  []time.Time
+}
+
+func (m *digestBuilderMock) BuildDigest(ctx context.Context, mode string, since time.Time) (webrss.Digest, error) {
+	m.since = append(m.since, since)
+	digest := m.digest
+	digest.Mode, digest.Since = mode, since
+	return digest, nil
+}
+
+// smtpServer is a local SMTP stand-in, it accepts every message and keeps it.
+type smtpServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	s := &smtpServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 localhost")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+func (s *smtpServer) settings() map[string]string {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return map[string]string{
+		recipientKey: "reader@example.com",
+		senderKey:    "webrss@example.com",
+		smtpHostKey:  host,
+		smtpPortKey:  port,
+	}
+}
+
+func (s *smtpServer) sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.messages...)
+}
+
+// messageParts returns subject and content of message parts by their content type.
+func messageParts(t *testing.T, raw string) (string, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	parts := map[string]string{}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		content, _ := ioutil.ReadAll(bufio.NewReader(part))
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(content)
+	}
+	return subject, parts
+}
+
+func testDigest() webrss.Digest {
+	return webrss.Digest{
+		CreatedAt: time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC),
+		Categories: []webrss.DigestCategory{{
+			Category: repository.Category{ID: 1, Title: "News"},
+			Entries: []repository.Entry{{
+				ID:          1,
+				Title:       "Rock & roll",
+				Link:        "https://example.com/rock",
+				PublishedAt: repository.NewTime(time.Date(2020, 1, 10, 10, 0, 0, 0, time.UTC)),
+				Feed:        repository.Feed{FeedTitle: "Example"},
+			}},
+		}},
+		Entries: 1,
+	}
+}
+
+func TestService_SendTest(t *testing.T) {
+	server := newSMTPServer(t)
+	defer server.listener.Close()
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	builder := &digestBuilderMock{digest: testDigest()}
+	s := Service{
+		nowFn:              func() time.Time { return now },
+		logger:             log.New(ioutil.Discard, "", 0),
+		settingsRepository: &settingsRepositoryMock{values: server.settings()},
+		digestBuilder:      builder,
+	}
+	if err := s.SendTest(context.Background()); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	if expected := now.Add(-24 * time.Hour); len(builder.since) != 1 || !builder.since[0].Equal(expected) {
+		t.Errorf("Expected digest since '%v', but got '%v'", expected, builder.since)
+	}
+	messages := server.sent()
+	if len(messages) != 1 {
+		t.Fatalf("Expected one message to be sent, but got '%d'", len(messages))
+	}
+	subject, parts := messageParts(t, messages[0])
+	if expected := "WebRSS digest: 1 new entries"; subject != expected {
+		t.Errorf("Expected subject to be '%s', but got '%s'", expected, subject)
+	}
+	for contentType, expected := range map[string][]string{
+		"text/plain": {"== News ==", "* Rock & roll", "https://example.com/rock"},
+		"text/html":  {"<h2 style=\"font-size: 1.1em; border-bottom: 1px solid #ddd;\">News</h2>", `<a href="https://example.com/rock">Rock &amp; roll</a>`},
+	} {
+		for _, fragment := range expected {
+			if !strings.Contains(parts[contentType], fragment) {
+				t.Errorf("Expected %s part to contain '%s', but got '%s'", contentType, fragment, parts[contentType])
+			}
+		}
+	}
+}
+
+func TestService_SendTest_notConfigured(t *testing.T) {
+	s := Service{
+		nowFn:              time.Now,
+		logger:             log.New(ioutil.Discard, "", 0),
+		settingsRepository: &settingsRepositoryMock{values: map[string]string{}},
+		digestBuilder:      &digestBuilderMock{},
+	}
+	if err := s.SendTest(context.Background()); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Expected err to be '%v', but got '%v'", ErrNotConfigured, err)
+	}
+}
+
+func TestService_RunDue(t *testing.T) {
+	// Friday
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		settings map[string]string
+		digest   webrss.Digest
+
+		expectedSince      []time.Time
+		expectedSent       int
+		expectedLastSentAt string
+	}{{
+		name:     "disabled",
+		settings: map[string]string{enabledKey: "false"},
+		digest:   testDigest(),
+	}, {
+		name:               "due for the first time",
+		settings:           map[string]string{enabledKey: "true", hourKey: "7"},
+		digest:             testDigest(),
+		expectedSince:      []time.Time{time.Date(2020, 1, 9, 7, 0, 0, 0, time.UTC)},
+		expectedSent:       1,
+		expectedLastSentAt: "2020-01-10T12:00:00Z",
+	}, {
+		name: "due since the last digest",
+		settings: map[string]string{enabledKey: "true", hourKey: "7",
+			lastSentAtKey: "2020-01-09T07:05:00Z"},
+		digest:             testDigest(),
+		expectedSince:      []time.Time{time.Date(2020, 1, 9, 7, 5, 0, 0, time.UTC)},
+		expectedSent:       1,
+		expectedLastSentAt: "2020-01-10T12:00:00Z",
+	}, {
+		name: "already sent",
+		settings: map[string]string{enabledKey: "true", hourKey: "7",
+			lastSentAtKey: "2020-01-10T07:05:00Z"},
+		digest:             testDigest(),
+		expectedLastSentAt: "2020-01-10T07:05:00Z",
+	}, {
+		name: "weekly digest sent this week",
+		settings: map[string]string{enabledKey: "true", hourKey: "7", frequencyKey: FrequencyWeekly,
+			weekdayKey: "1", lastSentAtKey: "2020-01-06T07:05:00Z"},
+		digest:             testDigest(),
+		expectedLastSentAt: "2020-01-06T07:05:00Z",
+	}, {
+		name:               "empty digest isn't sent",
+		settings:           map[string]string{enabledKey: "true", hourKey: "7"},
+		digest:             webrss.Digest{CreatedAt: now},
+		expectedSince:      []time.Time{time.Date(2020, 1, 9, 7, 0, 0, 0, time.UTC)},
+		expectedLastSentAt: "2020-01-10T12:00:00Z",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSMTPServer(t)
+			defer server.listener.Close()
+			values := server.settings()
+			for key, value := range tt.settings {
+				values[key] = value
+			}
+			builder := &digestBuilderMock{digest: tt.digest}
+			s := Service{
+				nowFn:              func() time.Time { return now },
+				logger:             log.New(ioutil.Discard, "", 0),
+				settingsRepository: &settingsRepositoryMock{values: values},
+				digestBuilder:      builder,
+			}
+			if err := s.RunDue(context.Background()); err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if len(builder.since) != len(tt.expectedSince) {
+				t.Fatalf("Expected digest to be built since '%v', but got '%v'", tt.expectedSince, builder.since)
+			}
+			for i := range builder.since {
+				if !builder.since[i].Equal(tt.expectedSince[i]) {
+					t.Errorf("Expected digest to be built since '%v', but got '%v'", tt.expectedSince[i], builder.since[i])
+				}
+			}
+			if sent := len(server.sent()); sent != tt.expectedSent {
+				t.Errorf("Expected sent messages to be '%d', but got '%d'", tt.expectedSent, sent)
+			}
+			if lastSentAt := values[lastSentAtKey]; lastSentAt != tt.expectedLastSentAt {
+				t.Errorf("Expected last sent at to be '%s', but got '%s'", tt.expectedLastSentAt, lastSentAt)
+			}
+		})
+	}
+}
+
+func TestSettings_scheduledAt(t *testing.T) {
+	// Friday
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		settings Settings
+
+		expected time.Time
+	}{{
+		name:     "daily, later today",
+		settings: Settings{Frequency: FrequencyDaily, Hour: 18},
+		expected: time.Date(2020, 1, 9, 18, 0, 0, 0, time.UTC),
+	}, {
+		name:     "daily, earlier today",
+		settings: Settings{Frequency: FrequencyDaily, Hour: 7},
+		expected: time.Date(2020, 1, 10, 7, 0, 0, 0, time.UTC),
+	}, {
+		name:     "weekly, today",
+		settings: Settings{Frequency: FrequencyWeekly, Weekday: int(time.Friday), Hour: 7},
+		expected: time.Date(2020, 1, 10, 7, 0, 0, 0, time.UTC),
+	}, {
+		name:     "weekly, later today",
+		settings: Settings{Frequency: FrequencyWeekly, Weekday: int(time.Friday), Hour: 18},
+		expected: time.Date(2020, 1, 3, 18, 0, 0, 0, time.UTC),
+	}, {
+		name:     "weekly, earlier this week",
+		settings: Settings{Frequency: FrequencyWeekly, Weekday: int(time.Monday), Hour: 7},
+		expected: time.Date(2020, 1, 6, 7, 0, 0, 0, time.UTC),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.settings.scheduledAt(now); !result.Equal(tt.expected) {
+				t.Errorf("Expected scheduled at to be '%v', but got '%v'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestService_SaveSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+
+		expectedErr      error
+		expectedPassword string
+	}{{
+		name:             "keeps current password",
+		settings:         Settings{Frequency: FrequencyDaily, Mode: webrss.DigestModeUnread, SMTPPort: 25},
+		expectedPassword: "secret",
+	}, {
+		name:             "changes password",
+		settings:         Settings{Frequency: FrequencyDaily, Mode: webrss.DigestModeUnread, SMTPPort: 25, SMTPPassword: "new"},
+		expectedPassword: "new",
+	}, {
+		name:             "enabled without recipient",
+		settings:         Settings{Enabled: true, Frequency: FrequencyDaily, Mode: webrss.DigestModeUnread, SMTPPort: 25, SMTPHost: "localhost"},
+		expectedErr:      ErrInvalidSettings,
+		expectedPassword: "secret",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedSettingsRepository := &settingsRepositoryMock{values: map[string]string{smtpPasswordKey: "secret"}}
+			s := Service{
+				nowFn:              time.Now,
+				logger:             log.New(ioutil.Discard, "", 0),
+				settingsRepository: mockedSettingsRepository,
+			}
+			if err := s.SaveSettings(context.Background(), tt.settings); !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if password := mockedSettingsRepository.values[smtpPasswordKey]; password != tt.expectedPassword {
+				t.Errorf("Expected password to be '%s', but got '%s'", tt.expectedPassword, password)
+			}
+			settings, err := s.Settings(context.Background())
+			if err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if settings.SMTPPassword != "" {
+				t.Errorf("Expected password to be hidden, but got '%s'", settings.SMTPPassword)
+			}
+			if tt.expectedErr == nil && mockedSettingsRepository.values[smtpPortKey] != strconv.Itoa(tt.settings.SMTPPort) {
+				t.Errorf("Expected port to be saved, but got '%s'", mockedSettingsRepository.values[smtpPortKey])
+			}
+		})
+	}
+}
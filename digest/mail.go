@@ -0,0 +1,105 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+const smtpTimeout = 30 * time.Second
+
+// message returns MIME message with text and HTML alternatives of the same content.
+func message(settings Settings, subject, text, html string, now time.Time) ([]byte, error) {
+	b := bytes.Buffer{}
+	w := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "From: %s\r\n", settings.Sender)
+	fmt.Fprintf(&b, "To: %s\r\n", settings.Recipient)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot create message part: %w", err)
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("cannot write message part: %w", err)
+		}
+		if err := qw.Close(); err != nil {
+			return nil, fmt.Errorf("cannot write message part: %w", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("cannot finish message: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// sendMail delivers message to the recipient, STARTTLS is used when server supports it, authentication
+// only when username is set.
+func sendMail(settings Settings, msg []byte) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(settings.SMTPHost, strconv.Itoa(settings.SMTPPort)), smtpTimeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to SMTP server: %w", err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("cannot set deadline: %w", err)
+	}
+	c, err := smtp.NewClient(conn, settings.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("cannot start SMTP session: %w", err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: settings.SMTPHost}); err != nil {
+			return fmt.Errorf("cannot start TLS: %w", err)
+		}
+	}
+	if settings.SMTPUsername != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("SMTP server doesn't support authentication")
+		}
+		if err := c.Auth(smtp.PlainAuth("", settings.SMTPUsername, settings.SMTPPassword, settings.SMTPHost)); err != nil {
+			return fmt.Errorf("cannot authenticate: %w", err)
+		}
+	}
+	if err := c.Mail(settings.Sender); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	if err := c.Rcpt(settings.Recipient); err != nil {
+		return fmt.Errorf("recipient rejected: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("cannot send message: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("cannot send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("cannot send message: %w", err)
+	}
+	return c.Quit()
+}
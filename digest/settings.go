@@ -0,0 +1,182 @@
+package digest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Alkemic/webrss/webrss"
+)
+
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+
+	defaultSMTPPort = 587
+
+	enabledKey      = "digest_enabled"
+	frequencyKey    = "digest_frequency"
+	weekdayKey      = "digest_weekday"
+	hourKey         = "digest_hour"
+	modeKey         = "digest_mode"
+	recipientKey    = "digest_recipient"
+	senderKey       = "digest_sender"
+	smtpHostKey     = "digest_smtp_host"
+	smtpPortKey     = "digest_smtp_port"
+	smtpUsernameKey = "digest_smtp_username"
+	smtpPasswordKey = "digest_smtp_password"
+	lastSentAtKey   = "digest_last_sent_at"
+)
+
+var (
+	ErrInvalidSettings = errors.New("invalid digest settings")
+	ErrNotConfigured   = errors.New("digest isn't configured")
+)
+
+type settingsRepository interface {
+	Get(ctx context.Context, key string) (string, error)
+	SetAll(ctx context.Context, values map[string]string) error
+}
+
+// Settings of the digest are kept in the settings table, missing keys have default values. Weekly digest is sent
+// on Weekday, both daily and weekly are sent at Hour of the server's local time.
+type Settings struct {
+	Enabled      bool      `json:"enabled"`
+	Frequency    string    `json:"frequency"`
+	Weekday      int       `json:"weekday"`
+	Hour         int       `json:"hour"`
+	Mode         string    `json:"mode"`
+	Recipient    string    `json:"recipient"`
+	Sender       string    `json:"sender"`
+	SMTPHost     string    `json:"smtp_host"`
+	SMTPPort     int       `json:"smtp_port"`
+	SMTPUsername string    `json:"smtp_username"`
+	SMTPPassword string    `json:"-"`
+	LastSentAt   time.Time `json:"last_sent_at"`
+}
+
+func defaultSettings() Settings {
+	return Settings{
+		Frequency: FrequencyDaily,
+		Weekday:   int(time.Monday),
+		Hour:      7,
+		Mode:      webrss.DigestModeUnread,
+		SMTPPort:  defaultSMTPPort,
+	}
+}
+
+func (s Settings) validate() error {
+	if s.Frequency != FrequencyDaily && s.Frequency != FrequencyWeekly {
+		return fmt.Errorf("%w: unknown frequency '%s'", ErrInvalidSettings, s.Frequency)
+	}
+	if s.Mode != webrss.DigestModeUnread && s.Mode != webrss.DigestModeHighlights {
+		return fmt.Errorf("%w: unknown mode '%s'", ErrInvalidSettings, s.Mode)
+	}
+	if s.Weekday < 0 || s.Weekday > 6 {
+		return fmt.Errorf("%w: weekday out of range", ErrInvalidSettings)
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return fmt.Errorf("%w: hour out of range", ErrInvalidSettings)
+	}
+	if s.SMTPPort < 1 || s.SMTPPort > 65535 {
+		return fmt.Errorf("%w: port out of range", ErrInvalidSettings)
+	}
+	if s.Enabled && !s.configured() {
+		return fmt.Errorf("%w: recipient, sender and SMTP host are required", ErrInvalidSettings)
+	}
+	return nil
+}
+
+func (s Settings) configured() bool {
+	return s.Recipient != "" && s.Sender != "" && s.SMTPHost != ""
+}
+
+// period returns duration between digests.
+func (s Settings) period() time.Duration {
+	if s.Frequency == FrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// scheduledAt returns the latest time digest was due to be sent at, not later than now.
+func (s Settings) scheduledAt(now time.Time) time.Time {
+	scheduledAt := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, 0, 0, 0, now.Location())
+	if scheduledAt.After(now) {
+		scheduledAt = scheduledAt.AddDate(0, 0, -1)
+	}
+	if s.Frequency == FrequencyWeekly {
+		for scheduledAt.Weekday() != time.Weekday(s.Weekday) {
+			scheduledAt = scheduledAt.AddDate(0, 0, -1)
+		}
+	}
+	return scheduledAt
+}
+
+func (s Settings) values() map[string]string {
+	return map[string]string{
+		enabledKey:      strconv.FormatBool(s.Enabled),
+		frequencyKey:    s.Frequency,
+		weekdayKey:      strconv.Itoa(s.Weekday),
+		hourKey:         strconv.Itoa(s.Hour),
+		modeKey:         s.Mode,
+		recipientKey:    s.Recipient,
+		senderKey:       s.Sender,
+		smtpHostKey:     s.SMTPHost,
+		smtpPortKey:     strconv.Itoa(s.SMTPPort),
+		smtpUsernameKey: s.SMTPUsername,
+		smtpPasswordKey: s.SMTPPassword,
+	}
+}
+
+func loadSettings(ctx context.Context, repo settingsRepository) (Settings, error) {
+	settings := defaultSettings()
+	values := map[string]string{}
+	for _, key := range []string{enabledKey, frequencyKey, weekdayKey, hourKey, modeKey, recipientKey, senderKey,
+		smtpHostKey, smtpPortKey, smtpUsernameKey, smtpPasswordKey, lastSentAtKey} {
+		value, err := repo.Get(ctx, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return Settings{}, fmt.Errorf("cannot fetch digest settings: %w", err)
+		}
+		values[key] = value
+	}
+	var err error
+	for key, value := range values {
+		switch key {
+		case enabledKey:
+			settings.Enabled, err = strconv.ParseBool(value)
+		case frequencyKey:
+			settings.Frequency = value
+		case weekdayKey:
+			settings.Weekday, err = strconv.Atoi(value)
+		case hourKey:
+			settings.Hour, err = strconv.Atoi(value)
+		case modeKey:
+			settings.Mode = value
+		case recipientKey:
+			settings.Recipient = value
+		case senderKey:
+			settings.Sender = value
+		case smtpHostKey:
+			settings.SMTPHost = value
+		case smtpPortKey:
+			settings.SMTPPort, err = strconv.Atoi(value)
+		case smtpUsernameKey:
+			settings.SMTPUsername = value
+		case smtpPasswordKey:
+			settings.SMTPPassword = value
+		case lastSentAtKey:
+			settings.LastSentAt, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			return Settings{}, fmt.Errorf("cannot parse value of %s: %w", key, err)
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,67 @@
+package digest
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/Alkemic/webrss/webrss"
+)
+
+var templateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04")
+	},
+	"highlights": func(mode string) bool {
+		return mode == webrss.DigestModeHighlights
+	},
+}
+
+var textTemplate = texttemplate.Must(texttemplate.New("digest.txt").Funcs(templateFuncs).Parse(
+	`WebRSS {{ if highlights .Mode }}highlights{{ else }}unread entries{{ end }} since {{ date .Since }}
+{{ range .Categories }}
+== {{ .Category.Title }} ==
+{{ range .Entries }}
+* {{ .Title }}
+  {{ .Feed.FeedTitle }}, {{ date .PublishedAt.Time }}
+  {{ .Link }}
+{{ end }}{{ else }}
+No new entries.
+{{ end }}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>WebRSS digest</title>
+</head>
+<body style="font-family: sans-serif; color: #222; max-width: 40em;">
+<h1 style="font-size: 1.3em;">WebRSS {{ if highlights .Mode }}highlights{{ else }}unread entries{{ end }} since {{ date .Since }}</h1>
+{{ range .Categories }}
+<h2 style="font-size: 1.1em; border-bottom: 1px solid #ddd;">{{ .Category.Title }}</h2>
+<ul style="padding-left: 1.2em;">
+{{ range .Entries }}
+<li style="margin-bottom: 0.5em;"><a href="{{ .Link }}">{{ .Title }}</a><br>
+<small style="color: #666;">{{ .Feed.FeedTitle }}, {{ date .PublishedAt.Time }}</small></li>
+{{ end }}
+</ul>
+{{ else }}
+<p>No new entries.</p>
+{{ end }}
+</body>
+</html>
+`))
+
+// render returns digest as plain text and HTML.
+func render(digest webrss.Digest) (string, string, error) {
+	text := strings.Builder{}
+	if err := textTemplate.Execute(&text, digest); err != nil {
+		return "", "", err
+	}
+	html := strings.Builder{}
+	if err := htmlTemplate.Execute(&html, digest); err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
@@ -0,0 +1,293 @@
+// Package epub builds EPUB 3 books of entries, with their images downloaded and embedded in the book.
+package epub
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/sanitize"
+)
+
+const (
+	// MediaType is the content type of generated books.
+	MediaType = "application/epub+zip"
+	// maxImages is the number of images downloaded for a single book, the rest is left out.
+	maxImages = 200
+	language  = "en"
+)
+
+// imageTypes maps embedded image types to file extensions.
+var imageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type chapter struct {
+	ID    string
+	File  string
+	Title string
+}
+
+type embeddedImage struct {
+	ID        string
+	File      string
+	MediaType string
+}
+
+// book holds state of the book being written.
+type book struct {
+	Identifier string
+	Title      string
+	Language   string
+	Modified   string
+	Chapters   []chapter
+	Images     []embeddedImage
+
+	zip        *zip.Writer
+	modifiedAt time.Time
+	// images maps URLs of downloaded images to their files, failed downloads are mapped to empty string
+	images map[string]string
+}
+
+type Generator struct {
+	nowFn      func() time.Time
+	logger     *log.Logger
+	httpClient *http.Client
+}
+
+func New(logger *log.Logger, httpClient *http.Client) Generator {
+	return Generator{
+		nowFn:      time.Now,
+		logger:     logger,
+		httpClient: httpClient,
+	}
+}
+
+// Write writes book with entries as its chapters, in the given order. Images that cannot be downloaded
+// are replaced by their alternative text.
+func (g Generator) Write(ctx context.Context, w io.Writer, title string, entries []repository.Entry) error {
+	now := g.nowFn()
+	b := &book{
+		Identifier: "urn:uuid:" + uuid.New().String(),
+		Title:      title,
+		Language:   language,
+		Modified:   now.UTC().Format("2006-01-02T15:04:05Z"),
+		zip:        zip.NewWriter(w),
+		modifiedAt: now,
+		images:     map[string]string{},
+	}
+	// mimetype has to be the first file, stored uncompressed
+	if err := b.writeFile("mimetype", zip.Store, []byte(MediaType)); err != nil {
+		return err
+	}
+	if err := b.writeFile("META-INF/container.xml", zip.Deflate, []byte(containerXML)); err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		if err := g.writeChapter(ctx, b, i+1, entry); err != nil {
+			return err
+		}
+	}
+	if err := b.writeFile("OEBPS/style.css", zip.Deflate, []byte(styleCSS)); err != nil {
+		return err
+	}
+	if err := b.writeTemplate("OEBPS/nav.xhtml", navTemplate, b); err != nil {
+		return err
+	}
+	if err := b.writeTemplate("OEBPS/toc.ncx", ncxTemplate, b); err != nil {
+		return err
+	}
+	if err := b.writeTemplate("OEBPS/content.opf", packageTemplate, b); err != nil {
+		return err
+	}
+	if err := b.zip.Close(); err != nil {
+		return fmt.Errorf("cannot finish book: %w", err)
+	}
+	return nil
+}
+
+func (g Generator) writeChapter(ctx context.Context, b *book, n int, entry repository.Entry) error {
+	title := strings.TrimSpace(entry.Title)
+	if title == "" {
+		title = entry.Link
+	}
+	base, err := url.Parse(entry.Link)
+	if err != nil {
+		base = nil
+	}
+	content := g.embedImages(ctx, b, sanitize.HTML(entry.Summary.String, base))
+	ch := chapter{
+		ID:    fmt.Sprintf("entry-%d", n),
+		File:  fmt.Sprintf("entry-%d.xhtml", n),
+		Title: title,
+	}
+	data := struct {
+		Title    string
+		Language string
+		Entry    repository.Entry
+		Content  string
+	}{
+		Title:    title,
+		Language: b.Language,
+		Entry:    entry,
+		Content:  content,
+	}
+	if err := b.writeTemplate("OEBPS/"+ch.File, chapterTemplate, data); err != nil {
+		return err
+	}
+	b.Chapters = append(b.Chapters, ch)
+	return nil
+}
+
+// embedImages turns sanitized content into XHTML, with images pointing to their embedded copies.
+func (g Generator) embedImages(ctx context.Context, b *book, content string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	out := strings.Builder{}
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		switch tokenType {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(xmlText(token.Data)))
+			continue
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token.Attr = xmlAttrs(token.Attr)
+		}
+		if token.DataAtom == atom.Img {
+			file := g.embedImage(ctx, b, attr(token, "src"))
+			if file == "" {
+				out.WriteString(html.EscapeString(xmlText(attr(token, "alt"))))
+				continue
+			}
+			for i := range token.Attr {
+				if token.Attr[i].Key == "src" {
+					token.Attr[i].Val = file
+				}
+			}
+			if attr(token, "alt") == "" {
+				// alt is required in XHTML content documents
+				token.Attr = append(token.Attr, html.Attribute{Key: "alt", Val: ""})
+			}
+		}
+		out.WriteString(token.String())
+	}
+	return out.String()
+}
+
+// embedImage downloads image and adds it to the book, returns its file or empty string when image
+// couldn't be embedded.
+func (g Generator) embedImage(ctx context.Context, b *book, src string) string {
+	if file, ok := b.images[src]; ok {
+		return file
+	}
+	parsed, err := url.Parse(src)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || len(b.Images) >= maxImages {
+		return ""
+	}
+	b.images[src] = ""
+	body, mediaType, err := g.download(ctx, src)
+	if err != nil {
+		g.logger.Printf("cannot download image %s: %v\n", src, err)
+		return ""
+	}
+	img := embeddedImage{
+		ID:        fmt.Sprintf("image-%d", len(b.Images)+1),
+		File:      fmt.Sprintf("images/image-%d%s", len(b.Images)+1, imageTypes[mediaType]),
+		MediaType: mediaType,
+	}
+	// images are compressed already
+	if err := b.writeFile("OEBPS/"+img.File, zip.Store, body); err != nil {
+		g.logger.Println("cannot embed image: ", err)
+		return ""
+	}
+	b.Images = append(b.Images, img)
+	b.images[src] = img.File
+	return img.File
+}
+
+// download fetches image, its type is detected from the content.
+func (g Generator) download(ctx context.Context, src string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot create request: %w", err)
+	}
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot read image: %w", err)
+	}
+	mediaType := http.DetectContentType(body)
+	if _, ok := imageTypes[mediaType]; !ok {
+		return nil, "", fmt.Errorf("unsupported image type %s", mediaType)
+	}
+	return body, mediaType, nil
+}
+
+func (b *book) writeFile(name string, method uint16, content []byte) error {
+	w, err := b.zip.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: b.modifiedAt})
+	if err != nil {
+		return fmt.Errorf("cannot create %s: %w", name, err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("cannot write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (b *book) writeTemplate(name string, tmpl *template.Template, data interface{}) error {
+	content := strings.Builder{}
+	if err := tmpl.Execute(&content, data); err != nil {
+		return fmt.Errorf("cannot render %s: %w", name, err)
+	}
+	return b.writeFile(name, zip.Deflate, []byte(content.String()))
+}
+
+func attr(token html.Token, key string) string {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// xmlAttrs keeps only the first of repeated attributes, which aren't allowed in XML, and removes
+// disallowed characters from their values.
+func xmlAttrs(attrs []html.Attribute) []html.Attribute {
+	seen := map[string]bool{}
+	unique := attrs[:0]
+	for _, a := range attrs {
+		if !seen[a.Key] {
+			seen[a.Key] = true
+			a.Val = xmlText(a.Val)
+			unique = append(unique, a)
+		}
+	}
+	return unique
+}
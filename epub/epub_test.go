@@ -0,0 +1,152 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.Black)
+	b := bytes.Buffer{}
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatalf("cannot encode image: %v", err)
+	}
+	return b.Bytes()
+}
+
+func readFiles(t *testing.T, book []byte) ([]*zip.File, map[string]string) {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatalf("Expected book to be zip archive, but got '%v'", err)
+	}
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("cannot open %s: %v", f.Name, err)
+		}
+		content, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return r.File, files
+}
+
+func wellFormed(content string) error {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func TestGenerator_Write(t *testing.T) {
+	imageContent := pngImage(t)
+	imageRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/image.png":
+			imageRequests++
+			rw.Write(imageContent)
+		case "/text":
+			rw.Write([]byte("not an image"))
+		default:
+			http.NotFound(rw, req)
+		}
+	}))
+	defer server.Close()
+
+	entries := []repository.Entry{{
+		ID:    1,
+		Title: "First & <best>",
+		Link:  server.URL + "/first",
+		Summary: repository.NewNullString(`<p>Text&nbsp;with <b>bold <i>nested</b> image <img src="/image.png" alt="a" alt="b">` +
+			`<br><img src="image.png" title="same image"><script>alert(1)</script></p>` +
+			`<img src="/missing" alt="missing image"><img src="/text"><p>control` + "\x0b" + ` character</p>`),
+		PublishedAt: repository.NewTime(time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)),
+		Feed:        repository.Feed{FeedTitle: "Feed"},
+	}, {
+		ID:   2,
+		Link: server.URL + "/second",
+		Feed: repository.Feed{FeedTitle: "Feed"},
+	}}
+	g := New(log.New(ioutil.Discard, "", 0), server.Client())
+	g.nowFn = func() time.Time { return time.Date(2020, 1, 11, 8, 0, 0, 0, time.UTC) }
+	b := bytes.Buffer{}
+	if err := g.Write(context.Background(), &b, "Export", entries); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+
+	zipFiles, files := readFiles(t, b.Bytes())
+	if zipFiles[0].Name != "mimetype" || zipFiles[0].Method != zip.Store || files["mimetype"] != MediaType {
+		t.Errorf("Expected the first file to be uncompressed mimetype, but got '%s'", zipFiles[0].Name)
+	}
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/toc.ncx",
+		"OEBPS/entry-1.xhtml", "OEBPS/entry-2.xhtml"} {
+		content, ok := files[name]
+		if !ok {
+			t.Errorf("Expected book to have '%s'", name)
+			continue
+		}
+		if err := wellFormed(content); err != nil {
+			t.Errorf("Expected '%s' to be well-formed XML, but got '%v':\n%s", name, err, content)
+		}
+	}
+	if imageRequests != 1 {
+		t.Errorf("Expected image to be downloaded '1' time, but got '%d'", imageRequests)
+	}
+	if files["OEBPS/images/image-1.png"] != string(imageContent) {
+		t.Errorf("Expected image to be embedded")
+	}
+	for _, expected := range []string{
+		`<img src="images/image-1.png" alt="a"/>`,
+		`<img src="images/image-1.png" title="same image" alt=""/>`,
+		"missing image",
+		"Text\u00a0with",
+	} {
+		if !strings.Contains(files["OEBPS/entry-1.xhtml"], expected) {
+			t.Errorf("Expected chapter to contain '%s', but got:\n%s", expected, files["OEBPS/entry-1.xhtml"])
+		}
+	}
+	if strings.Contains(files["OEBPS/entry-1.xhtml"], "alert") {
+		t.Errorf("Expected chapter content to be sanitized, but got:\n%s", files["OEBPS/entry-1.xhtml"])
+	}
+	for _, expected := range []string{
+		`<item id="image-1" href="images/image-1.png" media-type="image/png"/>`,
+		`<itemref idref="entry-2"/>`,
+		`<meta property="dcterms:modified">2020-01-11T08:00:00Z</meta>`,
+	} {
+		if !strings.Contains(files["OEBPS/content.opf"], expected) {
+			t.Errorf("Expected package to contain '%s', but got:\n%s", expected, files["OEBPS/content.opf"])
+		}
+	}
+	for _, expected := range []string{
+		`<a href="entry-1.xhtml">First &amp; &lt;best&gt;</a>`,
+		`<a href="entry-2.xhtml">` + server.URL + `/second</a>`,
+	} {
+		if !strings.Contains(files["OEBPS/nav.xhtml"], expected) {
+			t.Errorf("Expected table of contents to contain '%s', but got:\n%s", expected, files["OEBPS/nav.xhtml"])
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package epub
+
+import (
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var templateFuncs = map[string]interface{}{
+	// x escapes text for XHTML and XML documents
+	"x": func(s string) string {
+		return html.EscapeString(xmlText(s))
+	},
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04")
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+}
+
+// xmlText removes characters that aren't allowed in XML documents.
+func xmlText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r <= 0xd7ff) || (r >= 0xe000 && r <= 0xfffd) ||
+			(r >= 0x10000 && r <= 0x10ffff) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const styleCSS = `body { font-family: serif; line-height: 1.4; }
+h1 { font-size: 1.4em; }
+.meta { color: #666; font-size: 0.85em; }
+img { max-width: 100%; height: auto; }
+pre { white-space: pre-wrap; }
+`
+
+var chapterTemplate = template.Must(template.New("chapter.xhtml").Funcs(templateFuncs).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{ .Language }}" xml:lang="{{ .Language }}">
+<head>
+<meta charset="UTF-8"/>
+<title>{{ x .Title }}</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+<article>
+<h1>{{ x .Title }}</h1>
+<p class="meta">{{ x .Entry.Feed.FeedTitle }}{{ if .Entry.Author.String }}, {{ x .Entry.Author.String }}{{ end }}, {{ date .Entry.PublishedAt.Time }}<br/>
+<a href="{{ x .Entry.Link }}">{{ x .Entry.Link }}</a></p>
+{{ .Content }}
+</article>
+</body>
+</html>
+`))
+
+var navTemplate = template.Must(template.New("nav.xhtml").Funcs(templateFuncs).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{ .Language }}" xml:lang="{{ .Language }}">
+<head>
+<meta charset="UTF-8"/>
+<title>{{ x .Title }}</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>{{ x .Title }}</h1>
+<ol>
+{{ range .Chapters }}<li><a href="{{ .File }}">{{ x .Title }}</a></li>
+{{ end }}</ol>
+</nav>
+</body>
+</html>
+`))
+
+var ncxTemplate = template.Must(template.New("toc.ncx").Funcs(templateFuncs).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head>
+<meta name="dtb:uid" content="{{ .Identifier }}"/>
+</head>
+<docTitle><text>{{ x .Title }}</text></docTitle>
+<navMap>
+{{ range $i, $chapter := .Chapters }}<navPoint id="nav-{{ $chapter.ID }}" playOrder="{{ inc $i }}"><navLabel><text>{{ x $chapter.Title }}</text></navLabel><content src="{{ $chapter.File }}"/></navPoint>
+{{ end }}</navMap>
+</ncx>
+`))
+
+var packageTemplate = template.Must(template.New("content.opf").Funcs(templateFuncs).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="{{ .Language }}">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">{{ .Identifier }}</dc:identifier>
+<dc:title>{{ x .Title }}</dc:title>
+<dc:language>{{ .Language }}</dc:language>
+<dc:creator>WebRSS</dc:creator>
+<meta property="dcterms:modified">{{ .Modified }}</meta>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="style" href="style.css" media-type="text/css"/>
+{{ range .Chapters }}<item id="{{ .ID }}" href="{{ .File }}" media-type="application/xhtml+xml"/>
+{{ end }}{{ range .Images }}<item id="{{ .ID }}" href="{{ .File }}" media-type="{{ .MediaType }}"/>
+{{ end }}</manifest>
+<spine toc="ncx">
+<itemref idref="nav"/>
+{{ range .Chapters }}<itemref idref="{{ .ID }}"/>
+{{ end }}</spine>
+</package>
+`))
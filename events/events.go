@@ -0,0 +1,113 @@
+// Package events broadcasts live updates to subscribers, keeping recent events so reconnecting
+// subscribers can catch up on what they missed.
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+const (
+	EntryCreated  = "entry.created"
+	FeedUpdated   = "feed.updated"
+	FeedError     = "feed.error"
+	UnreadUpdated = "unread.updated"
+
+	// historySize is the number of the latest events kept for reconnecting subscribers.
+	historySize = 512
+	// subscriberBuffer is the number of events waiting for subscriber, slower subscribers are dropped
+	// and have to reconnect.
+	subscriberBuffer = 64
+)
+
+type Event struct {
+	ID   int64
+	Type string
+	Data []byte
+}
+
+type Broker struct {
+	logger *log.Logger
+
+	mu          sync.Mutex
+	lastID      int64
+	history     []Event
+	subscribers map[chan Event]struct{}
+}
+
+func New(logger *log.Logger) *Broker {
+	return &Broker{
+		logger:      logger,
+		history:     make([]Event, 0, historySize),
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Publish sends event with data serialized to JSON to all subscribers.
+func (b *Broker) Publish(eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		b.logger.Printf("cannot serialize event %s: %v\n", eventType, err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, Data: payload}
+	if len(b.history) == historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:historySize-1]
+	}
+	b.history = append(b.history, event)
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// HasSubscribers returns whether anyone is subscribed to events.
+func (b *Broker) HasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers) > 0
+}
+
+// Subscribe returns events published after lastEventID that are still kept, and channel receiving new
+// ones, which is closed when subscriber falls behind. Returned bool is false when some events after
+// lastEventID are no longer available, zero lastEventID means subscriber isn't interested in past events.
+// Unsubscribe has to be called when subscriber is done.
+func (b *Broker) Subscribe(lastEventID int64) ([]Event, bool, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan Event, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	if lastEventID == 0 || lastEventID == b.lastID {
+		return nil, true, ch, unsubscribe
+	}
+	// ids are restarted with the application, so subscriber may be ahead of them
+	if lastEventID > b.lastID || len(b.history) == 0 || b.history[0].ID > lastEventID+1 {
+		return nil, false, ch, unsubscribe
+	}
+	backlog := make([]Event, 0, b.lastID-lastEventID)
+	for _, event := range b.history {
+		if event.ID > lastEventID {
+			backlog = append(backlog, event)
+		}
+	}
+	return backlog, true, ch, unsubscribe
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+)
+
+func eventIDs(events []Event) []int64 {
+	ids := []int64{}
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestBroker_Subscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		published   int
+		lastEventID int64
+
+		expectedBacklog  []int64
+		expectedComplete bool
+	}{{
+		name:             "new subscriber",
+		published:        3,
+		expectedBacklog:  []int64{},
+		expectedComplete: true,
+	}, {
+		name:             "subscriber up to date",
+		published:        3,
+		lastEventID:      3,
+		expectedBacklog:  []int64{},
+		expectedComplete: true,
+	}, {
+		name:             "subscriber reconnecting",
+		published:        5,
+		lastEventID:      2,
+		expectedBacklog:  []int64{3, 4, 5},
+		expectedComplete: true,
+	}, {
+		name:             "subscriber missed events no longer kept",
+		published:        historySize + 10,
+		lastEventID:      5,
+		expectedBacklog:  []int64{},
+		expectedComplete: false,
+	}, {
+		name:             "subscriber ahead after restart",
+		published:        2,
+		lastEventID:      10,
+		expectedBacklog:  []int64{},
+		expectedComplete: false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(log.New(ioutil.Discard, "", 0))
+			for i := 0; i < tt.published; i++ {
+				b.Publish(EntryCreated, map[string]int{"id": i})
+			}
+			backlog, complete, _, unsubscribe := b.Subscribe(tt.lastEventID)
+			defer unsubscribe()
+			if ids := eventIDs(backlog); !reflect.DeepEqual(ids, tt.expectedBacklog) {
+				t.Errorf("Expected backlog to be '%v', but got '%v'", tt.expectedBacklog, ids)
+			}
+			if complete != tt.expectedComplete {
+				t.Errorf("Expected complete to be '%t', but got '%t'", tt.expectedComplete, complete)
+			}
+		})
+	}
+}
+
+func TestBroker_Publish(t *testing.T) {
+	b := New(log.New(ioutil.Discard, "", 0))
+	_, _, stream, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+
+	b.Publish(FeedUpdated, map[string]int{"feed_id": 1})
+	event := <-stream
+	expected := Event{ID: 1, Type: FeedUpdated, Data: []byte(`{"feed_id":1}`)}
+	if !reflect.DeepEqual(event, expected) {
+		t.Errorf("Expected event to be '%v', but got '%v'", expected, event)
+	}
+}
+
+func TestBroker_Publish_slowSubscriber(t *testing.T) {
+	b := New(log.New(ioutil.Discard, "", 0))
+	_, _, stream, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.Publish(EntryCreated, i)
+	}
+	received := 0
+	for range stream {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("Expected '%d' events before stream was closed, but got '%d'", subscriberBuffer, received)
+	}
+}
+
+func TestBroker_HasSubscribers(t *testing.T) {
+	b := New(log.New(ioutil.Discard, "", 0))
+	if b.HasSubscribers() {
+		t.Error("Expected broker not to have subscribers")
+	}
+	_, _, _, unsubscribe := b.Subscribe(0)
+	if !b.HasSubscribers() {
+		t.Error("Expected broker to have subscribers")
+	}
+	unsubscribe()
+	if b.HasSubscribers() {
+		t.Error("Expected broker not to have subscribers after unsubscribing")
+	}
+}
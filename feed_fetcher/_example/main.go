@@ -4,19 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"log"
-	"net/http"
 	"os"
 	"time"
 
 	"github.com/mmcdole/gofeed"
 
 	"github.com/Alkemic/webrss/feed_fetcher"
+	"github.com/Alkemic/webrss/httpclient"
 )
 
 func main() {
 	url := os.Args[1]
 	fp := gofeed.NewParser()
-	fetcher := feed_fetcher.NewFeedParser(fp, &http.Client{})
+	httpClient := httpclient.New(httpclient.Config{
+		ConnectTimeout: 10 * time.Second,
+		HeaderTimeout:  20 * time.Second,
+		Timeout:        time.Minute,
+		MaxBodySize:    10 << 20,
+	})
+	fetcher := feed_fetcher.NewFeedParser(fp, httpClient, httpClient)
 	start := time.Now()
 	ctx := context.Background()
 	feed, err := fetcher.Fetch(ctx, url)
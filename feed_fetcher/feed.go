@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/mmcdole/gofeed"
@@ -17,6 +18,8 @@ type Feed struct {
 	parsedFeed *gofeed.Feed
 	feedURL    string
 	httpClient *http.Client
+	statusCode int
+	size       int
 }
 
 func New(parsedFeed *gofeed.Feed, httpClient *http.Client, feedURL string) Feed {
@@ -27,6 +30,16 @@ func New(parsedFeed *gofeed.Feed, httpClient *http.Client, feedURL string) Feed
 	}
 }
 
+// StatusCode returns status code of the response feed was fetched from, zero if request failed.
+func (f Feed) StatusCode() int {
+	return f.statusCode
+}
+
+// Size returns number of bytes received when fetching feed.
+func (f Feed) Size() int {
+	return f.size
+}
+
 func (f Feed) Feed(ctx context.Context) repository.Feed {
 	faviconUrl, faviconContent, err := favicon.GetFavicon(ctx, f.httpClient, f.parsedFeed.Link)
 	if errors.Is(err, favicon.ErrCannotParse) {
@@ -59,11 +72,17 @@ func (f Feed) Entries(_ context.Context) []repository.Entry {
 		if len(summary) == 0 {
 			summary = item.Description
 		}
+		var tags repository.NullString
+		if len(item.Categories) > 0 {
+			tags = repository.NewNullString(joinTags(item.Categories))
+		}
 		entries = append(entries, repository.Entry{
 			Title:       item.Title,
 			Summary:     repository.NewNullString(summary),
 			Author:      parseAuthor(item.Author),
 			Link:        item.Link,
+			Tags:        tags,
+			Enclosure:   parseEnclosure(item.Enclosures),
 			PublishedAt: publishedAt,
 		})
 	}
@@ -77,3 +96,31 @@ func parseAuthor(feedAuthor *gofeed.Person) repository.NullString {
 	}
 	return repository.NewNullString(feedAuthor.Name)
 }
+
+// maxEnclosureLength is the size of entry's enclosure column.
+const maxEnclosureLength = 1024
+
+// parseEnclosure returns URL of the first enclosure, URLs not fitting in the column are skipped.
+func parseEnclosure(enclosures []*gofeed.Enclosure) repository.NullString {
+	for _, enclosure := range enclosures {
+		if enclosure != nil && enclosure.URL != "" && len(enclosure.URL) <= maxEnclosureLength {
+			return repository.NewNullString(enclosure.URL)
+		}
+	}
+	return repository.NullString{}
+}
+
+// maxTagsLength is the size of entry's tags column.
+const maxTagsLength = 1024
+
+func joinTags(categories []string) string {
+	tags := strings.Join(categories, ",")
+	if len(tags) <= maxTagsLength {
+		return tags
+	}
+	tags = tags[:maxTagsLength]
+	if i := strings.LastIndex(tags, ","); i > 0 {
+		return tags[:i]
+	}
+	return ""
+}
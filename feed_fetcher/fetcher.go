@@ -1,8 +1,10 @@
 package feed_fetcher
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/mmcdole/gofeed"
@@ -12,17 +14,21 @@ import (
 const defaultUserAgent = "WebRSS parser (https://github.com/Alkemic/webrss)"
 
 type FeedFetcher struct {
-	parser     *gofeed.Parser
-	httpClient *http.Client
+	parser        *gofeed.Parser
+	httpClient    *http.Client
+	faviconClient *http.Client
 }
 
-func NewFeedParser(parser *gofeed.Parser, httpClient *http.Client) *FeedFetcher {
+func NewFeedParser(parser *gofeed.Parser, httpClient, faviconClient *http.Client) *FeedFetcher {
 	return &FeedFetcher{
-		httpClient: httpClient,
-		parser:     parser,
+		httpClient:    httpClient,
+		faviconClient: faviconClient,
+		parser:        parser,
 	}
 }
 
+// Fetch fetches and parses feed, when response was received returned Feed carries its status code and size
+// even if error is returned.
 func (f FeedFetcher) Fetch(ctx context.Context, url string) (Feed, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
@@ -36,10 +42,18 @@ func (f FeedFetcher) Fetch(ctx context.Context, url string) (Feed, error) {
 		return Feed{}, fmt.Errorf("cannot execute request: %w", err)
 	}
 	defer resp.Body.Close()
-	parsedFeed, err := f.parser.Parse(resp.Body)
+	// body is read upfront, so errors from exceeded limits won't get lost in parser
+	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return Feed{}, fmt.Errorf("cannot parse feed data: %w", err)
+		return Feed{statusCode: resp.StatusCode, size: len(body)}, fmt.Errorf("cannot read response body: %w", err)
+	}
+	parsedFeed, err := f.parser.Parse(bytes.NewReader(body))
+	if err != nil {
+		return Feed{statusCode: resp.StatusCode, size: len(body)}, fmt.Errorf("cannot parse feed data: %w", err)
 	}
 
-	return New(parsedFeed, f.httpClient, url), nil
+	feed := New(parsedFeed, f.faviconClient, url)
+	feed.statusCode = resp.StatusCode
+	feed.size = len(body)
+	return feed, nil
 }
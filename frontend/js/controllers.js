@@ -66,6 +66,24 @@ App.controller("RSSCtrl", ($scope, $http, $sce, $uibModal, $location) => {
         })
     }
 
+    $scope.editDigest = () => {
+        $uibModal.open({
+            templateUrl: "digest_settings.html",
+            controller: "DigestSettingsCtrl",
+        })
+    }
+
+    $scope.editReadLater = () => {
+        $uibModal.open({
+            templateUrl: "read_later_settings.html",
+            controller: "ReadLaterSettingsCtrl",
+        })
+    }
+
+    $scope.exportStarred = () => {
+        window.location = "/api/export/epub?starred=true"
+    }
+
     $scope.updateFeed = feed => {
         $uibModal.open({
             templateUrl: "feed_update.html",
@@ -129,6 +147,7 @@ App.controller("RSSCtrl", ($scope, $http, $sce, $uibModal, $location) => {
                 .then(res => {
                     $scope.feeds.entries.list = res.data
                     $scope.feeds.entries.current = null
+                    $http.post(`/api/feed/${feed.id}/seen`, {seen_at: res.data.meta.listed_at})
                 })
         } else {
             $scope.feeds.selected = null
@@ -137,7 +156,7 @@ App.controller("RSSCtrl", ($scope, $http, $sce, $uibModal, $location) => {
         if (!!(match = /^\/search=(.*)/.exec($location.url()))) {
             let phrase = decodeURI(match[1])
             $scope.search = phrase
-            $http.get(`/api/entry/search/?phrase=${phrase}`)
+            $http.get(`/api/entry/search/?phrase=${encodeURIComponent(phrase)}`)
                 .then(res => {
                     $scope.feeds.entries.list = res.data
                     $scope.feeds.selected = null
@@ -145,11 +164,98 @@ App.controller("RSSCtrl", ($scope, $http, $sce, $uibModal, $location) => {
                     $scope.feeds.entries.current = null
                     $scope.loading = false
                 }, err => {
-                    alert("Error fetching search results.")
+                    alert(err.status === 400 ? err.data : "Error fetching search results.")
                     console.error(err)
                     $scope.loading = false
                 })
         }
+
+        if (!!(match = /^\/saved=(\d+)/.exec($location.url()))) {
+            let savedSearchId = parseInt(match[1])
+            $http.get(`/api/entry/?saved_search=${savedSearchId}`)
+                .then(res => {
+                    $http.post(`/api/saved_search/${savedSearchId}/seen`, {seen_at: res.data.meta.listed_at})
+                    $scope.feeds.entries.list = res.data
+                    $scope.feeds.selected = null
+                    $scope.feeds.search = true
+                    $scope.feeds.savedSearchId = savedSearchId
+                    $scope.feeds.entries.current = null
+                    $scope.loading = false
+                }, err => {
+                    alert(err.status === 400 ? err.data : "Error fetching saved search entries.")
+                    console.error(err)
+                    $scope.loading = false
+                })
+        } else {
+            $scope.feeds.savedSearchId = null
+        }
+
+        if (!!(match = /^\/tag=(.*)/.exec($location.url()))) {
+            let tag = decodeURIComponent(match[1])
+            $http.get(`/api/entry/?user_tag=${encodeURIComponent(tag)}`)
+                .then(res => {
+                    $scope.feeds.entries.list = res.data
+                    $scope.feeds.selected = null
+                    $scope.feeds.search = true
+                    $scope.feeds.entries.current = null
+                    $scope.loading = false
+                }, err => {
+                    alert(err.status === 400 ? err.data : "Error fetching tagged entries.")
+                    console.error(err)
+                    $scope.loading = false
+                })
+        }
+    }
+
+    $scope.openUserTag = tag => {
+        $location.url(`tag=${encodeURIComponent(tag)}`)
+    }
+
+    $scope.tagEntry = entry => {
+        let tag = prompt("Tag")
+        if (!tag || !tag.trim()) return
+        tag = tag.trim()
+        $http.post("/api/entry/tag/", {ids: [entry.id], tag: tag})
+            .then(() => {
+                if (entry.user_tags.indexOf(tag) === -1) entry.user_tags.push(tag)
+            }, err => alert(err.status === 400 ? err.data : "Error tagging entry."))
+    }
+
+    $scope.untagEntry = (entry, tag) => {
+        $http.post("/api/entry/untag/", {ids: [entry.id], tag: tag})
+            .then(() => {
+                entry.user_tags = entry.user_tags.filter(_tag => _tag !== tag)
+            }, err => alert(err.status === 400 ? err.data : "Error removing tag."))
+    }
+
+    $scope.sendEntry = entry => {
+        $http.post(`/api/entry/${entry.id}/send`)
+            .then(() => alert("Entry has been sent."), err => alert(err.status === 400 || err.status === 502 ? err.data : "Error sending entry."))
+    }
+
+    $scope.shareEntry = entry => {
+        const days = prompt("Link expires after days (leave empty to never expire)", "")
+        if (days === null) return
+        const data = {}
+        if (days.trim()) {
+            if (!(parseInt(days, 10) > 0)) return alert("Invalid number of days.")
+            data.expires_at = new Date(Date.now() + parseInt(days, 10) * 24 * 60 * 60 * 1000).toISOString()
+        }
+        $http.post(`/api/entry/${entry.id}/share/`, data)
+            .then(res => {
+                prompt("Public link", `${location.origin}/share/${res.data.token}`)
+            }, err => alert(err.status === 400 ? err.data : "Error sharing entry."))
+    }
+
+    $scope.openSavedSearch = savedSearch => {
+        savedSearch.new_entries = false
+        $location.url(`saved=${savedSearch.id}`)
+    }
+
+    $scope.deleteSavedSearch = savedSearch => {
+        if (!confirm(`Delete saved search "${savedSearch.name}"?`)) return
+        $http.delete(`/api/saved_search/${savedSearch.id}/`)
+            .then(() => $scope.loadCategories(false))
     }
 
     let initialLoading = true
@@ -176,6 +282,18 @@ App.controller("RSSCtrl", ($scope, $http, $sce, $uibModal, $location) => {
     }
     $scope.loadCategories(false)
 
+    const events = new EventSource("/api/events")
+    events.addEventListener("unread.updated", e => {
+        const counts = {}
+        JSON.parse(e.data).feeds.forEach(feed => counts[feed.id] = feed.un_read)
+        $scope.$apply(() => {
+            ($scope.feeds.categories.objects || []).forEach(category => category.feeds.forEach(feed => {
+                if (feed.id in counts) feed.un_read = counts[feed.id]
+            }))
+        })
+    })
+    events.addEventListener("resync", () => $scope.loadCategories())
+
     $scope.loadCategoriesFn = e => {
         e.preventDefault()
         $scope.loadCategories(false)
@@ -188,13 +306,21 @@ App.controller("RSSCtrl", ($scope, $http, $sce, $uibModal, $location) => {
         if (!entry.read_at) {
             entry.read_at = new Date()
             $scope.feeds.selected.un_read -= 1
+            $http.put(`/api/entry/${entry.id}/read`)
         }
         if (entry.new_entry) {
             entry.new_entry = false
         }
-        $http.get(`/api/entry/${entry.id}`)
     })
 
+    $scope.toggleStar = entry => {
+        if (entry.starred_at) {
+            $http.delete(`/api/entry/${entry.id}/star`).then(() => entry.starred_at = null)
+        } else {
+            $http.put(`/api/entry/${entry.id}/star`).then(() => entry.starred_at = new Date())
+        }
+    }
+
     $scope.loadMore = feedUrl => {
         $http.get(feedUrl)
             .then(res => {
@@ -322,5 +448,55 @@ App.controller("RSSCtrl", ($scope, $http, $sce, $uibModal, $location) => {
         })
     }
 
+    $scope.cancel = $uibModalInstance.dismiss
+}).controller("ReadLaterSettingsCtrl", ($scope, $uibModalInstance, $http) => {
+    $scope.form = {}
+    $http.get("/api/read_later/settings/")
+        .then(res => {
+            $scope.form = res.data
+        }, () => {
+            $scope.error = "Error fetching read later settings."
+        })
+
+    $scope.save = () => {
+        $scope.error = null
+        $http.put("/api/read_later/settings/", $scope.form)
+            .then(() => $uibModalInstance.close(), err => {
+                $scope.error = err.status === 400 ? err.data : "Something went wrong"
+            })
+    }
+
+    $scope.cancel = $uibModalInstance.dismiss
+}).controller("DigestSettingsCtrl", ($scope, $uibModalInstance, $http) => {
+    $scope.weekdays = ["Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"]
+        .map((name, value) => ({name, value}))
+    $scope.form = {}
+    $http.get("/api/digest/settings/")
+        .then(res => {
+            $scope.form = res.data
+        }, () => {
+            $scope.error = "Error fetching digest settings."
+        })
+
+    const errorMessage = err => err.status === 400 ? err.data : "Something went wrong"
+
+    $scope.save = () => {
+        $scope.error = $scope.message = null
+        $http.put("/api/digest/settings/", $scope.form)
+            .then(() => $uibModalInstance.close(), err => {
+                $scope.error = errorMessage(err)
+            })
+    }
+
+    $scope.sendTest = () => {
+        $scope.error = $scope.message = null
+        $http.post("/api/digest/test/")
+            .then(() => {
+                $scope.message = "Test digest has been sent."
+            }, err => {
+                $scope.error = errorMessage(err)
+            })
+    }
+
     $scope.cancel = $uibModalInstance.dismiss
 })
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type AnnotationValid struct {
+	Kind   string `validate:"required,oneof=highlight note" json:"kind"`
+	Quote  string `validate:"max=65535" json:"quote"`
+	Offset *int64 `validate:"omitempty,min=0" json:"offset"`
+	Note   string `validate:"max=65535" json:"note"`
+}
+
+func (v AnnotationValid) apply(annotation repository.Annotation) repository.Annotation {
+	annotation.Kind = v.Kind
+	annotation.Quote = repository.NullString{}
+	annotation.Offset = repository.NullInt64{}
+	if v.Kind == repository.AnnotationKindHighlight {
+		annotation.Quote = repository.NewNullString(v.Quote)
+		if v.Offset != nil {
+			annotation.Offset = repository.NewNullInt64(*v.Offset)
+		}
+	}
+	annotation.Note = repository.NullString{}
+	if v.Note != "" {
+		annotation.Note = repository.NewNullString(v.Note)
+	}
+	return annotation
+}
+
+// readAnnotation reads and validates annotation from request body, writes error response when it fails.
+func (h *entryHandler) readAnnotation(rw http.ResponseWriter, req *http.Request) (AnnotationValid, bool) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return AnnotationValid{}, false
+	}
+	annotationData := AnnotationValid{}
+	if err := json.Unmarshal(body, &annotationData); err != nil {
+		h.logger.Println("can't unmarshal body:", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return AnnotationValid{}, false
+	}
+	if err = validator.New().Struct(annotationData); err != nil {
+		h.logger.Println("validation error:", err)
+		http.Error(rw, "validation error", http.StatusBadRequest)
+		return AnnotationValid{}, false
+	}
+	return annotationData, true
+}
+
+func (h *entryHandler) handleAnnotationError(rw http.ResponseWriter, msg string, err error) {
+	h.logger.Println(msg, err)
+	if errors.Is(err, webrss.ErrInvalidAnnotation) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *entryHandler) ListEntryAnnotations(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	annotations, err := h.webrssService.ListEntryAnnotations(req.Context(), id)
+	if err != nil {
+		h.logger.Println("cannot fetch annotations: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": annotations,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize annotations: ", err)
+	}
+}
+
+func (h *entryHandler) CreateAnnotation(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	annotationData, ok := h.readAnnotation(rw, req)
+	if !ok {
+		return
+	}
+	annotation, err := h.webrssService.CreateAnnotation(req.Context(), annotationData.apply(repository.Annotation{EntryID: id}))
+	if err != nil {
+		h.handleAnnotationError(rw, "error creating annotation:", err)
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(annotation); err != nil {
+		h.logger.Println("cannot serialize annotation: ", err)
+	}
+}
+
+func (h *entryHandler) UpdateAnnotation(rw http.ResponseWriter, req *http.Request) {
+	entryID, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	id, err := requestIntParam(req, "annotation_id")
+	if err != nil {
+		h.logger.Println("cannot get param 'annotation_id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	annotationData, ok := h.readAnnotation(rw, req)
+	if !ok {
+		return
+	}
+	ctx := req.Context()
+	annotation, err := h.webrssService.GetAnnotation(ctx, entryID, id)
+	if err != nil {
+		h.handleAnnotationError(rw, "error getting annotation:", err)
+		return
+	}
+	if err := h.webrssService.UpdateAnnotation(ctx, annotationData.apply(annotation)); err != nil {
+		h.handleAnnotationError(rw, "error updating annotation:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *entryHandler) DeleteAnnotation(rw http.ResponseWriter, req *http.Request) {
+	entryID, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	id, err := requestIntParam(req, "annotation_id")
+	if err != nil {
+		h.logger.Println("cannot get param 'annotation_id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.DeleteAnnotation(req.Context(), entryID, id); err != nil {
+		h.handleAnnotationError(rw, "cannot delete annotation:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *entryHandler) ListAnnotations(rw http.ResponseWriter, req *http.Request) {
+	annotations, err := h.webrssService.ListAnnotations(req.Context())
+	if err != nil {
+		h.logger.Println("cannot fetch annotations: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": annotations,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize annotations: ", err)
+	}
+}
+
+// ExportAnnotations serves all annotations as Markdown file.
+func (h *entryHandler) ExportAnnotations(rw http.ResponseWriter, req *http.Request) {
+	document, err := h.webrssService.ExportAnnotations(req.Context())
+	if err != nil {
+		h.logger.Println("cannot export annotations: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	rw.Header().Set("Content-Disposition", `attachment; filename="annotations.md"`)
+	fmt.Fprint(rw, document)
+}
@@ -7,11 +7,13 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/Alkemic/go-route"
 	"github.com/Alkemic/go-route/middleware"
 	"gopkg.in/go-playground/validator.v9"
 
+	"github.com/Alkemic/webrss/metrics"
 	"github.com/Alkemic/webrss/repository"
 	"github.com/Alkemic/webrss/webrss"
 )
@@ -29,12 +31,67 @@ type webrssService interface {
 	CreateFeed(ctx context.Context, feedURL string, categoryID int64) error
 	DeleteFeed(ctx context.Context, feed repository.Feed) error
 	UpdateFeed(ctx context.Context, feed repository.Feed) error
+	ListFetches(ctx context.Context, feedID int64, limit int) ([]repository.FetchLog, error)
+	MarkFeedSeen(ctx context.Context, feedID int64, seenAt time.Time) error
 
-	SaveEntries(ctx context.Context, feedID int64, entries []repository.Entry) error
+	SaveEntries(ctx context.Context, feedID int64, entries []repository.Entry) (int, int, error)
 
-	GetEntry(ctx context.Context, id int64) (repository.Entry, error)
-	Search(ctx context.Context, phrase string, page int64, perPage int) ([]repository.Entry, error)
-	ListEntriesForFeed(ctx context.Context, feedID, page int64, perPage int) ([]repository.Entry, error)
+	GetEntry(ctx context.Context, id int64, markRead bool) (repository.Entry, error)
+	MarkEntriesRead(ctx context.Context, ids []int64) error
+	MarkEntriesUnread(ctx context.Context, ids []int64) error
+	ListStarredEntries(ctx context.Context, page repository.PageRequest) (repository.EntryPage, error)
+	StarEntry(ctx context.Context, id int64) error
+	UnstarEntry(ctx context.Context, id int64) error
+	MarkAllRead(ctx context.Context, feedID, categoryID int64, before time.Time) (webrss.MarkReadResult, error)
+	UndoMarkAllRead(ctx context.Context, token string) error
+	Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (repository.EntryPage, error)
+	ListEntries(ctx context.Context, filter repository.EntryFilter, page repository.PageRequest) (repository.EntryPage, error)
+	Now() time.Time
+	ListEntryRevisions(ctx context.Context, entryID int64) ([]webrss.RevisionDiff, error)
+
+	ListRules(ctx context.Context) ([]repository.Rule, error)
+	GetRule(ctx context.Context, id int64) (repository.Rule, error)
+	CreateRule(ctx context.Context, rule repository.Rule) error
+	UpdateRule(ctx context.Context, rule repository.Rule) error
+	DeleteRule(ctx context.Context, id int64) error
+	TestRule(ctx context.Context, rule repository.Rule) ([]repository.Entry, error)
+
+	ListSavedSearches(ctx context.Context) ([]repository.SavedSearch, error)
+	GetSavedSearch(ctx context.Context, id int64) (repository.SavedSearch, error)
+	CreateSavedSearch(ctx context.Context, savedSearch repository.SavedSearch) error
+	UpdateSavedSearch(ctx context.Context, savedSearch repository.SavedSearch) error
+	DeleteSavedSearch(ctx context.Context, id int64) error
+	MarkSavedSearchSeen(ctx context.Context, savedSearchID int64, seenAt time.Time) error
+
+	ListAnnotations(ctx context.Context) ([]repository.Annotation, error)
+	ExportAnnotations(ctx context.Context) (string, error)
+	ListEntryAnnotations(ctx context.Context, entryID int64) ([]repository.Annotation, error)
+	GetAnnotation(ctx context.Context, entryID, id int64) (repository.Annotation, error)
+	CreateAnnotation(ctx context.Context, annotation repository.Annotation) (repository.Annotation, error)
+	UpdateAnnotation(ctx context.Context, annotation repository.Annotation) error
+	DeleteAnnotation(ctx context.Context, entryID, id int64) error
+
+	ListUserTags(ctx context.Context) ([]repository.UserTag, error)
+	TagEntries(ctx context.Context, ids []int64, name string) error
+	UntagEntries(ctx context.Context, ids []int64, name string) error
+	RenameUserTag(ctx context.Context, id int64, name string) error
+	DeleteUserTag(ctx context.Context, id int64) error
+
+	CreateShare(ctx context.Context, entryID int64, expiresAt time.Time) (repository.Share, error)
+	ListShares(ctx context.Context) ([]repository.Share, error)
+	RevokeShare(ctx context.Context, id int64) error
+	GetSharedEntry(ctx context.Context, token string) (repository.Entry, error)
+
+	ListWebhooks(ctx context.Context) ([]repository.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (repository.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook repository.Webhook) error
+	UpdateWebhook(ctx context.Context, webhook repository.Webhook) error
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListWebhookDeliveries(ctx context.Context, webhookID int64) ([]repository.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, webhookID, id int64) error
+
+	ExportEntries(ctx context.Context, selection webrss.ExportSelection) ([]repository.Entry, error)
+	SendEntry(ctx context.Context, id int64) error
 }
 
 type categoryHandler struct {
@@ -200,11 +257,12 @@ func (h *categoryHandler) GetRoutes() *route.RegexpRouter {
 		"Content-Type": "application/json; charset=utf-8",
 	})
 
-	routing := route.New()
+	routing := metrics.NewRouter()
 	routing.Add(`^/?$`, setHeaders(collection.Dispatch))
 	routing.Add(`^/(?P<id>\d+)/$`, setHeaders(resource.Dispatch))
 	routing.Add(`^/(?P<id>\d+)/move_up$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(h.MoveUp)))
 	routing.Add(`^/(?P<id>\d+)/move_down$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(h.MoveDown)))
+	routing.Add(`^/(?P<id>\d+)/mark_read/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(h.MarkAllRead)))
 
-	return routing
+	return routing.RegexpRouter
 }
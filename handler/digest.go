@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+
+	"github.com/Alkemic/webrss/digest"
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type digestService interface {
+	Settings(ctx context.Context) (digest.Settings, error)
+	SaveSettings(ctx context.Context, settings digest.Settings) error
+	SendTest(ctx context.Context) error
+}
+
+type DigestSettingsValid struct {
+	Enabled      bool   `json:"enabled"`
+	Frequency    string `validate:"required,oneof=daily weekly" json:"frequency"`
+	Weekday      int    `validate:"min=0,max=6" json:"weekday"`
+	Hour         int    `validate:"min=0,max=23" json:"hour"`
+	Mode         string `validate:"required,oneof=unread highlights" json:"mode"`
+	Recipient    string `validate:"omitempty,email,max=255" json:"recipient"`
+	Sender       string `validate:"omitempty,email,max=255" json:"sender"`
+	SMTPHost     string `validate:"omitempty,hostname|ip,max=255" json:"smtp_host"`
+	SMTPPort     int    `validate:"min=1,max=65535" json:"smtp_port"`
+	SMTPUsername string `validate:"max=255" json:"smtp_username"`
+	SMTPPassword string `validate:"max=255" json:"smtp_password"`
+}
+
+type digestHandler struct {
+	logger        *log.Logger
+	digestService digestService
+}
+
+func NewDigest(logger *log.Logger, service digestService) *digestHandler {
+	return &digestHandler{
+		digestService: service,
+		logger:        logger,
+	}
+}
+
+func (h *digestHandler) handleDigestError(rw http.ResponseWriter, msg string, err error) {
+	h.logger.Println(msg, err)
+	if errors.Is(err, digest.ErrInvalidSettings) || errors.Is(err, digest.ErrNotConfigured) ||
+		errors.Is(err, webrss.ErrInvalidDigestMode) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *digestHandler) GetSettings(rw http.ResponseWriter, req *http.Request) {
+	settings, err := h.digestService.Settings(req.Context())
+	if err != nil {
+		h.handleDigestError(rw, "cannot fetch digest settings:", err)
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(settings); err != nil {
+		h.logger.Println("cannot serialize digest settings: ", err)
+	}
+}
+
+// SaveSettings saves digest settings, empty SMTP password leaves the current one.
+func (h *digestHandler) SaveSettings(rw http.ResponseWriter, req *http.Request) {
+	settingsData := DigestSettingsValid{}
+	if !readBody(h.logger, rw, req, &settingsData) {
+		return
+	}
+	settings := digest.Settings{
+		Enabled:      settingsData.Enabled,
+		Frequency:    settingsData.Frequency,
+		Weekday:      settingsData.Weekday,
+		Hour:         settingsData.Hour,
+		Mode:         settingsData.Mode,
+		Recipient:    settingsData.Recipient,
+		Sender:       settingsData.Sender,
+		SMTPHost:     settingsData.SMTPHost,
+		SMTPPort:     settingsData.SMTPPort,
+		SMTPUsername: settingsData.SMTPUsername,
+		SMTPPassword: settingsData.SMTPPassword,
+	}
+	if err := h.digestService.SaveSettings(req.Context(), settings); err != nil {
+		h.handleDigestError(rw, "cannot save digest settings:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+// SendTest sends digest of the last period right away.
+func (h *digestHandler) SendTest(rw http.ResponseWriter, req *http.Request) {
+	if err := h.digestService.SendTest(req.Context()); err != nil {
+		h.handleDigestError(rw, "cannot send test digest:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (r *digestHandler) GetRoutes() *route.RegexpRouter {
+	settings := webrss.RESTEndPoint{
+		Get: r.GetSettings,
+		Put: r.SaveSettings,
+	}
+
+	setHeaders := middleware.SetHeaders(map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	})
+
+	routing := metrics.NewRouter()
+	routing.Add(`^/settings/?$`, setHeaders(settings.Dispatch))
+	routing.Add(`^/test/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.SendTest)))
+
+	return routing.RegexpRouter
+}
@@ -1,15 +1,25 @@
 package handler
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Alkemic/go-route"
 	"github.com/Alkemic/go-route/middleware"
+	"gopkg.in/go-playground/validator.v9"
 
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/search"
 	"github.com/Alkemic/webrss/webrss"
 )
 
@@ -40,7 +50,8 @@ func (h *entryHandler) Get(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	entry, err := h.webrssService.GetEntry(req.Context(), int64(id))
+	markRead := req.URL.Query().Get("mark_read") == "true"
+	entry, err := h.webrssService.GetEntry(req.Context(), int64(id), markRead)
 	if err != nil {
 		h.logger.Println("error getting entry: ", err)
 		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -52,47 +63,312 @@ func (h *entryHandler) Get(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func getPage(req *http.Request) (int64, error) {
-	page, ok, err := routeIntParam("page", req)
+func (h *entryHandler) MarkRead(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.MarkEntriesRead(req.Context(), []int64{id}); err != nil {
+		h.logger.Println("cannot mark entry as read: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *entryHandler) MarkUnread(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.MarkEntriesUnread(req.Context(), []int64{id}); err != nil {
+		h.logger.Println("cannot mark entry as unread: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *entryHandler) Star(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.StarEntry(req.Context(), id); err != nil {
+		h.logger.Println("cannot star entry: ", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *entryHandler) Unstar(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.UnstarEntry(req.Context(), id); err != nil {
+		h.logger.Println("cannot unstar entry: ", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *entryHandler) ListStarred(rw http.ResponseWriter, req *http.Request) {
+	page, err := getPageRequest(req, h.perPage)
+	if err != nil {
+		h.logger.Println(err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.webrssService.ListStarredEntries(req.Context(), page)
+	if err != nil {
+		h.logger.Println("cannot fetch starred entries: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"objects": entries.Entries,
+		"meta":    pageMeta(req, entries),
+	}
+
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize entries: ", err)
+	}
+}
+
+type EntryIDsValid struct {
+	IDs []int64 `validate:"required,min=1,max=1000,dive,min=1" json:"ids"`
+}
+
+// BulkMarkRead marks as read entries with ids given in request body.
+func (h *entryHandler) BulkMarkRead(rw http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	idsData := EntryIDsValid{}
+	if err := json.Unmarshal(body, &idsData); err != nil {
+		h.logger.Println("can't unmarshal body:", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err = validator.New().Struct(idsData); err != nil {
+		h.logger.Println("validation error:", err)
+		http.Error(rw, "validation error", http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.MarkEntriesRead(req.Context(), idsData.IDs); err != nil {
+		h.logger.Println("cannot mark entries as read: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *entryHandler) ListRevisions(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.webrssService.ListEntryRevisions(req.Context(), id)
+	if err != nil {
+		h.logger.Println("cannot fetch entry revisions: ", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": revisions,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize entry revisions: ", err)
+	}
+}
+
+type ShareExpiryValid struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Share creates public link to entry, optionally expiring at time given in request body.
+func (h *entryHandler) Share(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	shareData := ShareExpiryValid{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &shareData); err != nil {
+			h.logger.Println("can't unmarshal body:", err)
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+	}
+	expiresAt := time.Time{}
+	if shareData.ExpiresAt != nil {
+		expiresAt = *shareData.ExpiresAt
+	}
+	share, err := h.webrssService.CreateShare(req.Context(), id, expiresAt)
+	if err != nil {
+		h.logger.Println("error creating share:", err)
+		if errors.Is(err, webrss.ErrInvalidShare) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(share); err != nil {
+		h.logger.Println("cannot serialize share: ", err)
+	}
+}
+
+// getPageRequest reads optional "cursor", "per_page" and "total=true" params. Number of entries per
+// page can't exceed maxPerPage.
+func getPageRequest(req *http.Request, maxPerPage int) (repository.PageRequest, error) {
+	page := repository.PageRequest{
+		PerPage:   maxPerPage,
+		WithTotal: req.URL.Query().Get("total") == "true",
+	}
+	if rawCursor := req.URL.Query().Get("cursor"); rawCursor != "" {
+		cursor, err := repository.DecodeCursor(rawCursor)
+		if err != nil {
+			return repository.PageRequest{}, fmt.Errorf("cannot decode param 'cursor': %w", err)
+		}
+		page.Cursor = cursor
+	}
+	perPage, ok, err := routeIntParam("per_page", req)
 	if err != nil && ok {
-		return 0, err
+		return repository.PageRequest{}, fmt.Errorf("cannot parse param 'per_page': %w", err)
 	}
-	if page < 1 {
-		page = 1
+	if ok && perPage >= 1 && perPage < maxPerPage {
+		page.PerPage = perPage
 	}
-	return int64(page), nil
+	return page, nil
 }
 
-func (h *entryHandler) Search(rw http.ResponseWriter, req *http.Request) {
-	query := req.URL.Query()
-	phrase := query.Get("phrase")
+// pageMeta builds URLs of neighbouring pages from the current request, keeping all of its params.
+func pageMeta(req *http.Request, page repository.EntryPage) map[string]interface{} {
+	pageURL := func(cursor *repository.Cursor) string {
+		if cursor == nil {
+			return ""
+		}
+		query := req.URL.Query()
+		query.Set("cursor", cursor.Encode())
+		return req.URL.Path + "?" + query.Encode()
+	}
+	meta := map[string]interface{}{
+		"next": pageURL(page.Next),
+		"prev": pageURL(page.Prev),
+	}
+	if page.Total != nil {
+		meta["total"] = *page.Total
+	}
+	return meta
+}
+
+// getSearchQuery reads searched "phrase" with optional "mode" (natural or boolean) and "sort" (relevance
+// or date) params. Phrase is parsed as a search query, see search package for its syntax.
+func getSearchQuery(req *http.Request) (repository.SearchQuery, error) {
+	params := req.URL.Query()
+	query := repository.SearchQuery{
+		Mode: repository.SearchModeNatural,
+		Sort: repository.SearchSortRelevance,
+	}
+	phrase := strings.TrimSpace(params.Get("phrase"))
 	if phrase == "" {
-		h.logger.Println("missing phrase in request")
+		return repository.SearchQuery{}, errors.New("missing phrase in request")
+	}
+	parsed, err := search.Parse(phrase)
+	if err != nil {
+		return repository.SearchQuery{}, err
+	}
+	query.Query = parsed
+	switch mode := params.Get("mode"); mode {
+	case "":
+	case repository.SearchModeNatural, repository.SearchModeBoolean:
+		query.Mode = mode
+	default:
+		return repository.SearchQuery{}, fmt.Errorf("invalid param 'mode': %s", mode)
+	}
+	switch sort := params.Get("sort"); sort {
+	case "":
+	case repository.SearchSortRelevance, repository.SearchSortDate:
+		query.Sort = sort
+	default:
+		return repository.SearchQuery{}, fmt.Errorf("invalid param 'sort': %s", sort)
+	}
+	return query, nil
+}
+
+func (h *entryHandler) Search(rw http.ResponseWriter, req *http.Request) {
+	query, err := getSearchQuery(req)
+	var parseErr *search.ParseError
+	if errors.As(err, &parseErr) {
+		http.Error(rw, parseErr.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		h.logger.Println(err)
 		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
 
-	page, err := getPage(req)
+	page, err := getPageRequest(req, h.perPage)
 	if err != nil {
 		h.logger.Println(err)
 		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
 
-	entries, err := h.webrssService.Search(req.Context(), phrase, page, h.perPage)
+	entries, err := h.webrssService.Search(req.Context(), query, page)
 	if err != nil {
 		h.logger.Println("cannot fetch entries: ", err)
 		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
-	nextPage := ""
-	if len(entries) == h.perPage {
-		nextPage = fmt.Sprintf("/api/entry/search/?phrase=%s&page=%d", phrase, page+1)
-	}
 	data := map[string]interface{}{
-		"objects": entries,
-		"meta":    map[string]string{"next": nextPage},
+		"objects": entries.Entries,
+		"meta":    pageMeta(req, entries),
 	}
 
 	if err := json.NewEncoder(rw).Encode(data); err != nil {
@@ -100,34 +376,107 @@ func (h *entryHandler) Search(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// entryDateParam parses date param given either as RFC 3339 time or as a date. With endOfDay the date
+// is moved to the next day, so the whole day is included in the range ending with it.
+func entryDateParam(req *http.Request, key string, endOfDay bool) (time.Time, error) {
+	rawValue := req.URL.Query().Get(key)
+	if rawValue == "" {
+		return time.Time{}, nil
+	}
+	if value, err := time.Parse(time.RFC3339, rawValue); err == nil {
+		return value, nil
+	}
+	value, err := time.Parse("2006-01-02", rawValue)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid '%s' param, expected date or RFC 3339 time", key)
+	}
+	if endOfDay {
+		value = value.AddDate(0, 0, 1)
+	}
+	return value, nil
+}
+
+// getEntryFilter reads listing scope, optional "unread=true", "starred=true", "from" and "to" filters,
+// and "order" (newest or oldest).
+func getEntryFilter(req *http.Request) (repository.EntryFilter, error) {
+	query := req.URL.Query()
+	filter := repository.EntryFilter{
+		Unread:  query.Get("unread") == "true",
+		Starred: query.Get("starred") == "true",
+		Order:   query.Get("order"),
+	}
+	if filter.Order != "" && filter.Order != repository.EntryOrderNewest && filter.Order != repository.EntryOrderOldest {
+		return repository.EntryFilter{}, errors.New("invalid 'order' param, expected 'newest' or 'oldest'")
+	}
+	var err error
+	if filter.PublishedAfter, err = entryDateParam(req, "from", false); err != nil {
+		return repository.EntryFilter{}, err
+	}
+	if filter.PublishedBefore, err = entryDateParam(req, "to", true); err != nil {
+		return repository.EntryFilter{}, err
+	}
+	scopes := 0
+	for key, id := range map[string]*int64{
+		"feed":         &filter.FeedID,
+		"category":     &filter.CategoryID,
+		"saved_search": &filter.SavedSearchID,
+	} {
+		value, ok, err := routeIntParam(key, req)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			return repository.EntryFilter{}, err
+		}
+		if value < 1 {
+			return repository.EntryFilter{}, fmt.Errorf("invalid '%s' param", key)
+		}
+		*id = int64(value)
+		scopes++
+	}
+	if filter.UserTag = query.Get("user_tag"); filter.UserTag != "" {
+		scopes++
+	}
+	if query.Get("all") == "true" {
+		scopes++
+	}
+	if scopes != 1 {
+		return repository.EntryFilter{}, errors.New("exactly one of 'feed', 'category', 'saved_search', 'user_tag' and 'all' params is required")
+	}
+	return filter, nil
+}
+
 func (h *entryHandler) List(rw http.ResponseWriter, req *http.Request) {
-	feedID, _, err := routeIntParam("feed", req)
+	filter, err := getEntryFilter(req)
 	if err != nil {
 		h.logger.Println(err)
 		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
-	page, err := getPage(req)
+	page, err := getPageRequest(req, h.perPage)
 	if err != nil {
 		h.logger.Println(err)
 		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		return
 	}
 
-	entries, err := h.webrssService.ListEntriesForFeed(req.Context(), int64(feedID), page, h.perPage)
-	if err != nil {
+	// listing time is returned, so client can mark listed entries as seen
+	listedAt := h.webrssService.Now()
+	entries, err := h.webrssService.ListEntries(req.Context(), filter, page)
+	if errors.Is(err, webrss.ErrInvalidSavedSearch) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
 		h.logger.Println("cannot fetch entries: ", err)
 		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
-	nextPage := ""
-	if len(entries) == h.perPage {
-		nextPage = fmt.Sprintf("/api/entry/?feed=%d&page=%d", feedID, page+1)
-	}
+	meta := pageMeta(req, entries)
+	meta["listed_at"] = listedAt
 	data := map[string]interface{}{
-		"objects": entries,
-		"meta":    map[string]string{"next": nextPage},
+		"objects": entries.Entries,
+		"meta":    meta,
 	}
 
 	if err := json.NewEncoder(rw).Encode(data); err != nil {
@@ -142,15 +491,46 @@ func (r *entryHandler) GetRoutes() *route.RegexpRouter {
 	collection := webrss.RESTEndPoint{
 		Get: r.List,
 	}
+	readState := webrss.RESTEndPoint{
+		Put:    r.MarkRead,
+		Delete: r.MarkUnread,
+	}
+	starredState := webrss.RESTEndPoint{
+		Put:    r.Star,
+		Delete: r.Unstar,
+	}
+	annotations := webrss.RESTEndPoint{
+		Get:  r.ListEntryAnnotations,
+		Post: r.CreateAnnotation,
+	}
+	annotation := webrss.RESTEndPoint{
+		Put:    r.UpdateAnnotation,
+		Delete: r.DeleteAnnotation,
+	}
 
 	setHeaders := middleware.SetHeaders(map[string]string{
 		"Content-Type": "application/json; charset=utf-8",
 	})
 
-	routing := route.New()
+	routing := metrics.NewRouter()
 	routing.Add(`^/?$`, setHeaders(collection.Dispatch))
 	routing.Add(`^/search/?$`, setHeaders(r.Search))
+	routing.Add(`^/starred/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodGet})(r.ListStarred)))
+	routing.Add(`^/read/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.BulkMarkRead)))
+	routing.Add(`^/mark_read/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.MarkAllRead)))
+	routing.Add(`^/mark_read/undo/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.UndoMarkAllRead)))
+	routing.Add(`^/tag/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.TagEntries)))
+	routing.Add(`^/untag/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.UntagEntries)))
+	routing.Add(`^/annotations/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodGet})(r.ListAnnotations)))
+	routing.Add(`^/annotations/export/?$`, middleware.AllowedMethods([]string{http.MethodGet})(r.ExportAnnotations))
 	routing.Add(`^/(?P<id>\d+)/?$`, setHeaders(resource.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/read/?$`, setHeaders(readState.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/star/?$`, setHeaders(starredState.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/revisions/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodGet})(r.ListRevisions)))
+	routing.Add(`^/(?P<id>\d+)/annotations/?$`, setHeaders(annotations.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/annotations/(?P<annotation_id>\d+)/?$`, setHeaders(annotation.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/share/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.Share)))
+	routing.Add(`^/(?P<id>\d+)/send/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.Send)))
 
-	return routing
+	return routing.RegexpRouter
 }
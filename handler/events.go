@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+
+	"github.com/Alkemic/webrss/events"
+	"github.com/Alkemic/webrss/metrics"
+)
+
+const (
+	// keepAliveInterval is how often comment is sent to idle event streams, so proxies don't close them.
+	keepAliveInterval = 30 * time.Second
+	// reconnectDelay is sent to clients as the delay before reconnecting, in milliseconds.
+	reconnectDelay = 5000
+	// resyncEvent tells client that it missed some events and has to reload its state.
+	resyncEvent = "resync"
+)
+
+type eventBroker interface {
+	Subscribe(lastEventID int64) ([]events.Event, bool, <-chan events.Event, func())
+}
+
+type eventsHandler struct {
+	logger *log.Logger
+	broker eventBroker
+}
+
+func NewEvents(logger *log.Logger, broker eventBroker) *eventsHandler {
+	return &eventsHandler{
+		logger: logger,
+		broker: broker,
+	}
+}
+
+func writeEvent(rw http.ResponseWriter, event events.Event) error {
+	_, err := fmt.Fprintf(rw, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+// Stream sends events as Server-Sent Events. Client reconnecting with Last-Event-ID header gets events it
+// missed, or resync event when they are no longer available.
+func (h *eventsHandler) Stream(rw http.ResponseWriter, req *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		h.logger.Println("response writer doesn't support flushing")
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	var lastEventID int64
+	if header := req.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		if lastEventID, err = strconv.ParseInt(header, 10, 64); err != nil || lastEventID < 0 {
+			http.Error(rw, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	backlog, complete, stream, unsubscribe := h.broker.Subscribe(lastEventID)
+	defer unsubscribe()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	fmt.Fprintf(rw, "retry: %d\n\n", reconnectDelay)
+	if !complete {
+		fmt.Fprintf(rw, "event: %s\ndata: {}\n\n", resyncEvent)
+	}
+	for _, event := range backlog {
+		if err := writeEvent(rw, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-stream:
+			if !ok {
+				// client fell behind, it will reconnect and get missed events
+				return
+			}
+			if err := writeEvent(rw, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *eventsHandler) GetRoutes() *route.RegexpRouter {
+	routing := metrics.NewRouter()
+	routing.Add(`^/?$`, middleware.AllowedMethods([]string{http.MethodGet})(h.Stream))
+
+	return routing.RegexpRouter
+}
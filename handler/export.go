@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+
+	"github.com/Alkemic/webrss/epub"
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type epubGenerator interface {
+	Write(ctx context.Context, w io.Writer, title string, entries []repository.Entry) error
+}
+
+type exportHandler struct {
+	logger        *log.Logger
+	webrssService webrssService
+	epubGenerator epubGenerator
+}
+
+func NewExport(logger *log.Logger, service webrssService, epubGenerator epubGenerator) *exportHandler {
+	return &exportHandler{
+		logger:        logger,
+		webrssService: service,
+		epubGenerator: epubGenerator,
+	}
+}
+
+// getExportSelection reads exported entries, given either as comma separated "ids", or by "starred=true"
+// and/or "category" params.
+func getExportSelection(req *http.Request) (webrss.ExportSelection, error) {
+	query := req.URL.Query()
+	selection := webrss.ExportSelection{
+		Starred: query.Get("starred") == "true",
+	}
+	if rawIDs := query.Get("ids"); rawIDs != "" {
+		for _, rawID := range strings.Split(rawIDs, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(rawID), 10, 64)
+			if err != nil || id < 1 {
+				return webrss.ExportSelection{}, errors.New("invalid 'ids' param, expected comma separated ids")
+			}
+			selection.IDs = append(selection.IDs, id)
+		}
+	}
+	if rawCategory := query.Get("category"); rawCategory != "" {
+		id, err := strconv.ParseInt(rawCategory, 10, 64)
+		if err != nil || id < 1 {
+			return webrss.ExportSelection{}, errors.New("invalid 'category' param")
+		}
+		selection.CategoryID = id
+	}
+	return selection, nil
+}
+
+// EPUB serves selected entries as EPUB book.
+func (h *exportHandler) EPUB(rw http.ResponseWriter, req *http.Request) {
+	selection, err := getExportSelection(req)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := req.Context()
+	entries, err := h.webrssService.ExportEntries(ctx, selection)
+	if errors.Is(err, webrss.ErrInvalidExport) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		h.logger.Println("cannot fetch exported entries: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	date := time.Now().Format("2006-01-02")
+	rw.Header().Set("Content-Type", epub.MediaType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="webrss-%s.epub"`, date))
+	if err := h.epubGenerator.Write(ctx, rw, "WebRSS "+date, entries); err != nil {
+		h.logger.Println("cannot write epub: ", err)
+	}
+}
+
+func (h *exportHandler) GetRoutes() *route.RegexpRouter {
+	routing := metrics.NewRouter()
+	routing.Add(`^/epub/?$`, middleware.AllowedMethods([]string{http.MethodGet})(h.EPUB))
+
+	return routing.RegexpRouter
+}
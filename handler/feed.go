@@ -12,15 +12,23 @@ import (
 	"github.com/Alkemic/go-route/middleware"
 	"gopkg.in/go-playground/validator.v9"
 
+	"github.com/Alkemic/webrss/metrics"
 	"github.com/Alkemic/webrss/repository"
 	"github.com/Alkemic/webrss/webrss"
 )
 
+const (
+	defaultFetchesLimit = 50
+	maxFetchesLimit     = 200
+)
+
 type FeedValid struct {
 	FeedURL        string `validate:"required,min=3,max=255,url" json:"feed_url"`
 	FeedFaviconURL string `validate:"max=255" json:"site_favicon_url"`
 	FeedTitle      string `validate:"max=255" json:"feed_title"`
 	Category       int64  `validate:"required"`
+	HideDuplicates bool   `json:"hide_duplicates"`
+	EntryOrder     string `validate:"omitempty,oneof=newest oldest" json:"entry_order"`
 }
 
 type feedHandler struct {
@@ -97,6 +105,11 @@ func (h *feedHandler) Update(rw http.ResponseWriter, req *http.Request) {
 	feed.FeedUrl = feedData.FeedURL
 	feed.FeedTitle = feedData.FeedTitle
 	feed.CategoryID = feedData.Category
+	feed.HideDuplicates = feedData.HideDuplicates
+	feed.EntryOrder = feedData.EntryOrder
+	if feed.EntryOrder == "" {
+		feed.EntryOrder = repository.EntryOrderNewest
+	}
 	feed.SiteFaviconUrl = repository.NewNullString(feedData.FeedFaviconURL)
 
 	if err := h.webrssService.UpdateFeed(ctx, feed); err != nil {
@@ -134,6 +147,39 @@ func (h *feedHandler) Delete(rw http.ResponseWriter, req *http.Request) {
 	fmt.Fprint(rw, `{"status":"ok"}`)
 }
 
+func (h *feedHandler) ListFetches(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	limit, ok, err := routeIntParam("limit", req)
+	if err != nil && ok {
+		h.logger.Println(err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if limit < 1 {
+		limit = defaultFetchesLimit
+	} else if limit > maxFetchesLimit {
+		limit = maxFetchesLimit
+	}
+
+	fetchLogs, err := h.webrssService.ListFetches(req.Context(), id, limit)
+	if err != nil {
+		h.logger.Println("cannot fetch fetch logs: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": fetchLogs,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize fetch logs: ", err)
+	}
+}
+
 func (r *feedHandler) GetRoutes() *route.RegexpRouter {
 	resource := webrss.RESTEndPoint{
 		Delete: r.Delete,
@@ -147,9 +193,12 @@ func (r *feedHandler) GetRoutes() *route.RegexpRouter {
 		"Content-Type": "application/json; charset=utf-8",
 	})
 
-	routing := route.New()
+	routing := metrics.NewRouter()
 	routing.Add(`^/?$`, setHeaders(collection.Dispatch))
 	routing.Add(`^/(?P<id>\d+)/?$`, setHeaders(resource.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/mark_read/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.MarkAllRead)))
+	routing.Add(`^/(?P<id>\d+)/seen/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.MarkSeen)))
+	routing.Add(`^/(?P<id>\d+)/fetches/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodGet})(r.ListFetches)))
 
-	return routing
+	return routing.RegexpRouter
 }
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type SeenValid struct {
+	SeenAt time.Time `json:"seen_at"`
+}
+
+// markSeen handles marking feed or saved search as seen on the device at time given in request body,
+// usually "listed_at" from entries listing meta. Current time is used when it's missing.
+func markSeen(logger *log.Logger, rw http.ResponseWriter, req *http.Request,
+	markFn func(ctx context.Context, id int64, seenAt time.Time) error) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	seenData := SeenValid{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &seenData); err != nil {
+			logger.Println("can't unmarshal body:", err)
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := markFn(req.Context(), id, seenData.SeenAt); err != nil {
+		logger.Println("cannot mark as seen: ", err)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		case errors.Is(err, repository.ErrNoDevice):
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		default:
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *feedHandler) MarkSeen(rw http.ResponseWriter, req *http.Request) {
+	markSeen(h.logger, rw, req, h.webrssService.MarkFeedSeen)
+}
+
+func (h *savedSearchHandler) MarkSeen(rw http.ResponseWriter, req *http.Request) {
+	markSeen(h.logger, rw, req, h.webrssService.MarkSavedSearchSeen)
+}
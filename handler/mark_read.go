@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type UndoValid struct {
+	Token string `validate:"required,max=36" json:"undo_token"`
+}
+
+// markAllRead handles marking entries as read in bulk, optional "before" param limits entries to those
+// created before given time.
+func markAllRead(logger *log.Logger, service webrssService, rw http.ResponseWriter, req *http.Request, feedID, categoryID int64) {
+	var before time.Time
+	if beforeRaw := req.URL.Query().Get("before"); beforeRaw != "" {
+		var err error
+		if before, err = time.Parse(time.RFC3339, beforeRaw); err != nil {
+			logger.Println("cannot parse param 'before': ", err)
+			http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := service.MarkAllRead(req.Context(), feedID, categoryID, before)
+	if err != nil {
+		logger.Println("cannot mark entries as read: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logger.Println("cannot serialize result: ", err)
+	}
+}
+
+func (h *entryHandler) MarkAllRead(rw http.ResponseWriter, req *http.Request) {
+	markAllRead(h.logger, h.webrssService, rw, req, 0, 0)
+}
+
+func (h *feedHandler) MarkAllRead(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	markAllRead(h.logger, h.webrssService, rw, req, id, 0)
+}
+
+func (h *categoryHandler) MarkAllRead(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	markAllRead(h.logger, h.webrssService, rw, req, 0, id)
+}
+
+// UndoMarkAllRead restores entries marked as read in bulk, using token returned when they were marked.
+func (h *entryHandler) UndoMarkAllRead(rw http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	undoData := UndoValid{}
+	if err := json.Unmarshal(body, &undoData); err != nil {
+		h.logger.Println("can't unmarshal body:", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err = validator.New().Struct(undoData); err != nil {
+		h.logger.Println("validation error:", err)
+		http.Error(rw, "validation error", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webrssService.UndoMarkAllRead(req.Context(), undoData.Token); errors.Is(err, webrss.ErrUndoExpired) {
+		http.Error(rw, http.StatusText(http.StatusGone), http.StatusGone)
+		return
+	} else if err != nil {
+		h.logger.Println("cannot undo marking entries as read: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/readlater"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type readLaterService interface {
+	Settings(ctx context.Context) (readlater.Settings, error)
+	SaveSettings(ctx context.Context, settings readlater.Settings) error
+}
+
+type ReadLaterSettingsValid struct {
+	Service              string `validate:"omitempty,oneof=wallabag" json:"service"`
+	AutoSendStarred      bool   `json:"auto_send_starred"`
+	WallabagURL          string `validate:"omitempty,url,max=2048" json:"wallabag_url"`
+	WallabagClientID     string `validate:"max=255" json:"wallabag_client_id"`
+	WallabagClientSecret string `validate:"max=255" json:"wallabag_client_secret"`
+	WallabagUsername     string `validate:"max=255" json:"wallabag_username"`
+	WallabagPassword     string `validate:"max=255" json:"wallabag_password"`
+}
+
+type readLaterHandler struct {
+	logger           *log.Logger
+	readLaterService readLaterService
+}
+
+func NewReadLater(logger *log.Logger, service readLaterService) *readLaterHandler {
+	return &readLaterHandler{
+		readLaterService: service,
+		logger:           logger,
+	}
+}
+
+// Send saves entry in the configured read-later service.
+func (h *entryHandler) Send(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.SendEntry(req.Context(), id); err != nil {
+		h.logger.Println("cannot send entry:", err)
+		switch {
+		case errors.Is(err, readlater.ErrNotConfigured):
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		case errors.Is(err, readlater.ErrSendFailed):
+			http.Error(rw, readlater.ErrSendFailed.Error(), http.StatusBadGateway)
+		default:
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *readLaterHandler) GetSettings(rw http.ResponseWriter, req *http.Request) {
+	settings, err := h.readLaterService.Settings(req.Context())
+	if err != nil {
+		h.logger.Println("cannot fetch read-later settings: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(settings); err != nil {
+		h.logger.Println("cannot serialize read-later settings: ", err)
+	}
+}
+
+// SaveSettings saves read-later settings, empty client secret and password leave the current ones.
+func (h *readLaterHandler) SaveSettings(rw http.ResponseWriter, req *http.Request) {
+	settingsData := ReadLaterSettingsValid{}
+	if !readBody(h.logger, rw, req, &settingsData) {
+		return
+	}
+	settings := readlater.Settings{
+		Service:              settingsData.Service,
+		AutoSendStarred:      settingsData.AutoSendStarred,
+		WallabagURL:          settingsData.WallabagURL,
+		WallabagClientID:     settingsData.WallabagClientID,
+		WallabagClientSecret: settingsData.WallabagClientSecret,
+		WallabagUsername:     settingsData.WallabagUsername,
+		WallabagPassword:     settingsData.WallabagPassword,
+	}
+	if err := h.readLaterService.SaveSettings(req.Context(), settings); err != nil {
+		h.logger.Println("cannot save read-later settings:", err)
+		if errors.Is(err, readlater.ErrInvalidSettings) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (r *readLaterHandler) GetRoutes() *route.RegexpRouter {
+	settings := webrss.RESTEndPoint{
+		Get: r.GetSettings,
+		Put: r.SaveSettings,
+	}
+
+	setHeaders := middleware.SetHeaders(map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	})
+
+	routing := metrics.NewRouter()
+	routing.Add(`^/settings/?$`, setHeaders(settings.Dispatch))
+
+	return routing.RegexpRouter
+}
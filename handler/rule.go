@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type RuleValid struct {
+	Title      string `validate:"max=255" json:"title"`
+	FeedID     int64  `validate:"min=0" json:"feed_id"`
+	CategoryID int64  `validate:"min=0" json:"category_id"`
+	Field      string `validate:"required,oneof=title author content link tags" json:"field"`
+	MatchType  string `validate:"required,oneof=keyword regex" json:"match_type"`
+	Pattern    string `validate:"required,max=1024" json:"pattern"`
+	Action     string `validate:"required,oneof=read star hide tag" json:"action"`
+	Tag        string `validate:"max=255" json:"tag"`
+}
+
+func (v RuleValid) apply(rule repository.Rule) repository.Rule {
+	rule.Title = v.Title
+	rule.FeedID = repository.NullInt64{}
+	if v.FeedID > 0 {
+		rule.FeedID = repository.NewNullInt64(v.FeedID)
+	}
+	rule.CategoryID = repository.NullInt64{}
+	if v.CategoryID > 0 {
+		rule.CategoryID = repository.NewNullInt64(v.CategoryID)
+	}
+	rule.Field = v.Field
+	rule.MatchType = v.MatchType
+	rule.Pattern = v.Pattern
+	rule.Action = v.Action
+	rule.Tag = repository.NullString{}
+	if v.Tag != "" {
+		rule.Tag = repository.NewNullString(v.Tag)
+	}
+	return rule
+}
+
+type ruleHandler struct {
+	logger        *log.Logger
+	webrssService webrssService
+}
+
+func NewRule(logger *log.Logger, service webrssService) *ruleHandler {
+	return &ruleHandler{
+		webrssService: service,
+		logger:        logger,
+	}
+}
+
+// readRule reads and validates rule from request body, writes error response when it fails.
+func (h *ruleHandler) readRule(rw http.ResponseWriter, req *http.Request) (RuleValid, bool) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return RuleValid{}, false
+	}
+	ruleData := RuleValid{}
+	if err := json.Unmarshal(body, &ruleData); err != nil {
+		h.logger.Println("can't unmarshal body:", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return RuleValid{}, false
+	}
+	if err = validator.New().Struct(ruleData); err != nil {
+		h.logger.Println("validation error:", err)
+		http.Error(rw, "validation error", http.StatusBadRequest)
+		return RuleValid{}, false
+	}
+	return ruleData, true
+}
+
+func (h *ruleHandler) handleError(rw http.ResponseWriter, msg string, err error) {
+	h.logger.Println(msg, err)
+	if errors.Is(err, webrss.ErrInvalidRule) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *ruleHandler) List(rw http.ResponseWriter, req *http.Request) {
+	rules, err := h.webrssService.ListRules(req.Context())
+	if err != nil {
+		h.logger.Println("cannot fetch rules: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": rules,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize rules: ", err)
+	}
+}
+
+func (h *ruleHandler) Create(rw http.ResponseWriter, req *http.Request) {
+	ruleData, ok := h.readRule(rw, req)
+	if !ok {
+		return
+	}
+	if err := h.webrssService.CreateRule(req.Context(), ruleData.apply(repository.Rule{})); err != nil {
+		h.handleError(rw, "error creating rule:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *ruleHandler) Update(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	ruleData, ok := h.readRule(rw, req)
+	if !ok {
+		return
+	}
+	ctx := req.Context()
+	rule, err := h.webrssService.GetRule(ctx, id)
+	if err != nil {
+		h.handleError(rw, "error getting rule:", err)
+		return
+	}
+	if err := h.webrssService.UpdateRule(ctx, ruleData.apply(rule)); err != nil {
+		h.handleError(rw, "error updating rule:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *ruleHandler) Delete(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.DeleteRule(req.Context(), id); err != nil {
+		h.handleError(rw, "cannot delete rule:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+// Test returns which of the latest entries would be matched by rule given in request body.
+func (h *ruleHandler) Test(rw http.ResponseWriter, req *http.Request) {
+	ruleData, ok := h.readRule(rw, req)
+	if !ok {
+		return
+	}
+	entries, err := h.webrssService.TestRule(req.Context(), ruleData.apply(repository.Rule{}))
+	if err != nil {
+		h.handleError(rw, "error testing rule:", err)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": entries,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize entries: ", err)
+	}
+}
+
+func (r *ruleHandler) GetRoutes() *route.RegexpRouter {
+	resource := webrss.RESTEndPoint{
+		Delete: r.Delete,
+		Put:    r.Update,
+	}
+	collection := webrss.RESTEndPoint{
+		Get:  r.List,
+		Post: r.Create,
+	}
+
+	setHeaders := middleware.SetHeaders(map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	})
+
+	routing := metrics.NewRouter()
+	routing.Add(`^/?$`, setHeaders(collection.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/?$`, setHeaders(resource.Dispatch))
+	routing.Add(`^/test/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.Test)))
+
+	return routing.RegexpRouter
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type SavedSearchValid struct {
+	Name       string `validate:"required,max=255" json:"name"`
+	Query      string `validate:"required,max=1024" json:"query"`
+	Mode       string `validate:"omitempty,oneof=natural boolean" json:"mode"`
+	CategoryID int64  `validate:"required,min=1" json:"category_id"`
+}
+
+func (v SavedSearchValid) apply(savedSearch repository.SavedSearch) repository.SavedSearch {
+	savedSearch.Name = v.Name
+	savedSearch.Query = v.Query
+	savedSearch.Mode = v.Mode
+	if savedSearch.Mode == "" {
+		savedSearch.Mode = repository.SearchModeNatural
+	}
+	savedSearch.CategoryID = v.CategoryID
+	return savedSearch
+}
+
+type savedSearchHandler struct {
+	logger        *log.Logger
+	webrssService webrssService
+}
+
+func NewSavedSearch(logger *log.Logger, service webrssService) *savedSearchHandler {
+	return &savedSearchHandler{
+		webrssService: service,
+		logger:        logger,
+	}
+}
+
+// readSavedSearch reads and validates saved search from request body, writes error response when it fails.
+func (h *savedSearchHandler) readSavedSearch(rw http.ResponseWriter, req *http.Request) (SavedSearchValid, bool) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return SavedSearchValid{}, false
+	}
+	savedSearchData := SavedSearchValid{}
+	if err := json.Unmarshal(body, &savedSearchData); err != nil {
+		h.logger.Println("can't unmarshal body:", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return SavedSearchValid{}, false
+	}
+	if err = validator.New().Struct(savedSearchData); err != nil {
+		h.logger.Println("validation error:", err)
+		http.Error(rw, "validation error", http.StatusBadRequest)
+		return SavedSearchValid{}, false
+	}
+	return savedSearchData, true
+}
+
+func (h *savedSearchHandler) handleError(rw http.ResponseWriter, msg string, err error) {
+	h.logger.Println(msg, err)
+	if errors.Is(err, webrss.ErrInvalidSavedSearch) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *savedSearchHandler) List(rw http.ResponseWriter, req *http.Request) {
+	savedSearches, err := h.webrssService.ListSavedSearches(req.Context())
+	if err != nil {
+		h.logger.Println("cannot fetch saved searches: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": savedSearches,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize saved searches: ", err)
+	}
+}
+
+func (h *savedSearchHandler) Create(rw http.ResponseWriter, req *http.Request) {
+	savedSearchData, ok := h.readSavedSearch(rw, req)
+	if !ok {
+		return
+	}
+	if err := h.webrssService.CreateSavedSearch(req.Context(), savedSearchData.apply(repository.SavedSearch{})); err != nil {
+		h.handleError(rw, "error creating saved search:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *savedSearchHandler) Update(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	savedSearchData, ok := h.readSavedSearch(rw, req)
+	if !ok {
+		return
+	}
+	ctx := req.Context()
+	savedSearch, err := h.webrssService.GetSavedSearch(ctx, id)
+	if err != nil {
+		h.handleError(rw, "error getting saved search:", err)
+		return
+	}
+	if err := h.webrssService.UpdateSavedSearch(ctx, savedSearchData.apply(savedSearch)); err != nil {
+		h.handleError(rw, "error updating saved search:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *savedSearchHandler) Delete(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.DeleteSavedSearch(req.Context(), id); err != nil {
+		h.handleError(rw, "cannot delete saved search:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (r *savedSearchHandler) GetRoutes() *route.RegexpRouter {
+	resource := webrss.RESTEndPoint{
+		Delete: r.Delete,
+		Put:    r.Update,
+	}
+	collection := webrss.RESTEndPoint{
+		Get:  r.List,
+		Post: r.Create,
+	}
+
+	setHeaders := middleware.SetHeaders(map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	})
+
+	routing := metrics.NewRouter()
+	routing.Add(`^/?$`, setHeaders(collection.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/?$`, setHeaders(resource.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/seen/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.MarkSeen)))
+
+	return routing.RegexpRouter
+}
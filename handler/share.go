@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/sanitize"
+)
+
+// sharePageHeaders are set on public entry page, its content comes from the feed, so nothing but
+// images and inline styles is allowed, and neither the page nor its token should leak elsewhere.
+var sharePageHeaders = map[string]string{
+	"Content-Type":            "text/html; charset=utf-8",
+	"Content-Security-Policy": "default-src 'none'; img-src http: https:; style-src 'unsafe-inline'; base-uri 'none'; form-action 'none'",
+	"Referrer-Policy":         "no-referrer",
+	"X-Robots-Tag":            "noindex, nofollow",
+	"X-Content-Type-Options":  "nosniff",
+}
+
+type shareHandler struct {
+	logger        *log.Logger
+	webrssService webrssService
+}
+
+func NewShare(logger *log.Logger, service webrssService) *shareHandler {
+	return &shareHandler{
+		webrssService: service,
+		logger:        logger,
+	}
+}
+
+func (h *shareHandler) List(rw http.ResponseWriter, req *http.Request) {
+	shares, err := h.webrssService.ListShares(req.Context())
+	if err != nil {
+		h.logger.Println("cannot fetch shares: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": shares,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize shares: ", err)
+	}
+}
+
+func (h *shareHandler) Revoke(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.RevokeShare(req.Context(), id); err != nil {
+		h.logger.Println("cannot revoke share: ", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+// Page renders read-only page of entry shared with token given in url, its content is sanitized.
+func (h *shareHandler) Page(rw http.ResponseWriter, req *http.Request) {
+	token, ok := route.GetParam(req, "token")
+	if !ok {
+		http.NotFound(rw, req)
+		return
+	}
+	entry, err := h.webrssService.GetSharedEntry(req.Context(), token)
+	if err != nil {
+		h.logger.Println("cannot fetch shared entry: ", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.NotFound(rw, req)
+			return
+		}
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	// links in the content are made absolute, so they point to the original site
+	base, _ := url.Parse(entry.Link)
+	tmpl, err := template.ParseFiles("templates/share.html")
+	if err != nil {
+		h.logger.Println("cannot parse template:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	tmplData := struct {
+		Entry   repository.Entry
+		Content template.HTML
+	}{
+		Entry:   entry,
+		Content: template.HTML(sanitize.HTML(entry.Summary.String, base)),
+	}
+	if err := tmpl.Execute(rw, tmplData); err != nil {
+		h.logger.Println("cannot execute template:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (r *shareHandler) GetRoutes() *route.RegexpRouter {
+	setHeaders := middleware.SetHeaders(map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	})
+
+	routing := metrics.NewRouter()
+	routing.Add(`^/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodGet})(r.List)))
+	routing.Add(`^/(?P<id>\d+)/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodDelete})(r.Revoke)))
+
+	return routing.RegexpRouter
+}
+
+// GetPublicRoutes returns routes available without logging in.
+func (r *shareHandler) GetPublicRoutes() *route.RegexpRouter {
+	routing := metrics.NewRouter()
+	routing.Add(`^/(?P<token>[\w-]+)/?$`, middleware.SetHeaders(sharePageHeaders)(
+		middleware.AllowedMethods([]string{http.MethodGet})(r.Page)))
+
+	return routing.RegexpRouter
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type UserTagValid struct {
+	Name string `validate:"required,max=255" json:"name"`
+}
+
+type EntriesUserTagValid struct {
+	IDs []int64 `validate:"required,min=1,max=1000,dive,min=1" json:"ids"`
+	Tag string  `validate:"required,max=255" json:"tag"`
+}
+
+type userTagHandler struct {
+	logger        *log.Logger
+	webrssService webrssService
+}
+
+func NewUserTag(logger *log.Logger, service webrssService) *userTagHandler {
+	return &userTagHandler{
+		webrssService: service,
+		logger:        logger,
+	}
+}
+
+// readBody reads and validates value from request body, writes error response when it fails.
+func readBody(logger *log.Logger, rw http.ResponseWriter, req *http.Request, value interface{}) bool {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		logger.Println("error reading body:", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return false
+	}
+	if err := json.Unmarshal(body, value); err != nil {
+		logger.Println("can't unmarshal body:", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return false
+	}
+	if err = validator.New().Struct(value); err != nil {
+		logger.Println("validation error:", err)
+		http.Error(rw, "validation error", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func handleUserTagError(logger *log.Logger, rw http.ResponseWriter, msg string, err error) {
+	logger.Println(msg, err)
+	if errors.Is(err, webrss.ErrInvalidUserTag) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *userTagHandler) List(rw http.ResponseWriter, req *http.Request) {
+	userTags, err := h.webrssService.ListUserTags(req.Context())
+	if err != nil {
+		h.logger.Println("cannot fetch user tags: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": userTags,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize user tags: ", err)
+	}
+}
+
+// Rename renames tag, using name of another tag merges them.
+func (h *userTagHandler) Rename(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	userTagData := UserTagValid{}
+	if !readBody(h.logger, rw, req, &userTagData) {
+		return
+	}
+	if err := h.webrssService.RenameUserTag(req.Context(), id, userTagData.Name); err != nil {
+		handleUserTagError(h.logger, rw, "error renaming user tag:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *userTagHandler) Delete(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.DeleteUserTag(req.Context(), id); err != nil {
+		handleUserTagError(h.logger, rw, "error deleting user tag:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (r *userTagHandler) GetRoutes() *route.RegexpRouter {
+	resource := webrss.RESTEndPoint{
+		Delete: r.Delete,
+		Put:    r.Rename,
+	}
+
+	setHeaders := middleware.SetHeaders(map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	})
+
+	routing := metrics.NewRouter()
+	routing.Add(`^/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodGet})(r.List)))
+	routing.Add(`^/(?P<id>\d+)/?$`, setHeaders(resource.Dispatch))
+
+	return routing.RegexpRouter
+}
+
+// TagEntries adds tag given in request body to entries with given ids.
+func (h *entryHandler) TagEntries(rw http.ResponseWriter, req *http.Request) {
+	tagData := EntriesUserTagValid{}
+	if !readBody(h.logger, rw, req, &tagData) {
+		return
+	}
+	if err := h.webrssService.TagEntries(req.Context(), tagData.IDs, tagData.Tag); err != nil {
+		handleUserTagError(h.logger, rw, "cannot tag entries:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+// UntagEntries removes tag given in request body from entries with given ids.
+func (h *entryHandler) UntagEntries(rw http.ResponseWriter, req *http.Request) {
+	tagData := EntriesUserTagValid{}
+	if !readBody(h.logger, rw, req, &tagData) {
+		return
+	}
+	if err := h.webrssService.UntagEntries(req.Context(), tagData.IDs, tagData.Tag); err != nil {
+		handleUserTagError(h.logger, rw, "cannot untag entries:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
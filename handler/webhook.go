@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Alkemic/go-route"
+	"github.com/Alkemic/go-route/middleware"
+
+	"github.com/Alkemic/webrss/metrics"
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/webrss"
+)
+
+type WebhookValid struct {
+	Title           string `validate:"max=255" json:"title"`
+	URL             string `validate:"required,url,max=2048" json:"url"`
+	FeedID          int64  `validate:"min=0" json:"feed_id"`
+	CategoryID      int64  `validate:"min=0" json:"category_id"`
+	RuleID          int64  `validate:"min=0" json:"rule_id"`
+	PayloadTemplate string `validate:"max=65535" json:"payload_template"`
+	Secret          string `validate:"max=255" json:"secret"`
+}
+
+// apply sets webhook's fields, empty secret leaves the current one.
+func (v WebhookValid) apply(webhook repository.Webhook) repository.Webhook {
+	webhook.Title = v.Title
+	webhook.URL = v.URL
+	webhook.FeedID = repository.NullInt64{}
+	if v.FeedID > 0 {
+		webhook.FeedID = repository.NewNullInt64(v.FeedID)
+	}
+	webhook.CategoryID = repository.NullInt64{}
+	if v.CategoryID > 0 {
+		webhook.CategoryID = repository.NewNullInt64(v.CategoryID)
+	}
+	webhook.RuleID = repository.NullInt64{}
+	if v.RuleID > 0 {
+		webhook.RuleID = repository.NewNullInt64(v.RuleID)
+	}
+	webhook.PayloadTemplate = repository.NullString{}
+	if v.PayloadTemplate != "" {
+		webhook.PayloadTemplate = repository.NewNullString(v.PayloadTemplate)
+	}
+	if v.Secret != "" {
+		webhook.Secret = v.Secret
+	}
+	return webhook
+}
+
+type webhookHandler struct {
+	logger        *log.Logger
+	webrssService webrssService
+}
+
+func NewWebhook(logger *log.Logger, service webrssService) *webhookHandler {
+	return &webhookHandler{
+		webrssService: service,
+		logger:        logger,
+	}
+}
+
+func (h *webhookHandler) handleError(rw http.ResponseWriter, msg string, err error) {
+	h.logger.Println(msg, err)
+	if errors.Is(err, webrss.ErrInvalidWebhook) {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *webhookHandler) List(rw http.ResponseWriter, req *http.Request) {
+	webhooks, err := h.webrssService.ListWebhooks(req.Context())
+	if err != nil {
+		h.logger.Println("cannot fetch webhooks: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": webhooks,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize webhooks: ", err)
+	}
+}
+
+func (h *webhookHandler) Get(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	webhook, err := h.webrssService.GetWebhook(req.Context(), id)
+	if err != nil {
+		h.handleError(rw, "cannot fetch webhook:", err)
+		return
+	}
+	if err := json.NewEncoder(rw).Encode(webhook); err != nil {
+		h.logger.Println("cannot serialize webhook: ", err)
+	}
+}
+
+func (h *webhookHandler) Create(rw http.ResponseWriter, req *http.Request) {
+	webhookData := WebhookValid{}
+	if !readBody(h.logger, rw, req, &webhookData) {
+		return
+	}
+	if err := h.webrssService.CreateWebhook(req.Context(), webhookData.apply(repository.Webhook{})); err != nil {
+		h.handleError(rw, "error creating webhook:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *webhookHandler) Update(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	webhookData := WebhookValid{}
+	if !readBody(h.logger, rw, req, &webhookData) {
+		return
+	}
+	ctx := req.Context()
+	webhook, err := h.webrssService.GetWebhook(ctx, id)
+	if err != nil {
+		h.handleError(rw, "error getting webhook:", err)
+		return
+	}
+	if err := h.webrssService.UpdateWebhook(ctx, webhookData.apply(webhook)); err != nil {
+		h.handleError(rw, "error updating webhook:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (h *webhookHandler) Delete(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.DeleteWebhook(req.Context(), id); err != nil {
+		h.handleError(rw, "cannot delete webhook:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+// ListDeliveries returns the latest deliveries of webhook with results of their last attempts.
+func (h *webhookHandler) ListDeliveries(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	deliveries, err := h.webrssService.ListWebhookDeliveries(req.Context(), id)
+	if err != nil {
+		h.logger.Println("cannot fetch webhook deliveries: ", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"objects": deliveries,
+	}
+	if err := json.NewEncoder(rw).Encode(data); err != nil {
+		h.logger.Println("cannot serialize webhook deliveries: ", err)
+	}
+}
+
+// Redeliver queues delivery again.
+func (h *webhookHandler) Redeliver(rw http.ResponseWriter, req *http.Request) {
+	id, err := requestIntParam(req, "id")
+	if err != nil {
+		h.logger.Println("cannot get param 'id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	deliveryID, err := requestIntParam(req, "delivery_id")
+	if err != nil {
+		h.logger.Println("cannot get param 'delivery_id': ", err)
+		http.Error(rw, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if err := h.webrssService.RedeliverWebhook(req.Context(), id, deliveryID); err != nil {
+		h.handleError(rw, "cannot redeliver webhook:", err)
+		return
+	}
+	fmt.Fprint(rw, `{"status":"ok"}`)
+}
+
+func (r *webhookHandler) GetRoutes() *route.RegexpRouter {
+	resource := webrss.RESTEndPoint{
+		Get:    r.Get,
+		Delete: r.Delete,
+		Put:    r.Update,
+	}
+	collection := webrss.RESTEndPoint{
+		Get:  r.List,
+		Post: r.Create,
+	}
+
+	setHeaders := middleware.SetHeaders(map[string]string{
+		"Content-Type": "application/json; charset=utf-8",
+	})
+
+	routing := metrics.NewRouter()
+	routing.Add(`^/?$`, setHeaders(collection.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/?$`, setHeaders(resource.Dispatch))
+	routing.Add(`^/(?P<id>\d+)/deliveries/?$`, setHeaders(middleware.AllowedMethods([]string{http.MethodGet})(r.ListDeliveries)))
+	routing.Add(`^/(?P<id>\d+)/deliveries/(?P<delivery_id>\d+)/redeliver/?$`,
+		setHeaders(middleware.AllowedMethods([]string{http.MethodPost})(r.Redeliver)))
+
+	return routing.RegexpRouter
+}
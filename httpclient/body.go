@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+const (
+	// maxCompressionRatio is the highest accepted ratio between decompressed and received body size.
+	maxCompressionRatio = 100
+	// compressionRatioThreshold is the size of decompressed body after which compression ratio is checked,
+	// small bodies are allowed to compress well.
+	compressionRatioThreshold = 1 << 20
+)
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// body enforces size limits on response body and translates errors caused by aborted request into *FetchError.
+type body struct {
+	url    string
+	raw    *countingReader
+	closer io.Closer
+	gzip   bool
+	reader io.Reader
+	read   int64
+	limit  int64
+	reason *abortReason
+	stop   func()
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		b.reader = b.raw
+		if b.gzip {
+			zr, err := gzip.NewReader(b.raw)
+			if err != nil {
+				return 0, b.wrapErr(err)
+			}
+			b.reader = zr
+		}
+	}
+	n, err := b.reader.Read(p)
+	b.read += int64(n)
+	if b.limit > 0 && (b.raw.n > b.limit || b.read > b.limit) {
+		return n, newFetchError(b.url, ErrBodyTooLarge, nil)
+	}
+	if b.gzip && b.read > compressionRatioThreshold && b.read > b.raw.n*maxCompressionRatio {
+		return n, newFetchError(b.url, ErrDecompressionBomb, nil)
+	}
+	if err != nil && err != io.EOF {
+		return n, b.wrapErr(err)
+	}
+	return n, err
+}
+
+func (b *body) wrapErr(err error) error {
+	if r := b.reason.get(); r != nil {
+		return newFetchError(b.url, r, err)
+	}
+	return err
+}
+
+func (b *body) Close() error {
+	b.stop()
+	return b.closer.Close()
+}
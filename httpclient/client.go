@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// ConnectTimeout limits time spent on dialing and TLS handshake.
+	ConnectTimeout time.Duration
+	// HeaderTimeout limits time between sending request and receiving response headers.
+	HeaderTimeout time.Duration
+	// Timeout limits the whole request, including reading the body.
+	Timeout time.Duration
+	// MaxBodySize limits the size of the response body, both as received and after decompression.
+	MaxBodySize int64
+	// AllowedNetworks are excluded from the check against loopback, link-local and private addresses.
+	AllowedNetworks []*net.IPNet
+}
+
+// New returns http client that enforces limits from given config and refuses to connect to internal
+// addresses, every violation is reported as *FetchError.
+func New(cfg Config) *http.Client {
+	return &http.Client{Transport: NewTransport(cfg)}
+}
+
+type transport struct {
+	cfg    Config
+	dialer *net.Dialer
+	base   *http.Transport
+}
+
+func NewTransport(cfg Config) *transport {
+	t := &transport{cfg: cfg}
+	t.dialer = &net.Dialer{
+		Timeout:   cfg.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   t.control,
+	}
+	t.base = &http.Transport{
+		DialContext:         t.dial,
+		TLSHandshakeTimeout: cfg.ConnectTimeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		// decompression is handled by us, so size of decompressed body can be limited
+		DisableCompression: true,
+	}
+	return t
+}
+
+func (t *transport) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := t.dialer.DialContext(ctx, network, addr)
+	if err != nil && isTimeout(err) && ctx.Err() == nil {
+		return nil, newFetchError(addr, ErrConnectTimeout, err)
+	}
+	return conn, err
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// abortReason holds the first reason for which the request was cancelled.
+type abortReason struct {
+	sync.Mutex
+	err error
+}
+
+func (r *abortReason) set(err error) {
+	r.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.Unlock()
+}
+
+func (r *abortReason) get() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.err
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	ctx, cancel := context.WithCancel(req.Context())
+	reason := &abortReason{}
+	abortAfter := func(d time.Duration, err error) *time.Timer {
+		if d <= 0 {
+			return nil
+		}
+		return time.AfterFunc(d, func() {
+			reason.set(err)
+			cancel()
+		})
+	}
+	totalTimer := abortAfter(t.cfg.Timeout, ErrTimeout)
+	headerTimer := abortAfter(t.cfg.HeaderTimeout, ErrHeaderTimeout)
+	stop := func() {
+		if totalTimer != nil {
+			totalTimer.Stop()
+		}
+		cancel()
+	}
+
+	req = req.Clone(ctx)
+	requestedGzip := false
+	if req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" && req.Method != http.MethodHead {
+		req.Header.Set("Accept-Encoding", "gzip")
+		requestedGzip = true
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if headerTimer != nil {
+		headerTimer.Stop()
+	}
+	if err != nil {
+		stop()
+		if r := reason.get(); r != nil {
+			return nil, newFetchError(url, r, err)
+		}
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) && isTimeout(err) {
+			// the only timeout left in the transport is the TLS handshake one
+			return nil, newFetchError(url, ErrConnectTimeout, err)
+		}
+		return nil, err
+	}
+
+	if t.cfg.MaxBodySize > 0 && resp.ContentLength > t.cfg.MaxBodySize {
+		resp.Body.Close()
+		stop()
+		return nil, newFetchError(url, ErrBodyTooLarge, nil)
+	}
+
+	b := &body{
+		url:    url,
+		raw:    &countingReader{r: resp.Body},
+		closer: resp.Body,
+		limit:  t.cfg.MaxBodySize,
+		reason: reason,
+		stop:   stop,
+	}
+	if requestedGzip && resp.Header.Get("Content-Encoding") == "gzip" {
+		b.gzip = true
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	resp.Body = b
+	return resp, nil
+}
@@ -0,0 +1,164 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("cannot compress data: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("cannot compress data: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestClient(t *testing.T) {
+	type check func([]byte, error, *testing.T)
+	checks := func(cs ...check) []check { return cs }
+	hasNoError := func(body []byte, err error, t *testing.T) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("Expected err to be nil, but got '%v'", err)
+		}
+	}
+	hasError := func(expectedErr error) check {
+		return func(body []byte, err error, t *testing.T) {
+			t.Helper()
+			if !errors.Is(err, expectedErr) {
+				t.Errorf("Expected error to be '%v', but got '%v'", expectedErr, err)
+			}
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) {
+				t.Errorf("Expected error to be *FetchError, but got '%T'", err)
+			}
+		}
+	}
+	hasBody := func(expectedBody string) check {
+		return func(body []byte, err error, t *testing.T) {
+			t.Helper()
+			if string(body) != expectedBody {
+				t.Errorf("Expected body to be '%s', but got '%s'", expectedBody, body)
+			}
+		}
+	}
+	bomb := gzipped(t, bytes.Repeat([]byte{0}, 4<<20))
+	_, loopbackNetwork, _ := net.ParseCIDR("127.0.0.1/32")
+	loopback := []*net.IPNet{loopbackNetwork}
+	tests := []struct {
+		name    string
+		cfg     Config
+		handler http.HandlerFunc
+
+		checks []check
+	}{{
+		name: "plain body",
+		cfg:  Config{AllowedNetworks: loopback, MaxBodySize: 1024},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte("feed content"))
+		},
+		checks: checks(hasNoError, hasBody("feed content")),
+	}, {
+		name: "gzipped body",
+		cfg:  Config{AllowedNetworks: loopback, MaxBodySize: 1024},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Accept-Encoding") != "gzip" {
+				t.Errorf("Expected request to accept gzip")
+			}
+			rw.Header().Set("Content-Encoding", "gzip")
+			rw.Write(gzipped(t, []byte("feed content")))
+		},
+		checks: checks(hasNoError, hasBody("feed content")),
+	}, {
+		name: "body with too big content length",
+		cfg:  Config{AllowedNetworks: loopback, MaxBodySize: 10},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(strings.Repeat("a", 20)))
+		},
+		checks: checks(hasError(ErrBodyTooLarge)),
+	}, {
+		name: "too big chunked body",
+		cfg:  Config{AllowedNetworks: loopback, MaxBodySize: 10},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(strings.Repeat("a", 8)))
+			rw.(http.Flusher).Flush()
+			rw.Write([]byte(strings.Repeat("a", 8)))
+		},
+		checks: checks(hasError(ErrBodyTooLarge)),
+	}, {
+		name: "too big decompressed body",
+		cfg:  Config{AllowedNetworks: loopback, MaxBodySize: 100},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Content-Encoding", "gzip")
+			rw.Write(gzipped(t, []byte(strings.Repeat("a", 200))))
+		},
+		checks: checks(hasError(ErrBodyTooLarge)),
+	}, {
+		name: "gzip bomb",
+		cfg:  Config{AllowedNetworks: loopback, MaxBodySize: 10 << 20},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Content-Encoding", "gzip")
+			rw.Write(bomb)
+		},
+		checks: checks(hasError(ErrDecompressionBomb)),
+	}, {
+		name: "header timeout",
+		cfg:  Config{AllowedNetworks: loopback, HeaderTimeout: 50 * time.Millisecond},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		},
+		checks: checks(hasError(ErrHeaderTimeout)),
+	}, {
+		name: "total timeout while reading body",
+		cfg:  Config{AllowedNetworks: loopback, HeaderTimeout: time.Second, Timeout: 100 * time.Millisecond},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte("partial"))
+			rw.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+		},
+		checks: checks(hasError(ErrTimeout)),
+	}, {
+		name: "loopback address is forbidden",
+		cfg:  Config{},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			t.Errorf("Expected request not to be made")
+		},
+		checks: checks(hasError(ErrForbiddenAddress)),
+	}, {
+		name: "redirect to forbidden address",
+		cfg:  Config{AllowedNetworks: loopback},
+		handler: func(rw http.ResponseWriter, req *http.Request) {
+			http.Redirect(rw, req, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		},
+		checks: checks(hasError(ErrForbiddenAddress)),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			var body []byte
+			resp, err := New(tt.cfg).Get(server.URL)
+			if err == nil {
+				body, err = ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+			for _, ch := range tt.checks {
+				ch(body, err, t)
+			}
+		})
+	}
+}
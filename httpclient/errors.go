@@ -0,0 +1,38 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrConnectTimeout    = errors.New("connect timeout")
+	ErrHeaderTimeout     = errors.New("timeout awaiting response headers")
+	ErrTimeout           = errors.New("request timeout")
+	ErrBodyTooLarge      = errors.New("response body too large")
+	ErrDecompressionBomb = errors.New("suspicious compression ratio")
+	ErrForbiddenAddress  = errors.New("address is not allowed")
+)
+
+// FetchError is returned when request was aborted because it violated one of the configured limits,
+// Err is always one of the package's Err* values, so it can be checked with errors.Is.
+type FetchError struct {
+	URL   string
+	Err   error
+	Cause error
+}
+
+func newFetchError(url string, kind, cause error) *FetchError {
+	return &FetchError{URL: url, Err: kind, Cause: cause}
+}
+
+func (e *FetchError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s (%s)", e.URL, e.Err, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.URL, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"net"
+	"syscall"
+)
+
+var forbiddenNetworks = mustParseNetworks(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local, cloud metadata services
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved, broadcast
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"64:ff9b::/96",   // IPv4/IPv6 translation
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+func mustParseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// control is called by dialer for every address after DNS resolution, so it also guards redirects
+// and hosts that resolve to internal addresses.
+func (t *transport) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return newFetchError(address, ErrForbiddenAddress, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return newFetchError(address, ErrForbiddenAddress, nil)
+	}
+	if containsIP(t.cfg.AllowedNetworks, ip) {
+		return nil
+	}
+	if containsIP(forbiddenNetworks, ip) {
+		return newFetchError(address, ErrForbiddenAddress, nil)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const unreadCountTimeout = 5 * time.Second
+
+// unreadCollector counts unread entries at the time of scraping.
+type unreadCollector struct {
+	logger        *log.Logger
+	unreadCounter unreadCounter
+	unread        *prometheus.Desc
+}
+
+func newUnreadCollector(logger *log.Logger, unreadCounter unreadCounter) *unreadCollector {
+	return &unreadCollector{
+		logger:        logger,
+		unreadCounter: unreadCounter,
+		unread:        prometheus.NewDesc(namespace+"_unread_entries", "Number of unread entries.", nil, nil),
+	}
+}
+
+func (c *unreadCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.unread
+}
+
+func (c *unreadCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), unreadCountTimeout)
+	defer cancel()
+	unread, err := c.unreadCounter.CountUnread(ctx)
+	if err != nil {
+		c.logger.Println("cannot count unread entries: ", err)
+		ch <- prometheus.NewInvalidMetric(c.unread, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.unread, prometheus.GaugeValue, float64(unread))
+}
+
+// dbStatsCollector exposes connection pool statistics.
+type dbStatsCollector struct {
+	db                *sqlx.DB
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newDBStatsCollector(db *sqlx.DB) *dbStatsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(namespace+"_db_"+name, help, nil, nil)
+	}
+	return &dbStatsCollector{
+		db:                db,
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:              desc("open_connections", "The number of established connections both in use and idle."),
+		inUse:             desc("in_use_connections", "The number of connections currently in use."),
+		idle:              desc("idle_connections", "The number of idle connections."),
+		waitCount:         desc("wait_count_total", "The total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "The total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime."),
+	}
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
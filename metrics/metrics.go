@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "webrss"
+
+type unreadCounter interface {
+	CountUnread(ctx context.Context) (int64, error)
+}
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	updaterRunDuration prometheus.Histogram
+	feedFetches        *prometheus.CounterVec
+	feedFetchDuration  *prometheus.HistogramVec
+	entriesIngested    prometheus.Counter
+}
+
+func New(logger *log.Logger, db *sqlx.DB, unreadCounter unreadCounter) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled HTTP requests.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent on handling HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		updaterRunDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "updater_run_duration_seconds",
+			Help:      "Time spent on updating all feeds.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		feedFetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "feed_fetches_total",
+			Help:      "Number of feed fetches by feed and outcome.",
+		}, []string{"feed_id", "outcome"}),
+		feedFetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "feed_fetch_duration_seconds",
+			Help:      "Time spent on fetching and saving a feed, by feed.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"feed_id"}),
+		entriesIngested: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "entries_ingested_total",
+			Help:      "Number of new entries saved by updater.",
+		}),
+	}
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.updaterRunDuration,
+		m.feedFetches,
+		m.feedFetchDuration,
+		m.entriesIngested,
+		newUnreadCollector(logger, unreadCounter),
+		newDBStatsCollector(db),
+	)
+	return m
+}
+
+// Handler returns handler exposing metrics in prometheus format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) ObserveUpdaterRun(duration time.Duration) {
+	m.updaterRunDuration.Observe(duration.Seconds())
+}
+
+// ObserveFetch records fetch of the feed. Fetches are labeled by feed id, so number of series grows with
+// number of feeds, deleted feeds' series are kept until restart.
+func (m *Metrics) ObserveFetch(feedID int64, outcome string, duration time.Duration) {
+	id := strconv.FormatInt(feedID, 10)
+	m.feedFetches.WithLabelValues(id, outcome).Inc()
+	m.feedFetchDuration.WithLabelValues(id).Observe(duration.Seconds())
+}
+
+func (m *Metrics) AddIngestedEntries(count int) {
+	m.entriesIngested.Add(float64(count))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// TimeTrack counts requests and measures their duration per route, it's meant to be used alongside
+// middleware.TimeTrack. Routes are labeled by patterns of routes matched in Router, requests which didn't
+// match any route are labeled as "other".
+func (m *Metrics) TimeTrack(f http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		req, label := withRouteLabel(req)
+		defer func(start time.Time) {
+			route := label.String()
+			m.httpRequests.WithLabelValues(route, req.Method, strconv.Itoa(recorder.status)).Inc()
+			m.httpDuration.WithLabelValues(route, req.Method).Observe(time.Since(start).Seconds())
+		}(time.Now())
+
+		f(recorder, req)
+	}
+}
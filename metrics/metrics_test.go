@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	handler := func(rw http.ResponseWriter, req *http.Request) {}
+	feedRouter := NewRouter()
+	feedRouter.Add(`^/?$`, handler)
+	feedRouter.Add(`^/(?P<id>\d+)/fetches/?$`, handler)
+	shareRouter := NewRouter()
+	shareRouter.Add(`^/(?P<token>[\w-]+)/?$`, handler)
+	router := NewRouter()
+	router.Add("^/api/feed", feedRouter.RegexpRouter)
+	router.Add("^/share", shareRouter.RegexpRouter)
+	router.Add("/static/", http.StripPrefix("/static/", http.NotFoundHandler()))
+	router.Add("/", handler)
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{{
+		name: "collection",
+		path: "/api/feed/",
+		want: "/api/feed",
+	}, {
+		name: "resource",
+		path: "/api/feed/12/fetches",
+		want: "/api/feed/{id}/fetches",
+	}, {
+		name: "share page",
+		path: "/share/f3b1-a2Z_9",
+		want: "/share/{token}",
+	}, {
+		name: "static files",
+		path: "/static/js/webrss.js",
+		want: "/static/",
+	}, {
+		name: "index",
+		path: "/",
+		want: "/",
+	}, {
+		name: "not found",
+		path: "/api/feed/abc",
+		want: "other",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, label := withRouteLabel(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if got := label.String(); got != tt.want {
+				t.Errorf("Expected route label to be '%v', but got '%v'", tt.want, got)
+			}
+		})
+	}
+}
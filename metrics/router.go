@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Alkemic/go-route"
+)
+
+// otherRoute is route label of requests not matched by any route, like not found ones.
+const otherRoute = "other"
+
+var (
+	patternParamRe = regexp.MustCompile(`\(\?P<(\w+)>[^)]*\)`)
+	patternCleaner = strings.NewReplacer("^", "", "$", "", "/?", "")
+)
+
+type routeLabelKey struct{}
+
+// routeLabel collects patterns of routes matched by nested routers.
+type routeLabel struct {
+	patterns []string
+}
+
+func (l *routeLabel) String() string {
+	if len(l.patterns) == 0 {
+		return otherRoute
+	}
+	label := strings.Join(l.patterns, "")
+	if label == "" {
+		return "/"
+	}
+	return label
+}
+
+func withRouteLabel(req *http.Request) (*http.Request, *routeLabel) {
+	label := &routeLabel{}
+	return req.WithContext(context.WithValue(req.Context(), routeLabelKey{}, label)), label
+}
+
+// recordPattern returns middleware adding pattern to route label of requests tracked by TimeTrack.
+func recordPattern(pattern string) route.Middleware {
+	pattern = patternCleaner.Replace(patternParamRe.ReplaceAllString(pattern, "{$1}"))
+	return func(f http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			if label, ok := req.Context().Value(routeLabelKey{}).(*routeLabel); ok {
+				label.patterns = append(label.patterns, pattern)
+			}
+			f(rw, req)
+		}
+	}
+}
+
+// Router is route.RegexpRouter, whose routes are labeled in metrics by their patterns, with parameters
+// replaced by their names, so number of label values is bounded by number of routes.
+type Router struct {
+	*route.RegexpRouter
+}
+
+func NewRouter() *Router {
+	return &Router{
+		RegexpRouter: route.New(),
+	}
+}
+
+// Add adds route like route.RegexpRouter.Add does, routers added as handlers are labeled with the pattern
+// they're mounted on followed by patterns of their own routes.
+func (r *Router) Add(pattern string, handler interface{}, allowedMethods ...string) *Router {
+	switch h := handler.(type) {
+	case func(http.ResponseWriter, *http.Request):
+		handler = recordPattern(pattern)(h)
+	case http.HandlerFunc:
+		handler = recordPattern(pattern)(h)
+	case *route.RegexpRouter:
+		h.AddMiddleware(recordPattern(pattern))
+	case *Router:
+		handler = h.AddMiddleware(recordPattern(pattern))
+	}
+	r.RegexpRouter.Add(pattern, handler, allowedMethods...)
+	return r
+}
@@ -0,0 +1,15 @@
+create table `fetch_log` (
+    `id` int(11) not null auto_increment,
+    `feed_id` int(11) not null,
+    `fetched_at` datetime not null,
+    `duration_ms` int(11) not null,
+    `status_code` int(11) not null default 0,
+    `bytes` int(11) not null default 0,
+    `items` int(11) not null default 0,
+    `new_entries` int(11) not null default 0,
+    `updated_entries` int(11) not null default 0,
+    `error` text collate utf8mb4_unicode_ci,
+    primary key (`id`),
+    key `fetch_log__feed_id__id` (`feed_id`, `id`),
+    constraint `fetch_log_ibfk_1` foreign key (`feed_id`) references `feed` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+drop table if exists `rule`;
+alter table `entry` drop column `tags`;
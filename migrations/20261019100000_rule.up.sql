@@ -0,0 +1,21 @@
+alter table `entry`
+    add column `tags` varchar(1024) collate utf8mb4_unicode_ci default null after `link`;
+
+create table `rule` (
+    `id` int(11) not null auto_increment,
+    `title` varchar(255) collate utf8mb4_unicode_ci not null,
+    `feed_id` int(11) default null,
+    `category_id` int(11) default null,
+    `field` varchar(16) collate utf8mb4_unicode_ci not null,
+    `match_type` varchar(16) collate utf8mb4_unicode_ci not null,
+    `pattern` varchar(1024) collate utf8mb4_unicode_ci not null,
+    `action` varchar(16) collate utf8mb4_unicode_ci not null,
+    `tag` varchar(255) collate utf8mb4_unicode_ci default null,
+    `created_at` datetime not null,
+    `updated_at` datetime default null,
+    `deleted_at` datetime default null,
+    primary key (`id`),
+    key `rule__deleted_at` (`deleted_at`),
+    constraint `rule_ibfk_1` foreign key (`feed_id`) references `feed` (`id`),
+    constraint `rule_ibfk_2` foreign key (`category_id`) references `category` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
@@ -0,0 +1,7 @@
+alter table `feed` drop column `hide_duplicates`;
+alter table `entry`
+    drop foreign key `entry_ibfk_2`,
+    drop key `entry_canonical_id`,
+    drop key `entry_normalized_link_idx`,
+    drop column `canonical_id`,
+    drop column `normalized_link`;
@@ -0,0 +1,9 @@
+alter table `entry`
+    add column `normalized_link` varchar(255) collate utf8mb4_unicode_ci not null default '' after `link`,
+    add column `canonical_id` int(11) default null after `feed_id`,
+    add key `entry_normalized_link_idx` (`normalized_link`),
+    add key `entry_canonical_id` (`canonical_id`),
+    add constraint `entry_ibfk_2` foreign key (`canonical_id`) references `entry` (`id`);
+
+alter table `feed`
+    add column `hide_duplicates` tinyint(1) not null default 0 after `category_id`;
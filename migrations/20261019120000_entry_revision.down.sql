@@ -0,0 +1,2 @@
+drop table if exists `entry_revision`;
+alter table `entry` drop column `content_changed_at`;
@@ -0,0 +1,14 @@
+alter table `entry`
+    add column `content_changed_at` datetime default null after `starred_at`;
+
+create table `entry_revision` (
+    `id` int(11) not null auto_increment,
+    `entry_id` int(11) not null,
+    `title` varchar(512) collate utf8mb4_unicode_ci not null,
+    `author` varchar(255) collate utf8mb4_unicode_ci default null,
+    `summary` longtext collate utf8mb4_unicode_ci,
+    `created_at` datetime not null,
+    primary key (`id`),
+    key `entry_revision_entry_id` (`entry_id`),
+    constraint `entry_revision_ibfk_1` foreign key (`entry_id`) references `entry` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
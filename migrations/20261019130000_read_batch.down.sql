@@ -0,0 +1 @@
+alter table `entry` drop key `entry_read_batch`, drop column `read_batch`;
@@ -0,0 +1,3 @@
+alter table `entry`
+    add column `read_batch` char(36) collate utf8mb4_unicode_ci default null after `read_at`,
+    add key `entry_read_batch` (`read_batch`);
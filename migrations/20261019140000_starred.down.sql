@@ -0,0 +1 @@
+alter table `entry` drop key `entry_starred_at`, drop column `starred_at`;
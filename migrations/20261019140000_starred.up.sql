@@ -0,0 +1,3 @@
+alter table `entry`
+    add column `starred_at` datetime default null after `read_at`,
+    add key `entry_starred_at` (`starred_at`);
@@ -0,0 +1 @@
+alter table `entry` drop key `entry_fulltext`;
@@ -0,0 +1 @@
+alter table `entry` add fulltext key `entry_fulltext` (`title`, `summary`, `author`);
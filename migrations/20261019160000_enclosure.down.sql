@@ -0,0 +1 @@
+alter table `entry` drop column `enclosure`;
@@ -0,0 +1,2 @@
+alter table `entry`
+    add column `enclosure` varchar(1024) collate utf8mb4_unicode_ci default null after `tags`;
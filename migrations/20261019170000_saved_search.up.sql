@@ -0,0 +1,14 @@
+create table `saved_search` (
+    `id` int(11) not null auto_increment,
+    `name` varchar(255) collate utf8mb4_unicode_ci not null,
+    `query` varchar(1024) collate utf8mb4_unicode_ci not null,
+    `mode` varchar(16) collate utf8mb4_unicode_ci not null,
+    `category_id` int(11) not null,
+    `last_read_at` datetime not null,
+    `created_at` datetime not null,
+    `updated_at` datetime default null,
+    `deleted_at` datetime default null,
+    primary key (`id`),
+    key `saved_search__deleted_at` (`deleted_at`),
+    constraint `saved_search_ibfk_1` foreign key (`category_id`) references `category` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
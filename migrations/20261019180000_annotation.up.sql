@@ -0,0 +1,15 @@
+create table `annotation` (
+    `id` int(11) not null auto_increment,
+    `entry_id` int(11) not null,
+    `kind` varchar(16) collate utf8mb4_unicode_ci not null,
+    `quote` text collate utf8mb4_unicode_ci default null,
+    `quote_offset` int(11) default null,
+    `note` text collate utf8mb4_unicode_ci default null,
+    `created_at` datetime not null,
+    `updated_at` datetime default null,
+    `deleted_at` datetime default null,
+    primary key (`id`),
+    key `annotation__entry_id` (`entry_id`),
+    key `annotation__deleted_at` (`deleted_at`),
+    constraint `annotation_ibfk_1` foreign key (`entry_id`) references `entry` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
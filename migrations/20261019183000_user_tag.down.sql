@@ -0,0 +1,2 @@
+drop table if exists `entry_user_tag`;
+drop table if exists `user_tag`;
@@ -0,0 +1,16 @@
+create table `user_tag` (
+    `id` int(11) not null auto_increment,
+    `name` varchar(255) collate utf8mb4_unicode_ci not null,
+    `created_at` datetime not null,
+    primary key (`id`),
+    unique key `user_tag_name` (`name`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
+
+create table `entry_user_tag` (
+    `entry_id` int(11) not null,
+    `user_tag_id` int(11) not null,
+    primary key (`entry_id`, `user_tag_id`),
+    key `entry_user_tag_user_tag_id` (`user_tag_id`),
+    constraint `entry_user_tag_ibfk_1` foreign key (`entry_id`) references `entry` (`id`),
+    constraint `entry_user_tag_ibfk_2` foreign key (`user_tag_id`) references `user_tag` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
@@ -0,0 +1 @@
+alter table `feed` drop column `entry_order`;
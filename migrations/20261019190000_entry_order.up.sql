@@ -0,0 +1,2 @@
+alter table `feed`
+    add column `entry_order` varchar(16) collate utf8mb4_unicode_ci not null default 'newest' after `hide_duplicates`;
@@ -0,0 +1,12 @@
+create table `last_seen` (
+    `id` int(11) not null auto_increment,
+    `device` varchar(36) collate utf8mb4_unicode_ci not null,
+    `feed_id` int(11) default null,
+    `saved_search_id` int(11) default null,
+    `seen_at` datetime not null,
+    primary key (`id`),
+    unique key `last_seen_device_feed_id` (`device`, `feed_id`),
+    unique key `last_seen_device_saved_search_id` (`device`, `saved_search_id`),
+    constraint `last_seen_ibfk_1` foreign key (`feed_id`) references `feed` (`id`),
+    constraint `last_seen_ibfk_2` foreign key (`saved_search_id`) references `saved_search` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
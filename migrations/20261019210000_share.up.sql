@@ -0,0 +1,13 @@
+create table `share` (
+    `id` int(11) not null auto_increment,
+    `entry_id` int(11) not null,
+    `token` varchar(64) collate utf8mb4_unicode_ci not null,
+    `expires_at` datetime default null,
+    `created_at` datetime not null,
+    `deleted_at` datetime default null,
+    primary key (`id`),
+    unique key `share__token` (`token`),
+    key `share__entry_id` (`entry_id`),
+    key `share__deleted_at` (`deleted_at`),
+    constraint `share_ibfk_1` foreign key (`entry_id`) references `entry` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
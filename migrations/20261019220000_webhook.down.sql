@@ -0,0 +1,2 @@
+drop table if exists `webhook_delivery`;
+drop table if exists `webhook`;
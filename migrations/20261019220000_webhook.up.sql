@@ -0,0 +1,39 @@
+create table `webhook` (
+    `id` int(11) not null auto_increment,
+    `title` varchar(255) collate utf8mb4_unicode_ci not null,
+    `url` varchar(2048) collate utf8mb4_unicode_ci not null,
+    `feed_id` int(11) default null,
+    `category_id` int(11) default null,
+    `rule_id` int(11) default null,
+    `payload_template` text collate utf8mb4_unicode_ci default null,
+    `secret` varchar(255) collate utf8mb4_unicode_ci not null,
+    `created_at` datetime not null,
+    `updated_at` datetime default null,
+    `deleted_at` datetime default null,
+    primary key (`id`),
+    key `webhook__deleted_at` (`deleted_at`),
+    constraint `webhook_ibfk_1` foreign key (`feed_id`) references `feed` (`id`),
+    constraint `webhook_ibfk_2` foreign key (`category_id`) references `category` (`id`),
+    constraint `webhook_ibfk_3` foreign key (`rule_id`) references `rule` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
+
+create table `webhook_delivery` (
+    `id` int(11) not null auto_increment,
+    `webhook_id` int(11) not null,
+    `entry_id` int(11) not null,
+    `event` varchar(32) collate utf8mb4_unicode_ci not null,
+    `payload` mediumtext collate utf8mb4_unicode_ci not null,
+    `status` varchar(16) collate utf8mb4_unicode_ci not null,
+    `attempts` int(11) not null default 0,
+    `next_attempt_at` datetime default null,
+    `status_code` int(11) default null,
+    `error` text collate utf8mb4_unicode_ci default null,
+    `created_at` datetime not null,
+    `attempted_at` datetime default null,
+    `delivered_at` datetime default null,
+    primary key (`id`),
+    key `webhook_delivery__webhook_id` (`webhook_id`, `created_at`),
+    key `webhook_delivery__status` (`status`, `next_attempt_at`),
+    constraint `webhook_delivery_ibfk_1` foreign key (`webhook_id`) references `webhook` (`id`),
+    constraint `webhook_delivery_ibfk_2` foreign key (`entry_id`) references `entry` (`id`)
+) engine=InnoDB default charset=utf8mb4 collate=utf8mb4_unicode_ci;
@@ -0,0 +1,110 @@
+// Package readlater saves entries to external read-later services.
+package readlater
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+var (
+	ErrInvalidSettings = errors.New("invalid read-later settings")
+	ErrNotConfigured   = errors.New("read-later service isn't configured")
+	ErrSendFailed      = errors.New("cannot save entry in read-later service")
+)
+
+// Saver saves entries to a read-later service.
+type Saver interface {
+	Save(ctx context.Context, entry repository.Entry) error
+}
+
+type Service struct {
+	logger             *log.Logger
+	settingsRepository settingsRepository
+	httpClient         *http.Client
+}
+
+func New(logger *log.Logger, settingsRepository settingsRepository, httpClient *http.Client) Service {
+	return Service{
+		logger:             logger,
+		settingsRepository: settingsRepository,
+		httpClient:         httpClient,
+	}
+}
+
+// saver returns implementation of the configured service.
+func (s Service) saver(settings Settings) (Saver, error) {
+	switch settings.Service {
+	case ServiceWallabag:
+		return NewWallabag(s.httpClient, settings.WallabagURL, settings.WallabagClientID, settings.WallabagClientSecret,
+			settings.WallabagUsername, settings.WallabagPassword), nil
+	default:
+		return nil, ErrNotConfigured
+	}
+}
+
+// Settings returns read-later settings, without secrets.
+func (s Service) Settings(ctx context.Context) (Settings, error) {
+	settings, err := loadSettings(ctx, s.settingsRepository)
+	if err != nil {
+		return Settings{}, err
+	}
+	settings.WallabagClientSecret, settings.WallabagPassword = "", ""
+	return settings, nil
+}
+
+// SaveSettings validates and saves settings, empty secrets keep the current ones.
+func (s Service) SaveSettings(ctx context.Context, settings Settings) error {
+	current, err := loadSettings(ctx, s.settingsRepository)
+	if err != nil {
+		return err
+	}
+	if settings.WallabagClientSecret == "" {
+		settings.WallabagClientSecret = current.WallabagClientSecret
+	}
+	if settings.WallabagPassword == "" {
+		settings.WallabagPassword = current.WallabagPassword
+	}
+	if err := settings.validate(); err != nil {
+		return err
+	}
+	if err := s.settingsRepository.SetAll(ctx, settings.values()); err != nil {
+		return fmt.Errorf("cannot save read-later settings: %w", err)
+	}
+	return nil
+}
+
+// Send saves entry in the configured service.
+func (s Service) Send(ctx context.Context, entry repository.Entry) error {
+	settings, err := loadSettings(ctx, s.settingsRepository)
+	if err != nil {
+		return err
+	}
+	saver, err := s.saver(settings)
+	if err != nil {
+		return err
+	}
+	return saver.Save(ctx, entry)
+}
+
+// AutoSendStarred reports whether starred entries should be sent to the configured service, only that
+// setting is read, as it's checked whenever entry is starred.
+func (s Service) AutoSendStarred(ctx context.Context) (bool, error) {
+	value, err := s.settingsRepository.Get(ctx, autoSendStarredKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("cannot fetch read-later settings: %w", err)
+	}
+	autoSend, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("cannot parse value of %s: %w", autoSendStarredKey, err)
+	}
+	return autoSend, nil
+}
@@ -0,0 +1,274 @@
+package readlater
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type settingsRepositoryMock struct {
+	values map[string]string
+}
+
+func (m *settingsRepositoryMock) Get(ctx context.Context, key string) (string, error) {
+	value, ok := m.values[key]
+	if !ok {
+		return "", fmt.Errorf("cannot fetch value of key %s: %w", key, sql.ErrNoRows)
+	}
+	return value, nil
+}
+
+func (m *settingsRepositoryMock) SetAll(ctx context.Context, values map[string]string) error {
+	for key, value := range values {
+		m.values[key] = value
+	}
+	return nil
+}
+
+// wallabagServer is a local Wallabag stand-in, it issues token for the expected credentials and keeps
+// saved entries.
+type wallabagServer struct {
+	*httptest.Server
+	entriesStatus int
+	saved         []map[string]string
+}
+
+func newWallabagServer(t *testing.T) *wallabagServer {
+	server := &wallabagServer{entriesStatus: http.StatusOK}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/v2/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Expected form to be parsed, but got '%v'", err)
+		}
+		expected := map[string]string{
+			"grant_type":    "password",
+			"client_id":     "client",
+			"client_secret": "client-secret",
+			"username":      "user",
+			"password":      "password",
+		}
+		for key, value := range expected {
+			if r.PostForm.Get(key) != value {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "invalid_grant"}`))
+				return
+			}
+		}
+		w.Write([]byte(`{"access_token": "token", "token_type": "bearer"}`))
+	})
+	mux.HandleFunc("/api/entries.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected method to be '%s', but got '%s'", http.MethodPost, r.Method)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		entry := map[string]string{}
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			t.Errorf("Expected body to be decoded, but got '%v'", err)
+		}
+		if server.entriesStatus != http.StatusOK {
+			w.WriteHeader(server.entriesStatus)
+			return
+		}
+		server.saved = append(server.saved, entry)
+		w.Write([]byte(`{"id": 1}`))
+	})
+	server.Server = httptest.NewServer(mux)
+	return server
+}
+
+func (s *wallabagServer) settings() map[string]string {
+	return map[string]string{
+		serviceKey:              ServiceWallabag,
+		autoSendStarredKey:      "false",
+		wallabagURLKey:          s.URL + "/",
+		wallabagClientIDKey:     "client",
+		wallabagClientSecretKey: "client-secret",
+		wallabagUsernameKey:     "user",
+		wallabagPasswordKey:     "password",
+	}
+}
+
+func newService(server *wallabagServer, values map[string]string) Service {
+	return New(log.New(ioutil.Discard, "", 0), &settingsRepositoryMock{values: values}, server.Client())
+}
+
+func TestService_Send(t *testing.T) {
+	entry := repository.Entry{ID: 1, Title: "Entry", Link: "https://example.com/entry", UserTags: []string{"go", "later"}}
+	tests := []struct {
+		name          string
+		settings      map[string]string
+		entriesStatus int
+
+		expectedErr   error
+		expectedSaved []map[string]string
+	}{{
+		name:          "entry saved",
+		settings:      map[string]string{},
+		entriesStatus: http.StatusOK,
+		expectedSaved: []map[string]string{
+			{"url": "https://example.com/entry", "title": "Entry", "tags": "go,later"},
+		},
+	}, {
+		name:          "invalid credentials",
+		settings:      map[string]string{wallabagPasswordKey: "wrong"},
+		entriesStatus: http.StatusOK,
+		expectedErr:   ErrSendFailed,
+	}, {
+		name:          "api failure",
+		settings:      map[string]string{},
+		entriesStatus: http.StatusInternalServerError,
+		expectedErr:   ErrSendFailed,
+	}, {
+		name:          "service not configured",
+		settings:      map[string]string{serviceKey: ""},
+		entriesStatus: http.StatusOK,
+		expectedErr:   ErrNotConfigured,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWallabagServer(t)
+			defer server.Close()
+			server.entriesStatus = tt.entriesStatus
+			values := server.settings()
+			for key, value := range tt.settings {
+				values[key] = value
+			}
+			err := newService(server, values).Send(context.Background(), entry)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(server.saved, tt.expectedSaved) {
+				t.Errorf("Expected saved entries to be '%v', but got '%v'", tt.expectedSaved, server.saved)
+			}
+		})
+	}
+}
+
+func TestService_AutoSendStarred(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+
+		expected bool
+	}{{
+		name:     "sending enabled",
+		settings: map[string]string{serviceKey: ServiceWallabag, autoSendStarredKey: "true"},
+		expected: true,
+	}, {
+		name:     "sending disabled",
+		settings: map[string]string{serviceKey: ServiceWallabag, autoSendStarredKey: "false"},
+		expected: false,
+	}, {
+		name:     "not configured",
+		settings: map[string]string{},
+		expected: false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(log.New(ioutil.Discard, "", 0), &settingsRepositoryMock{values: tt.settings}, http.DefaultClient)
+			autoSend, err := s.AutoSendStarred(context.Background())
+			if err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if autoSend != tt.expected {
+				t.Errorf("Expected auto send to be '%v', but got '%v'", tt.expected, autoSend)
+			}
+		})
+	}
+}
+
+func TestService_SaveSettings(t *testing.T) {
+	current := map[string]string{
+		serviceKey:              ServiceWallabag,
+		wallabagURLKey:          "https://wallabag.example.com",
+		wallabagClientIDKey:     "client",
+		wallabagClientSecretKey: "client-secret",
+		wallabagUsernameKey:     "user",
+		wallabagPasswordKey:     "password",
+	}
+	tests := []struct {
+		name     string
+		current  map[string]string
+		settings Settings
+
+		expectedErr    error
+		expectedSecret string
+	}{{
+		name:    "empty secrets keep the current ones",
+		current: current,
+		settings: Settings{Service: ServiceWallabag, AutoSendStarred: true, WallabagURL: "https://wallabag.example.com",
+			WallabagClientID: "client", WallabagUsername: "user"},
+		expectedSecret: "client-secret",
+	}, {
+		name:    "new secrets",
+		current: current,
+		settings: Settings{Service: ServiceWallabag, WallabagURL: "https://wallabag.example.com",
+			WallabagClientID: "client", WallabagClientSecret: "new-secret", WallabagUsername: "user",
+			WallabagPassword: "new-password"},
+		expectedSecret: "new-secret",
+	}, {
+		name:    "missing credentials",
+		current: map[string]string{},
+		settings: Settings{Service: ServiceWallabag, WallabagURL: "https://wallabag.example.com",
+			WallabagClientID: "client", WallabagUsername: "user"},
+		expectedErr: ErrInvalidSettings,
+	}, {
+		name:        "invalid url",
+		current:     current,
+		settings:    Settings{Service: ServiceWallabag, WallabagURL: "wallabag.example.com"},
+		expectedErr: ErrInvalidSettings,
+	}, {
+		name:        "unknown service",
+		current:     map[string]string{},
+		settings:    Settings{Service: "pocket"},
+		expectedErr: ErrInvalidSettings,
+	}, {
+		name:        "sending starred without service",
+		current:     map[string]string{},
+		settings:    Settings{AutoSendStarred: true},
+		expectedErr: ErrInvalidSettings,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]string{}
+			for key, value := range tt.current {
+				values[key] = value
+			}
+			repo := &settingsRepositoryMock{values: values}
+			s := New(log.New(ioutil.Discard, "", 0), repo, http.DefaultClient)
+			err := s.SaveSettings(context.Background(), tt.settings)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if secret := repo.values[wallabagClientSecretKey]; secret != tt.expectedSecret {
+				t.Errorf("Expected client secret to be '%s', but got '%s'", tt.expectedSecret, secret)
+			}
+			settings, err := s.Settings(context.Background())
+			if err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if settings.WallabagClientSecret != "" || settings.WallabagPassword != "" {
+				t.Errorf("Expected secrets to be hidden, but got '%v'", settings)
+			}
+			if settings.AutoSendStarred != tt.settings.AutoSendStarred {
+				t.Errorf("Expected auto send starred to be '%v', but got '%v'", tt.settings.AutoSendStarred, settings.AutoSendStarred)
+			}
+		})
+	}
+}
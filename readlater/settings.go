@@ -0,0 +1,105 @@
+package readlater
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	ServiceWallabag = "wallabag"
+
+	serviceKey              = "readlater_service"
+	autoSendStarredKey      = "readlater_auto_send_starred"
+	wallabagURLKey          = "readlater_wallabag_url"
+	wallabagClientIDKey     = "readlater_wallabag_client_id"
+	wallabagClientSecretKey = "readlater_wallabag_client_secret"
+	wallabagUsernameKey     = "readlater_wallabag_username"
+	wallabagPasswordKey     = "readlater_wallabag_password"
+)
+
+type settingsRepository interface {
+	Get(ctx context.Context, key string) (string, error)
+	SetAll(ctx context.Context, values map[string]string) error
+}
+
+// Settings of the read-later integration are kept in the settings table, empty Service disables it.
+// Wallabag's OAuth client uses password grant, so it's configured with both client credentials and
+// user's credentials.
+type Settings struct {
+	Service              string `json:"service"`
+	AutoSendStarred      bool   `json:"auto_send_starred"`
+	WallabagURL          string `json:"wallabag_url"`
+	WallabagClientID     string `json:"wallabag_client_id"`
+	WallabagClientSecret string `json:"-"`
+	WallabagUsername     string `json:"wallabag_username"`
+	WallabagPassword     string `json:"-"`
+}
+
+func (s Settings) validate() error {
+	switch s.Service {
+	case "":
+		if s.AutoSendStarred {
+			return fmt.Errorf("%w: service is required to send starred entries", ErrInvalidSettings)
+		}
+	case ServiceWallabag:
+		target, err := url.Parse(s.WallabagURL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return fmt.Errorf("%w: invalid Wallabag url", ErrInvalidSettings)
+		}
+		if s.WallabagClientID == "" || s.WallabagClientSecret == "" || s.WallabagUsername == "" || s.WallabagPassword == "" {
+			return fmt.Errorf("%w: client credentials, username and password are required", ErrInvalidSettings)
+		}
+	default:
+		return fmt.Errorf("%w: unknown service '%s'", ErrInvalidSettings, s.Service)
+	}
+	return nil
+}
+
+func (s Settings) values() map[string]string {
+	return map[string]string{
+		serviceKey:              s.Service,
+		autoSendStarredKey:      strconv.FormatBool(s.AutoSendStarred),
+		wallabagURLKey:          s.WallabagURL,
+		wallabagClientIDKey:     s.WallabagClientID,
+		wallabagClientSecretKey: s.WallabagClientSecret,
+		wallabagUsernameKey:     s.WallabagUsername,
+		wallabagPasswordKey:     s.WallabagPassword,
+	}
+}
+
+func loadSettings(ctx context.Context, repo settingsRepository) (Settings, error) {
+	settings := Settings{}
+	for _, key := range []string{serviceKey, autoSendStarredKey, wallabagURLKey, wallabagClientIDKey,
+		wallabagClientSecretKey, wallabagUsernameKey, wallabagPasswordKey} {
+		value, err := repo.Get(ctx, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return Settings{}, fmt.Errorf("cannot fetch read-later settings: %w", err)
+		}
+		switch key {
+		case serviceKey:
+			settings.Service = value
+		case autoSendStarredKey:
+			if settings.AutoSendStarred, err = strconv.ParseBool(value); err != nil {
+				return Settings{}, fmt.Errorf("cannot parse value of %s: %w", key, err)
+			}
+		case wallabagURLKey:
+			settings.WallabagURL = value
+		case wallabagClientIDKey:
+			settings.WallabagClientID = value
+		case wallabagClientSecretKey:
+			settings.WallabagClientSecret = value
+		case wallabagUsernameKey:
+			settings.WallabagUsername = value
+		case wallabagPasswordKey:
+			settings.WallabagPassword = value
+		}
+	}
+	return settings, nil
+}
@@ -0,0 +1,111 @@
+package readlater
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+// maxErrorBody is the number of bytes of failed response included in the error.
+const maxErrorBody = 512
+
+// Wallabag saves entries using Wallabag's API, with token obtained by OAuth password grant for every
+// save, as saving is rare enough not to keep tokens around.
+type Wallabag struct {
+	httpClient   *http.Client
+	baseURL      string
+	clientID     string
+	clientSecret string
+	username     string
+	password     string
+}
+
+func NewWallabag(httpClient *http.Client, baseURL, clientID, clientSecret, username, password string) Wallabag {
+	return Wallabag{
+		httpClient:   httpClient,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		username:     username,
+		password:     password,
+	}
+}
+
+// Save adds entry's link to Wallabag, with entry's title and user tags.
+func (w Wallabag) Save(ctx context.Context, entry repository.Entry) error {
+	token, err := w.token(ctx)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(map[string]string{
+		"url":   entry.Link,
+		"title": entry.Title,
+		"tags":  strings.Join(entry.UserTags, ","),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot serialize entry: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.baseURL+"/api/entries.json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrSendFailed, err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// token obtains access token using password grant.
+func (w Wallabag) token(ctx context.Context) (string, error) {
+	form := url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {w.clientID},
+		"client_secret": {w.clientSecret},
+		"username":      {w.username},
+		"password":      {w.password},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.baseURL+"/oauth/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: cannot authenticate: %s", ErrSendFailed, err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", fmt.Errorf("cannot authenticate: %w", err)
+	}
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("%w: invalid token response", ErrSendFailed)
+	}
+	return token.AccessToken, nil
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return fmt.Errorf("%w: unexpected response status %d: %s", ErrSendFailed, resp.StatusCode, body)
+}
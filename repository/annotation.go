@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	selectAnnotationsQuery = `select * from annotation where deleted_at is null order by created_at desc, id desc;`
+	// highlights are ordered as they appear in the text, notes follow them
+	selectAnnotationsForEntryQuery = `
+select *
+from annotation
+where deleted_at is null and entry_id = ?
+order by quote_offset is null, quote_offset asc, id asc;`
+	getAnnotationQuery    = `select * from annotation where deleted_at is null and entry_id = ? and id = ?;`
+	createAnnotationQuery = `insert into annotation (entry_id, kind, quote, quote_offset, note, created_at)
+values (:entry_id, :kind, :quote, :quote_offset, :note, :created_at);`
+	updateAnnotationQuery = `
+update annotation
+set kind = :kind, quote = :quote, quote_offset = :quote_offset, note = :note, updated_at = :updated_at, deleted_at = :deleted_at
+where id = :id and deleted_at is null;`
+)
+
+type annotationRepository struct {
+	db *sqlx.DB
+}
+
+func NewAnnotationRepository(db *sqlx.DB) *annotationRepository {
+	return &annotationRepository{
+		db: db,
+	}
+}
+
+// List returns annotations of all entries, most recent first.
+func (r *annotationRepository) List(ctx context.Context) ([]Annotation, error) {
+	annotations := []Annotation{}
+	if err := r.db.SelectContext(ctx, &annotations, selectAnnotationsQuery); err != nil {
+		return nil, fmt.Errorf("cannot select annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+func (r *annotationRepository) ListForEntry(ctx context.Context, entryID int64) ([]Annotation, error) {
+	annotations := []Annotation{}
+	if err := r.db.SelectContext(ctx, &annotations, selectAnnotationsForEntryQuery, entryID); err != nil {
+		return nil, fmt.Errorf("cannot select annotations for entry: %w", err)
+	}
+	return annotations, nil
+}
+
+func (r *annotationRepository) Get(ctx context.Context, entryID, id int64) (Annotation, error) {
+	annotation := Annotation{}
+	if err := r.db.GetContext(ctx, &annotation, getAnnotationQuery, entryID, id); err != nil {
+		return Annotation{}, fmt.Errorf("cannot fetch annotation (id=%d): %w", id, err)
+	}
+	return annotation, nil
+}
+
+func (r *annotationRepository) Create(ctx context.Context, annotation Annotation) (int64, error) {
+	res, err := r.db.NamedExecContext(ctx, createAnnotationQuery, annotation)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create annotation: %w", err)
+	}
+	lastInsertedID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	return lastInsertedID, nil
+}
+
+func (r *annotationRepository) Update(ctx context.Context, annotation Annotation) error {
+	if _, err := r.db.NamedExecContext(ctx, updateAnnotationQuery, annotation); err != nil {
+		return fmt.Errorf("cannot update annotation: %w", err)
+	}
+	return nil
+}
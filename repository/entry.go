@@ -2,33 +2,100 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 var (
-	selectEntriesForFeedQuery = `
-SELECT *
-FROM entry e
-where e.deleted_at is null and e.feed_id = ?
-ORDER BY e.published_at DESC
-LIMIT ? OFFSET ?;`
-	selectEntriesForPhraseQuery = `
-select *
-from entry e
-where e.deleted_at is null and e.title like ? and e.summary like ?
-order by e.published_at desc
-limit ? offset ?;`
+	// listings are ordered by time column, conditions are built by the listing
+	selectEntriesPageQuery = `
+select e.*
+from %s
+where %s
+order by %s
+limit ?;`
+	countEntriesQuery    = `select count(*) from %s where %s;`
+	entriesWithFeedTable = `entry e join feed f on f.id = e.feed_id`
+	entriesTable         = `entry e`
+	// already starred entries keep the time they were starred at
+	starEntryQuery     = `update entry set starred_at = ? where id = ? and deleted_at is null and starred_at is null;`
+	unstarEntryQuery   = `update entry set starred_at = null where id = ? and deleted_at is null and starred_at is not null;`
+	selectEntryIDQuery = `select id from entry where id = ? and deleted_at is null;`
 	getEntryQuery      = `SELECT * FROM entry e where e.deleted_at is null and id = ?;`
-	getEntryByURLQuery = `SELECT * FROM entry e where e.deleted_at is null and link = ? and feed_id = ?;`
+	// deleted entries are included, as their annotations are still listed
+	selectEntriesByIDsQuery = `select * from entry where id in (?);`
+	// deleted entries are included, so entries hidden by rules won't be created again
+	getEntryByURLQuery = `SELECT * FROM entry e where link = ? and feed_id = ?;`
 	updateEntryQuery   = `
 update entry 
-set title = :title, author = :author, summary = :summary, link = :link, published_at = :published_at, 
-feed_id = :feed_id, read_at = :read_at, created_at = :created_at, updated_at = :updated_at, deleted_at = :deleted_at 
+set title = :title, author = :author, summary = :summary, link = :link, normalized_link = :normalized_link, tags = :tags, enclosure = :enclosure, 
+published_at = :published_at, feed_id = :feed_id, canonical_id = :canonical_id, read_at = :read_at, read_batch = :read_batch, starred_at = :starred_at, 
+content_changed_at = :content_changed_at, created_at = :created_at, updated_at = :updated_at, deleted_at = :deleted_at 
 where id = :id and deleted_at is null;`
-	createEntryQuery = `insert into entry(title, author, summary, link, published_at, feed_id, read_at, created_at)
-values (:title, :author, :summary, :link, :published_at, :feed_id, :read_at, :created_at);`
+	countUnreadQuery = `
+select count(*)
+from entry e
+join feed f on f.id = e.feed_id
+where e.read_at is null and e.deleted_at is null and f.deleted_at is null;`
+	selectLatestEntriesQuery = `
+select e.*
+from entry e
+join feed f on f.id = e.feed_id
+where e.deleted_at is null and f.deleted_at is null and (? = 0 or e.feed_id = ?) and (? = 0 or f.category_id = ?)
+order by e.created_at desc, e.id desc
+limit ?;`
+	// duplicates are left out, like in listings of feeds hiding them
+	selectEntriesCreatedSinceQuery = `
+select e.*
+from entry e
+join feed f on f.id = e.feed_id
+where e.deleted_at is null and f.deleted_at is null and (not f.hide_duplicates or e.canonical_id is null)
+	and e.created_at > ? and (? = 0 or e.read_at is null)
+order by e.published_at desc, e.id desc
+limit ?;`
+	createEntryQuery = `insert into entry(title, author, summary, link, normalized_link, tags, enclosure, published_at, feed_id, canonical_id, read_at, starred_at, created_at, deleted_at)
+values (:title, :author, :summary, :link, :normalized_link, :tags, :enclosure, :published_at, :feed_id, :canonical_id, :read_at, :starred_at, :created_at, :deleted_at);`
+	getEntryByNormalizedLinkQuery = `
+select e.*
+from entry e
+join feed f on f.id = e.feed_id
+where e.normalized_link = ? and e.feed_id != ? and e.deleted_at is null and f.deleted_at is null
+order by e.id asc
+limit 1;`
+	// only columns needed to compare entries are selected, as many candidates are fetched on every update
+	selectDuplicateCandidatesQuery = `
+select e.id, e.title, e.normalized_link, e.canonical_id, e.read_at, e.created_at
+from entry e
+join feed f on f.id = e.feed_id
+where e.feed_id != ? and e.created_at >= ? and e.deleted_at is null and f.deleted_at is null
+order by e.id desc
+limit ?;`
+	selectWithoutNormalizedLinkQuery = `select * from entry where normalized_link = '' and id > ? order by id asc limit ?;`
+	updateNormalizedLinkQuery        = `update entry set normalized_link = ? where id = ?;`
+	// entries are marked along with all entries from their duplicate groups
+	markEntriesReadQuery = `
+update entry e
+join (select distinct coalesce(canonical_id, id) id from entry where id in (?)) g on e.id = g.id or e.canonical_id = g.id
+set e.read_at = ?
+where e.read_at is null;`
+	markEntriesUnreadQuery = `update entry set read_at = null, read_batch = null where id in (?);`
+	// like single entries, entries are marked along with all entries from their duplicate groups
+	markAllReadQuery = `
+update entry e
+join (
+	select distinct coalesce(s.canonical_id, s.id) id
+	from entry s
+	join feed f on f.id = s.feed_id
+	where s.read_at is null and s.deleted_at is null and f.deleted_at is null and s.created_at <= ?
+		and (? = 0 or s.feed_id = ?) and (? = 0 or f.category_id = ?)
+) g on e.id = g.id or e.canonical_id = g.id
+set e.read_at = ?, e.read_batch = ?
+where e.read_at is null and e.deleted_at is null;`
+	undoMarkAllReadQuery = `update entry set read_at = null, read_batch = null where read_batch = ? and read_at >= ?;`
 )
 
 type entryRepository struct {
@@ -57,33 +124,305 @@ func (r *entryRepository) GetByURL(ctx context.Context, url string, feedID int64
 	return entry, nil
 }
 
-func (r *entryRepository) ListForFeed(ctx context.Context, feedID, page int64, perPage int) ([]Entry, error) {
+// ListByIDs returns entries with given ids, including deleted ones.
+func (r *entryRepository) ListByIDs(ctx context.Context, ids []int64) ([]Entry, error) {
+	if len(ids) == 0 {
+		return []Entry{}, nil
+	}
+	query, args, err := sqlx.In(selectEntriesByIDsQuery, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing query 'in' values: %w", err)
+	}
 	entries := []Entry{}
-	if err := r.db.SelectContext(ctx, &entries, selectEntriesForFeedQuery, feedID, perPage, perPage*int(page-1)); err != nil {
+	if err := r.db.SelectContext(ctx, &entries, r.db.Rebind(query), args...); err != nil {
 		return nil, fmt.Errorf("cannot select entries: %w", err)
 	}
 	return entries, nil
 }
 
-func (r *entryRepository) ListForPhrase(ctx context.Context, phrase string, page int64, perPage int) ([]Entry, error) {
-	phrase = "%" + phrase + "%"
+const (
+	EntryOrderNewest = "newest"
+	EntryOrderOldest = "oldest"
+)
+
+// EntryFilter limits listed entries, zero value matches entries from all feeds. Entries of saved search
+// are searched by its query, not by the filter. Entries are published in [PublishedAfter, PublishedBefore)
+// range, zero time leaves the range open. Empty Order lists newest entries first.
+type EntryFilter struct {
+	FeedID          int64
+	CategoryID      int64
+	SavedSearchID   int64
+	UserTag         string
+	Unread          bool
+	Starred         bool
+	PublishedAfter  time.Time
+	PublishedBefore time.Time
+	Order           string
+}
+
+func (f EntryFilter) where() (string, []interface{}) {
+	conditions := []string{
+		"e.deleted_at is null",
+		"f.deleted_at is null",
+		"(not f.hide_duplicates or e.canonical_id is null)",
+	}
+	var args []interface{}
+	if f.FeedID != 0 {
+		conditions = append(conditions, "e.feed_id = ?")
+		args = append(args, f.FeedID)
+	}
+	if f.CategoryID != 0 {
+		conditions = append(conditions, "f.category_id = ?")
+		args = append(args, f.CategoryID)
+	}
+	if f.UserTag != "" {
+		conditions = append(conditions, `exists (
+select 1 from entry_user_tag eut join user_tag t on t.id = eut.user_tag_id where eut.entry_id = e.id and t.name = ?)`)
+		args = append(args, f.UserTag)
+	}
+	if f.Unread {
+		conditions = append(conditions, "e.read_at is null")
+	}
+	if f.Starred {
+		conditions = append(conditions, "e.starred_at is not null")
+	}
+	if !f.PublishedAfter.IsZero() {
+		conditions = append(conditions, "e.published_at >= ?")
+		args = append(args, f.PublishedAfter)
+	}
+	if !f.PublishedBefore.IsZero() {
+		conditions = append(conditions, "e.published_at < ?")
+		args = append(args, f.PublishedBefore)
+	}
+	return strings.Join(conditions, " and "), args
+}
+
+// List returns page of entries matching filter, in filter's order.
+func (r *entryRepository) List(ctx context.Context, filter EntryFilter, page PageRequest) (EntryPage, error) {
+	where, args := filter.where()
+	key := publishedAtKey
+	if filter.Order == EntryOrderOldest {
+		key = key.reversed()
+	}
+	return r.listPage(ctx, entriesWithFeedTable, where, args, key, page)
+}
+
+// ListStarred returns page of starred entries, most recently starred first. Starred entries are listed
+// regardless of their feed being deleted.
+func (r *entryRepository) ListStarred(ctx context.Context, page PageRequest) (EntryPage, error) {
+	where := "e.starred_at is not null and e.deleted_at is null"
+	return r.listPage(ctx, entriesTable, where, nil, starredAtKey, page)
+}
+
+// listPage selects page of entries ordered by key's column and id, descending unless key is ascending.
+// Args are params of from and where, in that order.
+func (r *entryRepository) listPage(ctx context.Context, from, where string, args []interface{}, key pageKey,
+	page PageRequest) (EntryPage, error) {
+	var total *int64
+	if page.WithTotal {
+		count := int64(0)
+		if err := r.db.GetContext(ctx, &count, fmt.Sprintf(countEntriesQuery, from, where), args...); err != nil {
+			return EntryPage{}, fmt.Errorf("cannot count entries: %w", err)
+		}
+		total = &count
+	}
+
+	// entries before the cursor are selected in reversed order, starting from the cursor
+	descending := key.ascending == page.Cursor.Before
+	direction, operator := "desc", "<"
+	if !descending {
+		direction, operator = "asc", ">"
+	}
+	order := fmt.Sprintf("%[1]s %[2]s, e.id %[2]s", key.column, direction)
+	if !page.Cursor.IsZero() {
+		where = fmt.Sprintf("%[1]s and (%[2]s %[3]s ? or (%[2]s = ? and e.id %[3]s ?))", where, key.column, operator)
+		value := key.value(page.Cursor)
+		args = append(args[:len(args):len(args)], value, value, page.Cursor.ID)
+	}
+	args = append(args[:len(args):len(args)], page.PerPage+1)
+
+	selected := []Entry{}
+	if err := r.db.SelectContext(ctx, &selected, fmt.Sprintf(selectEntriesPageQuery, from, where, order), args...); err != nil {
+		return EntryPage{}, fmt.Errorf("cannot select entries: %w", err)
+	}
+	result := newEntryPage(selected, page, key)
+	result.Total = total
+	return result, nil
+}
+
+// Star stars entry, time of starring already starred entry isn't changed.
+// Star stars the entry and reports whether it wasn't starred before, sql.ErrNoRows is returned when the
+// entry doesn't exist.
+func (r *entryRepository) Star(ctx context.Context, id int64, starredAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, starEntryQuery, starredAt, id)
+	if err != nil {
+		return false, fmt.Errorf("cannot star entry: %w", err)
+	}
+	return r.checkChanged(ctx, res, id)
+}
+
+// Unstar unstars the entry, sql.ErrNoRows is returned when the entry doesn't exist.
+func (r *entryRepository) Unstar(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, unstarEntryQuery, id)
+	if err != nil {
+		return fmt.Errorf("cannot unstar entry: %w", err)
+	}
+	_, err = r.checkChanged(ctx, res, id)
+	return err
+}
+
+// checkChanged reports whether the entry was changed, sql.ErrNoRows is returned when it wasn't changed
+// because it doesn't exist, rather than because it already was in the requested state.
+func (r *entryRepository) checkChanged(ctx context.Context, res sql.Result, id int64) (bool, error) {
+	changed, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("cannot fetch number of changed entries: %w", err)
+	}
+	if changed > 0 {
+		return true, nil
+	}
+	var entryID int64
+	if err := r.db.GetContext(ctx, &entryID, selectEntryIDQuery, id); err != nil {
+		return false, fmt.Errorf("cannot fetch entry (id=%d): %w", id, err)
+	}
+	return false, nil
+}
+
+// ListLatest returns most recently created entries, optionally limited to given feed or category.
+func (r *entryRepository) ListLatest(ctx context.Context, feedID, categoryID int64, limit int) ([]Entry, error) {
 	entries := []Entry{}
-	if err := r.db.SelectContext(ctx, &entries, selectEntriesForPhraseQuery, phrase, phrase, perPage, perPage*int(page-1)); err != nil {
-		return nil, fmt.Errorf("cannot select entries: %w", err)
+	if err := r.db.SelectContext(ctx, &entries, selectLatestEntriesQuery, feedID, feedID, categoryID, categoryID, limit); err != nil {
+		return nil, fmt.Errorf("cannot select latest entries: %w", err)
 	}
 	return entries, nil
 }
 
-func (r *entryRepository) Create(ctx context.Context, entry Entry) error {
-	if _, err := r.db.NamedExecContext(ctx, createEntryQuery, entry); err != nil {
-		return fmt.Errorf("cannot create entry: %w", err)
+// ListCreatedSince returns up to limit entries created after given time, newest first, only unread ones when
+// unread is set.
+func (r *entryRepository) ListCreatedSince(ctx context.Context, since time.Time, unread bool, limit int) ([]Entry, error) {
+	entries := []Entry{}
+	if err := r.db.SelectContext(ctx, &entries, selectEntriesCreatedSinceQuery, since, unread, limit); err != nil {
+		return nil, fmt.Errorf("cannot select entries created since %s: %w", since, err)
+	}
+	return entries, nil
+}
+
+// GetByNormalizedLink returns the first entry with given normalized link from feed other than given one.
+func (r *entryRepository) GetByNormalizedLink(ctx context.Context, link string, feedID int64) (Entry, error) {
+	entry := Entry{}
+	if err := r.db.GetContext(ctx, &entry, getEntryByNormalizedLinkQuery, link, feedID); err != nil {
+		return Entry{}, fmt.Errorf("cannot fetch entry (normalized link=%s): %w", link, err)
+	}
+	return entry, nil
+}
+
+// ListDuplicateCandidates returns entries from feeds other than given one, created since given time,
+// newest first.
+func (r *entryRepository) ListDuplicateCandidates(ctx context.Context, feedID int64, since time.Time, limit int) ([]Entry, error) {
+	entries := []Entry{}
+	if err := r.db.SelectContext(ctx, &entries, selectDuplicateCandidatesQuery, feedID, since, limit); err != nil {
+		return nil, fmt.Errorf("cannot select duplicate candidates: %w", err)
+	}
+	return entries, nil
+}
+
+// ListWithoutNormalizedLink returns entries with id greater than given one, that don't have normalized link.
+func (r *entryRepository) ListWithoutNormalizedLink(ctx context.Context, afterID int64, limit int) ([]Entry, error) {
+	entries := []Entry{}
+	if err := r.db.SelectContext(ctx, &entries, selectWithoutNormalizedLinkQuery, afterID, limit); err != nil {
+		return nil, fmt.Errorf("cannot select entries without normalized link: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *entryRepository) UpdateNormalizedLink(ctx context.Context, id int64, link string) error {
+	if _, err := r.db.ExecContext(ctx, updateNormalizedLinkQuery, link, id); err != nil {
+		return fmt.Errorf("cannot update normalized link: %w", err)
 	}
 	return nil
 }
 
+// MarkRead marks entries with given ids as read, together with their duplicates from other feeds.
+func (r *entryRepository) MarkRead(ctx context.Context, ids []int64, readAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(markEntriesReadQuery, ids, readAt)
+	if err != nil {
+		return fmt.Errorf("error preparing query 'in' values: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("cannot mark entries as read: %w", err)
+	}
+	return nil
+}
+
+func (r *entryRepository) MarkUnread(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(markEntriesUnreadQuery, ids)
+	if err != nil {
+		return fmt.Errorf("error preparing query 'in' values: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("cannot mark entries as unread: %w", err)
+	}
+	return nil
+}
+
+// MarkAllRead marks as read, as a batch with given id, entries created before given time, optionally
+// limited to given feed or category, along with their duplicates from other feeds. Returns number of marked
+// entries.
+func (r *entryRepository) MarkAllRead(ctx context.Context, batch string, readAt, before time.Time, feedID, categoryID int64) (int64, error) {
+	res, err := r.db.ExecContext(ctx, markAllReadQuery, before, feedID, feedID, categoryID, categoryID, readAt, batch)
+	if err != nil {
+		return 0, fmt.Errorf("cannot mark all entries as read: %w", err)
+	}
+	marked, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch number of marked entries: %w", err)
+	}
+	return marked, nil
+}
+
+// UndoMarkAllRead marks as unread entries from given batch, if they were marked as read after given time.
+// Returns number of restored entries.
+func (r *entryRepository) UndoMarkAllRead(ctx context.Context, batch string, after time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, undoMarkAllReadQuery, batch, after)
+	if err != nil {
+		return 0, fmt.Errorf("cannot undo marking entries as read: %w", err)
+	}
+	restored, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch number of restored entries: %w", err)
+	}
+	return restored, nil
+}
+
+func (r *entryRepository) Create(ctx context.Context, entry Entry) (int64, error) {
+	res, err := r.db.NamedExecContext(ctx, createEntryQuery, entry)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create entry: %w", err)
+	}
+	lastInsertedID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	return lastInsertedID, nil
+}
+
 func (r *entryRepository) Update(ctx context.Context, entry Entry) error {
 	if _, err := r.db.NamedExecContext(ctx, updateEntryQuery, entry); err != nil {
 		return fmt.Errorf("cannot update entry: %w", err)
 	}
 	return nil
 }
+
+func (r *entryRepository) CountUnread(ctx context.Context) (int64, error) {
+	var unread int64
+	if err := r.db.GetContext(ctx, &unread, countUnreadQuery); err != nil {
+		return 0, fmt.Errorf("cannot count unread entries: %w", err)
+	}
+	return unread, nil
+}
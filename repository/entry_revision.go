@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	createEntryRevisionQuery = `insert into entry_revision (entry_id, title, author, summary, created_at)
+values (:entry_id, :title, :author, :summary, :created_at);`
+	selectEntryRevisionsQuery = `select * from entry_revision where entry_id = ? order by created_at asc, id asc;`
+)
+
+type entryRevisionRepository struct {
+	db *sqlx.DB
+}
+
+func NewEntryRevisionRepository(db *sqlx.DB) *entryRevisionRepository {
+	return &entryRevisionRepository{
+		db: db,
+	}
+}
+
+func (r *entryRevisionRepository) Create(ctx context.Context, revision EntryRevision) error {
+	if _, err := r.db.NamedExecContext(ctx, createEntryRevisionQuery, revision); err != nil {
+		return fmt.Errorf("cannot create entry revision: %w", err)
+	}
+	return nil
+}
+
+// ListForEntry returns revisions of entry, oldest first.
+func (r *entryRevisionRepository) ListForEntry(ctx context.Context, entryID int64) ([]EntryRevision, error) {
+	revisions := []EntryRevision{}
+	if err := r.db.SelectContext(ctx, &revisions, selectEntryRevisionsQuery, entryID); err != nil {
+		return nil, fmt.Errorf("cannot select entry revisions: %w", err)
+	}
+	return revisions, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEntryFilter_where(t *testing.T) {
+	const baseConditions = "e.deleted_at is null and f.deleted_at is null and (not f.hide_duplicates or e.canonical_id is null)"
+	tests := []struct {
+		name   string
+		filter EntryFilter
+
+		expectedWhere string
+		expectedArgs  []interface{}
+	}{{
+		name:          "all entries",
+		filter:        EntryFilter{},
+		expectedWhere: baseConditions,
+	}, {
+		name:          "feed entries",
+		filter:        EntryFilter{FeedID: 12},
+		expectedWhere: baseConditions + " and e.feed_id = ?",
+		expectedArgs:  []interface{}{int64(12)},
+	}, {
+		name:          "unread category entries",
+		filter:        EntryFilter{CategoryID: 3, Unread: true},
+		expectedWhere: baseConditions + " and f.category_id = ? and e.read_at is null",
+		expectedArgs:  []interface{}{int64(3)},
+	}, {
+		name: "starred entries published in range",
+		filter: EntryFilter{
+			Starred:         true,
+			PublishedAfter:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			PublishedBefore: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		expectedWhere: baseConditions + " and e.starred_at is not null and e.published_at >= ? and e.published_at < ?",
+		expectedArgs: []interface{}{
+			time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := tt.filter.where()
+			if where != tt.expectedWhere {
+				t.Errorf("Expected where to be '%s', but got '%s'", tt.expectedWhere, where)
+			}
+			if !reflect.DeepEqual(args, tt.expectedArgs) {
+				t.Errorf("Expected args to be '%v', but got '%v'", tt.expectedArgs, args)
+			}
+		})
+	}
+}
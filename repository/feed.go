@@ -11,21 +11,24 @@ var (
 	selectFeedsForCategoriesQuery = `
 SELECT 
 	*,
-	(select count(*) from entry e where e.read_at is null and e.feed_id = f.id) un_read,
-	coalesce(f.last_read_at < (select max(e.published_at) from entry e where e.feed_id = f.id), false) new_entries
+	(select count(*) from entry e where e.read_at is null and e.deleted_at is null and e.feed_id = f.id
+		and (not f.hide_duplicates or e.canonical_id is null)) un_read,
+	(select max(e.created_at) from entry e where e.feed_id = f.id and e.deleted_at is null) latest_entry_at
 FROM feed f 
 where f.deleted_at is null and f.category_id in (?) 
 ORDER BY "f.order" ASC;`
 	selectFeedsQuery = `SELECT * FROM feed where deleted_at is null ORDER BY "order" ASC;`
 	getFeedQuery     = `select * from feed where id = ? and deleted_at is null;`
-	updateFeedQuery  = `
+	// deleted feeds are included, as their starred entries are still listed
+	selectFeedsByIDsQuery = `select * from feed where id in (?);`
+	updateFeedQuery       = `
 update feed 
 set feed_title = :feed_title, feed_url = :feed_url, feed_image = :feed_image, feed_subtitle = :feed_subtitle, site_url = :site_url, 
-site_favicon_url = :site_favicon_url, site_favicon = :site_favicon, category_id = :category_id, last_read_at = :last_read_at, 
+site_favicon_url = :site_favicon_url, site_favicon = :site_favicon, category_id = :category_id, hide_duplicates = :hide_duplicates, entry_order = :entry_order, last_read_at = :last_read_at, 
 created_at = :created_at, updated_at = :updated_at, deleted_at = :deleted_at
 where id = :id and deleted_at is null;`
-	createFeedQuery = `insert into feed (feed_title, feed_url, feed_image, feed_subtitle, site_url, site_favicon_url, site_favicon, category_id, last_read_at, created_at)
-values (:feed_title, :feed_url, :feed_image, :feed_subtitle, :site_url, :site_favicon_url, :site_favicon, :category_id, :last_read_at, :created_at);`
+	createFeedQuery = `insert into feed (feed_title, feed_url, feed_image, feed_subtitle, site_url, site_favicon_url, site_favicon, category_id, hide_duplicates, entry_order, last_read_at, created_at)
+values (:feed_title, :feed_url, :feed_image, :feed_subtitle, :site_url, :site_favicon_url, :site_favicon, :category_id, :hide_duplicates, :entry_order, :last_read_at, :created_at);`
 )
 
 type feedRepository struct {
@@ -62,6 +65,23 @@ func (r *feedRepository) ListForCategories(ctx context.Context, categoriesIDs []
 	return feeds, nil
 }
 
+// ListByIDs returns feeds with given ids, including deleted ones.
+func (r *feedRepository) ListByIDs(ctx context.Context, ids []int64) ([]Feed, error) {
+	if len(ids) == 0 {
+		return []Feed{}, nil
+	}
+
+	query, args, err := sqlx.In(selectFeedsByIDsQuery, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing query 'in' values: %w", err)
+	}
+	feeds := []Feed{}
+	if err = r.db.SelectContext(ctx, &feeds, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("cannot select feeds: %w", err)
+	}
+	return feeds, nil
+}
+
 func (r *feedRepository) List(ctx context.Context) ([]Feed, error) {
 	feeds := []Feed{}
 	if err := r.db.SelectContext(ctx, &feeds, selectFeedsQuery); err != nil {
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	createFetchLogQuery = `insert into fetch_log (feed_id, fetched_at, duration_ms, status_code, bytes, items, new_entries, updated_entries, error)
+values (:feed_id, :fetched_at, :duration_ms, :status_code, :bytes, :items, :new_entries, :updated_entries, :error);`
+	selectFetchLogsForFeedQuery = `select * from fetch_log where feed_id = ? order by id desc limit ?;`
+	// derived table is required, as mysql doesn't support limit in subqueries used with comparison
+	deleteOldFetchLogsQuery = `
+delete from fetch_log
+where feed_id = ? and id <= (
+	select id from (select id from fetch_log where feed_id = ? order by id desc limit 1 offset ?) last_kept
+);`
+)
+
+type fetchLogRepository struct {
+	db *sqlx.DB
+}
+
+func NewFetchLogRepository(db *sqlx.DB) *fetchLogRepository {
+	return &fetchLogRepository{
+		db: db,
+	}
+}
+
+func (r *fetchLogRepository) Create(ctx context.Context, fetchLog FetchLog) error {
+	if _, err := r.db.NamedExecContext(ctx, createFetchLogQuery, fetchLog); err != nil {
+		return fmt.Errorf("cannot create fetch log: %w", err)
+	}
+	return nil
+}
+
+func (r *fetchLogRepository) ListForFeed(ctx context.Context, feedID int64, limit int) ([]FetchLog, error) {
+	fetchLogs := []FetchLog{}
+	if err := r.db.SelectContext(ctx, &fetchLogs, selectFetchLogsForFeedQuery, feedID, limit); err != nil {
+		return nil, fmt.Errorf("cannot select fetch logs: %w", err)
+	}
+	return fetchLogs, nil
+}
+
+// DeleteOld removes all but the newest keep fetch logs of given feed.
+func (r *fetchLogRepository) DeleteOld(ctx context.Context, feedID int64, keep int) error {
+	if _, err := r.db.ExecContext(ctx, deleteOldFetchLogsQuery, feedID, feedID, keep); err != nil {
+		return fmt.Errorf("cannot delete old fetch logs: %w", err)
+	}
+	return nil
+}
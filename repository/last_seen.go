@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	selectLastSeenQuery       = `select * from last_seen where device = ?;`
+	selectLatestLastSeenQuery = `
+select feed_id, saved_search_id, max(seen_at) seen_at
+from last_seen
+group by feed_id, saved_search_id;`
+	// seen time is never moved back, so a stale tab can't bring back already seen entries as new
+	upsertFeedSeenQuery = `
+insert into last_seen (device, feed_id, seen_at) values (?, ?, ?)
+on duplicate key update seen_at = greatest(seen_at, values(seen_at));`
+	upsertSavedSearchSeenQuery = `
+insert into last_seen (device, saved_search_id, seen_at) values (?, ?, ?)
+on duplicate key update seen_at = greatest(seen_at, values(seen_at));`
+)
+
+type lastSeenRepository struct {
+	db *sqlx.DB
+}
+
+func NewLastSeenRepository(db *sqlx.DB) *lastSeenRepository {
+	return &lastSeenRepository{
+		db: db,
+	}
+}
+
+// List returns times feeds and saved searches were last seen on the device.
+func (r *lastSeenRepository) List(ctx context.Context, device string) ([]LastSeen, error) {
+	lastSeen := []LastSeen{}
+	if err := r.db.SelectContext(ctx, &lastSeen, selectLastSeenQuery, device); err != nil {
+		return nil, fmt.Errorf("cannot select last seen: %w", err)
+	}
+	return lastSeen, nil
+}
+
+// ListLatest returns times feeds and saved searches were last seen on any device.
+func (r *lastSeenRepository) ListLatest(ctx context.Context) ([]LastSeen, error) {
+	lastSeen := []LastSeen{}
+	if err := r.db.SelectContext(ctx, &lastSeen, selectLatestLastSeenQuery); err != nil {
+		return nil, fmt.Errorf("cannot select latest last seen: %w", err)
+	}
+	return lastSeen, nil
+}
+
+// SeeFeed records that feed was seen on the device. Only the device's own record is moved, other devices
+// keep their own ones.
+func (r *lastSeenRepository) SeeFeed(ctx context.Context, device string, feedID int64, seenAt time.Time) error {
+	if device == "" {
+		return fmt.Errorf("cannot save feed last seen: %w", ErrNoDevice)
+	}
+	if _, err := r.db.ExecContext(ctx, upsertFeedSeenQuery, device, feedID, seenAt); err != nil {
+		return fmt.Errorf("cannot save feed last seen: %w", err)
+	}
+	return nil
+}
+
+// SeeSavedSearch records that saved search was seen on the device, like SeeFeed.
+func (r *lastSeenRepository) SeeSavedSearch(ctx context.Context, device string, savedSearchID int64, seenAt time.Time) error {
+	if device == "" {
+		return fmt.Errorf("cannot save saved search last seen: %w", ErrNoDevice)
+	}
+	if _, err := r.db.ExecContext(ctx, upsertSavedSearchSeenQuery, device, savedSearchID, seenAt); err != nil {
+		return fmt.Errorf("cannot save saved search last seen: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -14,7 +15,8 @@ type Category struct {
 	UpdatedAt NullTime `db:"updated_at" json:"-"`
 	DeletedAt NullTime `db:"deleted_at" json:"-"`
 
-	Feeds []Feed `db:"-" json:"feeds"`
+	Feeds         []Feed        `db:"-" json:"feeds"`
+	SavedSearches []SavedSearch `db:"-" json:"saved_searches"`
 }
 
 type Feed struct {
@@ -27,30 +29,237 @@ type Feed struct {
 	SiteFaviconUrl NullString `db:"site_favicon_url" json:"site_favicon_url"`
 	SiteFavicon    NullString `db:"site_favicon" json:"site_favicon"`
 	CategoryID     int64      `db:"category_id" json:"category_id"`
+	HideDuplicates bool       `db:"hide_duplicates" json:"hide_duplicates"`
+	EntryOrder     string     `db:"entry_order" json:"entry_order"`
 	LastReadAt     Time       `db:"last_read_at" json:"-"`
 	CreatedAt      Time       `db:"created_at" json:"-"`
 	UpdatedAt      NullTime   `db:"updated_at" json:"-"`
 	DeletedAt      NullTime   `db:"deleted_at" json:"-"`
 
-	UnRead     int64 `db:"un_read" json:"un_read"`
-	NewEntries int64 `db:"new_entries" json:"new_entries"`
+	UnRead        int64    `db:"un_read" json:"un_read"`
+	LatestEntryAt NullTime `db:"latest_entry_at" json:"-"`
+	NewEntries    int64    `db:"-" json:"new_entries"`
 }
 
+// Entry with CanonicalID set is a duplicate of an entry from another feed. Updated is set when entry's
+// content changed after it was read. Score and Snippet are set only in search results.
 type Entry struct {
-	ID          int64      `db:"id" json:"id"`
-	Title       string     `db:"title" json:"title"`
-	Author      NullString `db:"author" json:"author"`
-	Summary     NullString `db:"summary" json:"summary"`
-	Link        string     `db:"link" json:"link"`
-	PublishedAt Time       `db:"published_at" json:"published_at"`
-	FeedID      int64      `db:"feed_id" json:"feed_id"`
-	ReadAt      NullTime   `db:"read_at" json:"read_at"`
-	CreatedAt   Time       `db:"created_at" json:"-"`
-	UpdatedAt   NullTime   `db:"updated_at" json:"-"`
-	DeletedAt   NullTime   `db:"deleted_at" json:"-"`
-
-	Feed     Feed `db:"-" json:"feed"`
-	NewEntry bool `db:"-" json:"new_entry"`
+	ID             int64      `db:"id" json:"id"`
+	Title          string     `db:"title" json:"title"`
+	Author         NullString `db:"author" json:"author"`
+	Summary        NullString `db:"summary" json:"summary"`
+	Link           string     `db:"link" json:"link"`
+	Tags           NullString `db:"tags" json:"tags"`
+	Enclosure      NullString `db:"enclosure" json:"enclosure"`
+	PublishedAt    Time       `db:"published_at" json:"published_at"`
+	FeedID         int64      `db:"feed_id" json:"feed_id"`
+	CanonicalID    NullInt64  `db:"canonical_id" json:"canonical_id"`
+	NormalizedLink string     `db:"normalized_link" json:"-"`
+	ReadAt         NullTime   `db:"read_at" json:"read_at"`
+	ReadBatch      NullString `db:"read_batch" json:"-"`
+	StarredAt      NullTime   `db:"starred_at" json:"starred_at"`
+	ChangedAt      NullTime   `db:"content_changed_at" json:"-"`
+	CreatedAt      Time       `db:"created_at" json:"-"`
+	UpdatedAt      NullTime   `db:"updated_at" json:"-"`
+	DeletedAt      NullTime   `db:"deleted_at" json:"-"`
+	Score          float64    `db:"score" json:"score,omitempty"`
+
+	Feed     Feed     `db:"-" json:"feed"`
+	NewEntry bool     `db:"-" json:"new_entry"`
+	Updated  bool     `db:"-" json:"updated"`
+	Snippet  string   `db:"-" json:"snippet,omitempty"`
+	UserTags []string `db:"-" json:"user_tags"`
+}
+
+// EntryRevision holds entry's content from before it was changed by the feed, CreatedAt is the time
+// it was replaced.
+type EntryRevision struct {
+	ID        int64      `db:"id" json:"id"`
+	EntryID   int64      `db:"entry_id" json:"entry_id"`
+	Title     string     `db:"title" json:"title"`
+	Author    NullString `db:"author" json:"author"`
+	Summary   NullString `db:"summary" json:"summary"`
+	CreatedAt Time       `db:"created_at" json:"created_at"`
+}
+
+// LastSeen is the time feed or saved search was last seen on the device, entries created after it are new.
+type LastSeen struct {
+	ID            int64     `db:"id" json:"id"`
+	Device        string    `db:"device" json:"-"`
+	FeedID        NullInt64 `db:"feed_id" json:"feed_id"`
+	SavedSearchID NullInt64 `db:"saved_search_id" json:"saved_search_id"`
+	SeenAt        Time      `db:"seen_at" json:"seen_at"`
+}
+
+// UserTag is a label given to entries by user or by rules, Entries is number of tagged entries.
+type UserTag struct {
+	ID        int64  `db:"id" json:"id"`
+	Name      string `db:"name" json:"name"`
+	CreatedAt Time   `db:"created_at" json:"-"`
+
+	Entries int64 `db:"entries" json:"entries"`
+}
+
+type EntryUserTag struct {
+	EntryID int64  `db:"entry_id"`
+	Name    string `db:"name"`
+}
+
+const (
+	RuleFieldTitle   = "title"
+	RuleFieldAuthor  = "author"
+	RuleFieldContent = "content"
+	RuleFieldLink    = "link"
+	RuleFieldTags    = "tags"
+
+	RuleMatchKeyword = "keyword"
+	RuleMatchRegex   = "regex"
+
+	RuleActionRead = "read"
+	RuleActionStar = "star"
+	RuleActionHide = "hide"
+	RuleActionTag  = "tag"
+)
+
+// Rule is applied to entries when they're saved for the first time. Rule without feed and category
+// is global, otherwise it's applied only to given feed or feeds in given category.
+type Rule struct {
+	ID         int64      `db:"id" json:"id"`
+	Title      string     `db:"title" json:"title"`
+	FeedID     NullInt64  `db:"feed_id" json:"feed_id"`
+	CategoryID NullInt64  `db:"category_id" json:"category_id"`
+	Field      string     `db:"field" json:"field"`
+	MatchType  string     `db:"match_type" json:"match_type"`
+	Pattern    string     `db:"pattern" json:"pattern"`
+	Action     string     `db:"action" json:"action"`
+	Tag        NullString `db:"tag" json:"tag"`
+	CreatedAt  Time       `db:"created_at" json:"-"`
+	UpdatedAt  NullTime   `db:"updated_at" json:"-"`
+	DeletedAt  NullTime   `db:"deleted_at" json:"-"`
+}
+
+// SavedSearch is a search query listed in the category like a feed, its entries created after it was last
+// seen are new. LastReadAt is used only when it wasn't seen on any device yet.
+type SavedSearch struct {
+	ID         int64    `db:"id" json:"id"`
+	Name       string   `db:"name" json:"name"`
+	Query      string   `db:"query" json:"query"`
+	Mode       string   `db:"mode" json:"mode"`
+	CategoryID int64    `db:"category_id" json:"category_id"`
+	LastReadAt Time     `db:"last_read_at" json:"-"`
+	CreatedAt  Time     `db:"created_at" json:"-"`
+	UpdatedAt  NullTime `db:"updated_at" json:"-"`
+	DeletedAt  NullTime `db:"deleted_at" json:"-"`
+
+	UnRead     int64 `db:"-" json:"un_read"`
+	NewEntries int64 `db:"-" json:"new_entries"`
+}
+
+// SearchStatsQuery is a search query whose stats are counted, entries published after Since are new.
+type SearchStatsQuery struct {
+	Query SearchQuery
+	Since time.Time
+}
+
+// SearchStats are number of unread entries matching search query and whether any of them is new.
+type SearchStats struct {
+	UnRead     int64 `db:"un_read"`
+	NewEntries int64 `db:"new_entries"`
+}
+
+const (
+	AnnotationKindHighlight = "highlight"
+	AnnotationKindNote      = "note"
+)
+
+// Annotation is a quote highlighted in entry's text, found at Offset of the text, or a note on the entry.
+// Highlight may have a note too. Entry is set only when annotations of many entries are listed.
+type Annotation struct {
+	ID        int64      `db:"id" json:"id"`
+	EntryID   int64      `db:"entry_id" json:"entry_id"`
+	Kind      string     `db:"kind" json:"kind"`
+	Quote     NullString `db:"quote" json:"quote"`
+	Offset    NullInt64  `db:"quote_offset" json:"offset"`
+	Note      NullString `db:"note" json:"note"`
+	CreatedAt Time       `db:"created_at" json:"created_at"`
+	UpdatedAt NullTime   `db:"updated_at" json:"updated_at"`
+	DeletedAt NullTime   `db:"deleted_at" json:"-"`
+
+	Entry *Entry `db:"-" json:"entry,omitempty"`
+}
+
+type Share struct {
+	ID        int64    `db:"id" json:"id"`
+	EntryID   int64    `db:"entry_id" json:"entry_id"`
+	Token     string   `db:"token" json:"token"`
+	ExpiresAt NullTime `db:"expires_at" json:"expires_at"`
+	CreatedAt Time     `db:"created_at" json:"created_at"`
+	DeletedAt NullTime `db:"deleted_at" json:"-"`
+
+	Entry *Entry `db:"-" json:"entry,omitempty"`
+}
+
+// Webhook is called with entries created in feeds matching its filters, all set filters have to match.
+// Payload is rendered from PayloadTemplate and signed with Secret.
+type Webhook struct {
+	ID              int64      `db:"id" json:"id"`
+	Title           string     `db:"title" json:"title"`
+	URL             string     `db:"url" json:"url"`
+	FeedID          NullInt64  `db:"feed_id" json:"feed_id"`
+	CategoryID      NullInt64  `db:"category_id" json:"category_id"`
+	RuleID          NullInt64  `db:"rule_id" json:"rule_id"`
+	PayloadTemplate NullString `db:"payload_template" json:"payload_template"`
+	Secret          string     `db:"secret" json:"-"`
+	CreatedAt       Time       `db:"created_at" json:"created_at"`
+	UpdatedAt       NullTime   `db:"updated_at" json:"updated_at"`
+	DeletedAt       NullTime   `db:"deleted_at" json:"-"`
+}
+
+const (
+	WebhookEventEntryCreated = "entry.created"
+
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is a queued call of webhook, pending deliveries are attempted at NextAttemptAt. Result
+// of the last attempt is kept in StatusCode and Error.
+type WebhookDelivery struct {
+	ID            int64      `db:"id" json:"id"`
+	WebhookID     int64      `db:"webhook_id" json:"webhook_id"`
+	EntryID       int64      `db:"entry_id" json:"entry_id"`
+	Event         string     `db:"event" json:"event"`
+	Payload       string     `db:"payload" json:"payload"`
+	Status        string     `db:"status" json:"status"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	NextAttemptAt NullTime   `db:"next_attempt_at" json:"next_attempt_at"`
+	StatusCode    NullInt64  `db:"status_code" json:"status_code"`
+	Error         NullString `db:"error" json:"error"`
+	CreatedAt     Time       `db:"created_at" json:"created_at"`
+	AttemptedAt   NullTime   `db:"attempted_at" json:"attempted_at"`
+	DeliveredAt   NullTime   `db:"delivered_at" json:"delivered_at"`
+}
+
+type FetchLog struct {
+	ID             int64      `db:"id" json:"id"`
+	FeedID         int64      `db:"feed_id" json:"feed_id"`
+	FetchedAt      Time       `db:"fetched_at" json:"fetched_at"`
+	DurationMs     int64      `db:"duration_ms" json:"duration_ms"`
+	StatusCode     int        `db:"status_code" json:"status_code"`
+	Bytes          int        `db:"bytes" json:"bytes"`
+	Items          int        `db:"items" json:"items"`
+	NewEntries     int        `db:"new_entries" json:"new_entries"`
+	UpdatedEntries int        `db:"updated_entries" json:"updated_entries"`
+	Error          NullString `db:"error" json:"error"`
+}
+
+// Finish sets duration of the fetch, and error it ended with if any.
+func (l *FetchLog) Finish(duration time.Duration, err error) {
+	l.DurationMs = duration.Milliseconds()
+	if err != nil {
+		l.Error = NewNullString(err.Error())
+	}
 }
 
 type User struct {
@@ -59,7 +268,11 @@ type User struct {
 }
 
 func (u User) Validate(user, pass string) bool {
-	return u.Name == user && bcrypt.CompareHashAndPassword(u.Password, []byte(pass)) == nil
+	return u.Name == user && u.ValidatePassword(pass)
+}
+
+func (u User) ValidatePassword(pass string) bool {
+	return bcrypt.CompareHashAndPassword(u.Password, []byte(pass)) == nil
 }
 
 func (u *User) SetPassword(pass string) error {
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to a position in entries listing, ordered newest first by time (or by relevance score
+// in search results) and id. Zero cursor points to the beginning of the listing.
+type Cursor struct {
+	Time  time.Time
+	Score float64
+	ID    int64
+	// Before selects entries preceding the position, otherwise entries following it are selected.
+	Before bool
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == 0
+}
+
+// Encode returns opaque representation of the cursor, that can be decoded by DecodeCursor.
+func (c Cursor) Encode() string {
+	direction := "a"
+	if c.Before {
+		direction = "b"
+	}
+	raw := fmt.Sprintf("%s:%d:%s:%d", direction, c.Time.UnixNano(), strconv.FormatFloat(c.Score, 'g', -1, 64), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || (parts[0] != "a" && parts[0] != "b") {
+		return Cursor{}, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	score, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	id, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || id < 1 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{Time: time.Unix(0, nanos).UTC(), Score: score, ID: id, Before: parts[0] == "b"}, nil
+}
+
+type PageRequest struct {
+	Cursor  Cursor
+	PerPage int
+	// WithTotal requests counting all entries in the listing.
+	WithTotal bool
+}
+
+// EntryPage is a page of entries with cursors to neighbouring pages, cursors are nil when there is
+// no such page. Total is set only when requested.
+type EntryPage struct {
+	Entries []Entry
+	Next    *Cursor
+	Prev    *Cursor
+	Total   *int64
+}
+
+// pageKey is the column entries listing is ordered by, together with entry id.
+type pageKey struct {
+	column    string
+	ascending bool
+	// position returns cursor pointing at the entry.
+	position func(Entry) Cursor
+	// value returns value of the column at cursor's position.
+	value func(Cursor) interface{}
+}
+
+var (
+	publishedAtKey = pageKey{
+		column:   "e.published_at",
+		position: func(entry Entry) Cursor { return Cursor{Time: entry.PublishedAt.Time, ID: entry.ID} },
+		value:    func(cursor Cursor) interface{} { return cursor.Time },
+	}
+	starredAtKey = pageKey{
+		column:   "e.starred_at",
+		position: func(entry Entry) Cursor { return Cursor{Time: entry.StarredAt.Time, ID: entry.ID} },
+		value:    func(cursor Cursor) interface{} { return cursor.Time },
+	}
+	scoreKey = pageKey{
+		column:   "e.score",
+		position: func(entry Entry) Cursor { return Cursor{Score: entry.Score, ID: entry.ID} },
+		value:    func(cursor Cursor) interface{} { return cursor.Score },
+	}
+)
+
+// reversed returns key ordering entries in opposite direction.
+func (k pageKey) reversed() pageKey {
+	k.ascending = !k.ascending
+	return k
+}
+
+// newEntryPage builds page from entries selected with one extra entry, which tells whether there is
+// more entries in the direction of the listing. Entries selected before the cursor are in reversed order.
+func newEntryPage(entries []Entry, page PageRequest, key pageKey) EntryPage {
+	hasMore := len(entries) > page.PerPage
+	if hasMore {
+		entries = entries[:page.PerPage]
+	}
+	if page.Cursor.Before {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+
+	result := EntryPage{Entries: entries}
+	if len(entries) == 0 {
+		return result
+	}
+	first, last := entries[0], entries[len(entries)-1]
+	hasNext, hasPrev := hasMore, !page.Cursor.IsZero()
+	if page.Cursor.Before {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		next := key.position(last)
+		result.Next = &next
+	}
+	if hasPrev {
+		prev := key.position(first)
+		prev.Before = true
+		result.Prev = &prev
+	}
+	return result
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursor_Encode(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{{
+		name:   "after cursor",
+		cursor: Cursor{Time: time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC), ID: 42},
+	}, {
+		name:   "before cursor",
+		cursor: Cursor{Time: time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC), ID: 1, Before: true},
+	}, {
+		name:   "score cursor",
+		cursor: Cursor{Time: time.Unix(0, 0).UTC(), Score: 0.1234567890123, ID: 7},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.cursor) {
+				t.Errorf("Expected cursor to be '%v', but got '%v'", tt.cursor, decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{{
+		name:    "not base64",
+		encoded: "!!!",
+	}, {
+		name:    "missing parts",
+		encoded: "YToxOjI",
+	}, {
+		name:    "invalid direction",
+		encoded: "YzoxOjI6Mw",
+	}, {
+		name:    "invalid score",
+		encoded: "YToxOng6Mw",
+	}, {
+		name:    "invalid id",
+		encoded: "YToxOjI6MA",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.encoded); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Expected error to be '%v', but got '%v'", ErrInvalidCursor, err)
+			}
+		})
+	}
+}
+
+func TestNewEntryPage(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2020, 1, 1, hour, 0, 0, 0, time.UTC)
+	}
+	entry := func(id int64, hour int) Entry {
+		return Entry{ID: id, PublishedAt: NewTime(at(hour))}
+	}
+	tests := []struct {
+		name    string
+		entries []Entry
+		page    PageRequest
+
+		expectedIDs  []int64
+		expectedNext *Cursor
+		expectedPrev *Cursor
+	}{{
+		name:        "empty first page",
+		entries:     []Entry{},
+		page:        PageRequest{PerPage: 2},
+		expectedIDs: []int64{},
+	}, {
+		name:        "single first page",
+		entries:     []Entry{entry(3, 3), entry(2, 2)},
+		page:        PageRequest{PerPage: 2},
+		expectedIDs: []int64{3, 2},
+	}, {
+		name:         "first page with more entries",
+		entries:      []Entry{entry(3, 3), entry(2, 2), entry(1, 1)},
+		page:         PageRequest{PerPage: 2},
+		expectedIDs:  []int64{3, 2},
+		expectedNext: &Cursor{Time: at(2), ID: 2},
+	}, {
+		name:         "last page after cursor",
+		entries:      []Entry{entry(1, 1)},
+		page:         PageRequest{PerPage: 2, Cursor: Cursor{Time: at(2), ID: 2}},
+		expectedIDs:  []int64{1},
+		expectedPrev: &Cursor{Time: at(1), ID: 1, Before: true},
+	}, {
+		name:         "page before cursor",
+		entries:      []Entry{entry(2, 2), entry(3, 3), entry(4, 4)},
+		page:         PageRequest{PerPage: 2, Cursor: Cursor{Time: at(1), ID: 1, Before: true}},
+		expectedIDs:  []int64{3, 2},
+		expectedNext: &Cursor{Time: at(2), ID: 2},
+		expectedPrev: &Cursor{Time: at(3), ID: 3, Before: true},
+	}, {
+		name:         "first page before cursor",
+		entries:      []Entry{entry(2, 2), entry(3, 3)},
+		page:         PageRequest{PerPage: 2, Cursor: Cursor{Time: at(1), ID: 1, Before: true}},
+		expectedIDs:  []int64{3, 2},
+		expectedNext: &Cursor{Time: at(2), ID: 2},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newEntryPage(tt.entries, tt.page, publishedAtKey)
+			ids := []int64{}
+			for _, entry := range page.Entries {
+				ids = append(ids, entry.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("Expected ids to be '%v', but got '%v'", tt.expectedIDs, ids)
+			}
+			if !reflect.DeepEqual(page.Next, tt.expectedNext) {
+				t.Errorf("Expected next to be '%v', but got '%v'", tt.expectedNext, page.Next)
+			}
+			if !reflect.DeepEqual(page.Prev, tt.expectedPrev) {
+				t.Errorf("Expected prev to be '%v', but got '%v'", tt.expectedPrev, page.Prev)
+			}
+		})
+	}
+}
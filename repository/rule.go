@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	selectRulesQuery = `select * from rule where deleted_at is null order by id asc;`
+	getRuleQuery     = `select * from rule where deleted_at is null and id = ?;`
+	// selects global rules, rules for the feed and for the category feed belongs to
+	selectRulesForFeedQuery = `
+select r.*
+from rule r
+where r.deleted_at is null and (
+	(r.feed_id is null and r.category_id is null)
+	or r.feed_id = ?
+	or r.category_id = (select f.category_id from feed f where f.id = ?)
+)
+order by r.id asc;`
+	createRuleQuery = `insert into rule (title, feed_id, category_id, field, match_type, pattern, action, tag, created_at)
+values (:title, :feed_id, :category_id, :field, :match_type, :pattern, :action, :tag, :created_at);`
+	updateRuleQuery = `
+update rule
+set title = :title, feed_id = :feed_id, category_id = :category_id, field = :field, match_type = :match_type,
+pattern = :pattern, action = :action, tag = :tag, updated_at = :updated_at, deleted_at = :deleted_at
+where id = :id and deleted_at is null;`
+)
+
+type ruleRepository struct {
+	db *sqlx.DB
+}
+
+func NewRuleRepository(db *sqlx.DB) *ruleRepository {
+	return &ruleRepository{
+		db: db,
+	}
+}
+
+func (r *ruleRepository) List(ctx context.Context) ([]Rule, error) {
+	rules := []Rule{}
+	if err := r.db.SelectContext(ctx, &rules, selectRulesQuery); err != nil {
+		return nil, fmt.Errorf("cannot select rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *ruleRepository) ListForFeed(ctx context.Context, feedID int64) ([]Rule, error) {
+	rules := []Rule{}
+	if err := r.db.SelectContext(ctx, &rules, selectRulesForFeedQuery, feedID, feedID); err != nil {
+		return nil, fmt.Errorf("cannot select rules for feed: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *ruleRepository) Get(ctx context.Context, id int64) (Rule, error) {
+	rule := Rule{}
+	if err := r.db.GetContext(ctx, &rule, getRuleQuery, id); err != nil {
+		return Rule{}, fmt.Errorf("cannot fetch rule (id=%d): %w", id, err)
+	}
+	return rule, nil
+}
+
+func (r *ruleRepository) Create(ctx context.Context, rule Rule) (int64, error) {
+	res, err := r.db.NamedExecContext(ctx, createRuleQuery, rule)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create rule: %w", err)
+	}
+	lastInsertedID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	return lastInsertedID, nil
+}
+
+func (r *ruleRepository) Update(ctx context.Context, rule Rule) error {
+	if _, err := r.db.NamedExecContext(ctx, updateRuleQuery, rule); err != nil {
+		return fmt.Errorf("cannot update rule: %w", err)
+	}
+	return nil
+}
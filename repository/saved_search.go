@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	selectSavedSearchesQuery              = `select * from saved_search where deleted_at is null order by id asc;`
+	selectSavedSearchesForCategoriesQuery = `select * from saved_search where deleted_at is null and category_id in (?) order by id asc;`
+	getSavedSearchQuery                   = `select * from saved_search where deleted_at is null and id = ?;`
+	createSavedSearchQuery                = `insert into saved_search (name, query, mode, category_id, last_read_at, created_at)
+values (:name, :query, :mode, :category_id, :last_read_at, :created_at);`
+	updateSavedSearchQuery = `
+update saved_search
+set name = :name, query = :query, mode = :mode, category_id = :category_id, last_read_at = :last_read_at,
+updated_at = :updated_at, deleted_at = :deleted_at
+where id = :id and deleted_at is null;`
+)
+
+type savedSearchRepository struct {
+	db *sqlx.DB
+}
+
+func NewSavedSearchRepository(db *sqlx.DB) *savedSearchRepository {
+	return &savedSearchRepository{
+		db: db,
+	}
+}
+
+func (r *savedSearchRepository) List(ctx context.Context) ([]SavedSearch, error) {
+	savedSearches := []SavedSearch{}
+	if err := r.db.SelectContext(ctx, &savedSearches, selectSavedSearchesQuery); err != nil {
+		return nil, fmt.Errorf("cannot select saved searches: %w", err)
+	}
+	return savedSearches, nil
+}
+
+func (r *savedSearchRepository) ListForCategories(ctx context.Context, categoriesIDs []int64) ([]SavedSearch, error) {
+	if len(categoriesIDs) == 0 {
+		return []SavedSearch{}, nil
+	}
+	query, args, err := sqlx.In(selectSavedSearchesForCategoriesQuery, categoriesIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing query 'in' values: %w", err)
+	}
+	savedSearches := []SavedSearch{}
+	if err := r.db.SelectContext(ctx, &savedSearches, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("cannot select saved searches for categories: %w", err)
+	}
+	return savedSearches, nil
+}
+
+func (r *savedSearchRepository) Get(ctx context.Context, id int64) (SavedSearch, error) {
+	savedSearch := SavedSearch{}
+	if err := r.db.GetContext(ctx, &savedSearch, getSavedSearchQuery, id); err != nil {
+		return SavedSearch{}, fmt.Errorf("cannot fetch saved search (id=%d): %w", id, err)
+	}
+	return savedSearch, nil
+}
+
+func (r *savedSearchRepository) Create(ctx context.Context, savedSearch SavedSearch) (int64, error) {
+	res, err := r.db.NamedExecContext(ctx, createSavedSearchQuery, savedSearch)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create saved search: %w", err)
+	}
+	lastInsertedID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	return lastInsertedID, nil
+}
+
+func (r *savedSearchRepository) Update(ctx context.Context, savedSearch SavedSearch) error {
+	if _, err := r.db.NamedExecContext(ctx, updateSavedSearchQuery, savedSearch); err != nil {
+		return fmt.Errorf("cannot update saved search: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Alkemic/webrss/search"
+)
+
+const (
+	SearchModeNatural = "natural"
+	SearchModeBoolean = "boolean"
+
+	SearchSortRelevance = "relevance"
+	SearchSortDate      = "date"
+)
+
+var (
+	// search results are selected in derived table, so they can be ordered by their relevance score
+	searchEntriesTable = `(
+select e.*, %s score
+from entry e
+join feed f on f.id = e.feed_id
+where e.deleted_at is null and f.deleted_at is null and %s
+) e`
+	matchExpression = `match(e.title, e.summary, e.author) against (? in %s)`
+	// stats of each query are selected in their own row, numbered by position of the query
+	searchStatsQuery = `
+select ? idx, coalesce(sum(e.read_at is null), 0) un_read, coalesce(max(e.created_at) > ?, false) new_entries
+from %s
+where true`
+
+	searchModes = map[string]string{
+		SearchModeNatural: "natural language mode",
+		SearchModeBoolean: "boolean mode",
+	}
+	searchWordRe = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+	likeEscaper  = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+)
+
+// SearchQuery is a parsed query, its words are searched in entries' title, summary and author using MySQL's
+// natural language or boolean full-text search mode.
+type SearchQuery struct {
+	Query search.And
+	Mode  string
+	Sort  string
+}
+
+// Search returns page of entries from not deleted feeds matching query, with their relevance score set.
+// Entries are ordered by relevance or newest first, entries are always ordered by date when query doesn't
+// contain any words.
+func (r *entryRepository) Search(ctx context.Context, query SearchQuery, page PageRequest) (EntryPage, error) {
+	mode, ok := searchModes[query.Mode]
+	if !ok {
+		return EntryPage{}, fmt.Errorf("unknown search mode '%s'", query.Mode)
+	}
+	compiled, err := compileSearch(query.Query, mode)
+	if err != nil {
+		return EntryPage{}, fmt.Errorf("cannot compile search query: %w", err)
+	}
+	key := scoreKey
+	if query.Sort == SearchSortDate || !compiled.ranked {
+		key = publishedAtKey
+	}
+	return r.listPage(ctx, compiled.from(), "true", compiled.args(), key, page)
+}
+
+// ListSearchStats counts unread entries matching each of the queries and checks whether any of them was
+// published after query's Since, stats of all queries are selected in a single query and returned in their order.
+func (r *entryRepository) ListSearchStats(ctx context.Context, queries []SearchStatsQuery) ([]SearchStats, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+	selects := make([]string, 0, len(queries))
+	args := []interface{}{}
+	for i, query := range queries {
+		mode, ok := searchModes[query.Query.Mode]
+		if !ok {
+			return nil, fmt.Errorf("unknown search mode '%s'", query.Query.Mode)
+		}
+		compiled, err := compileSearch(query.Query.Query, mode)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compile search query: %w", err)
+		}
+		selects = append(selects, fmt.Sprintf(searchStatsQuery, compiled.from()))
+		args = append(append(args, i, query.Since), compiled.args()...)
+	}
+	rows := []struct {
+		Idx int `db:"idx"`
+		SearchStats
+	}{}
+	if err := r.db.SelectContext(ctx, &rows, strings.Join(selects, "\nunion all\n"), args...); err != nil {
+		return nil, fmt.Errorf("cannot select search stats: %w", err)
+	}
+	stats := make([]SearchStats, len(queries))
+	for _, row := range rows {
+		stats[row.Idx] = row.SearchStats
+	}
+	return stats, nil
+}
+
+// compiledSearch holds conditions of the query, along with expression of relevance score matching words
+// from the query.
+type compiledSearch struct {
+	score      string
+	scoreArgs  []interface{}
+	ranked     bool
+	conditions []string
+	condArgs   []interface{}
+}
+
+func (c compiledSearch) from() string {
+	return fmt.Sprintf(searchEntriesTable, c.score, strings.Join(c.conditions, " and "))
+}
+
+func (c compiledSearch) args() []interface{} {
+	return append(append([]interface{}{}, c.scoreArgs...), c.condArgs...)
+}
+
+// compileSearch compiles query to SQL conditions. Words and phrases that must be found are combined in a single
+// full-text match, which is also used as relevance score. In natural language mode the match requires any of
+// the words, so phrases and prefixes are required by separate boolean mode matches.
+func compileSearch(query search.And, mode string) (compiledSearch, error) {
+	compiled := compiledSearch{score: "0"}
+	var words, terms []string
+	for _, node := range query.Nodes {
+		if text, ok := node.(search.Text); ok {
+			term, textWords := booleanTerm(text)
+			if term == "" {
+				continue
+			}
+			words = append(words, textWords...)
+			terms = append(terms, "+"+term)
+			if mode == searchModes[SearchModeNatural] && term != textWords[0] {
+				compiled.conditions = append(compiled.conditions, fmt.Sprintf(matchExpression, searchModes[SearchModeBoolean]))
+				compiled.condArgs = append(compiled.condArgs, term)
+			}
+			continue
+		}
+		condition, args, err := compileNode(node)
+		if err != nil {
+			return compiledSearch{}, err
+		}
+		compiled.conditions = append(compiled.conditions, condition)
+		compiled.condArgs = append(compiled.condArgs, args...)
+	}
+
+	if len(terms) > 0 {
+		against := strings.Join(terms, " ")
+		if mode == searchModes[SearchModeNatural] {
+			against = strings.Join(words, " ")
+		}
+		compiled.score = fmt.Sprintf(matchExpression, mode)
+		compiled.scoreArgs = []interface{}{against}
+		compiled.ranked = true
+		compiled.conditions = append(compiled.conditions, fmt.Sprintf(matchExpression, mode)+" > 0")
+		compiled.condArgs = append(compiled.condArgs, against)
+	}
+	if len(compiled.conditions) == 0 {
+		compiled.conditions = []string{"true"}
+	}
+	return compiled, nil
+}
+
+// compileNode compiles node to SQL condition with its params.
+func compileNode(node search.Node) (string, []interface{}, error) {
+	switch node := node.(type) {
+	case search.And:
+		conditions := make([]string, 0, len(node.Nodes))
+		var args []interface{}
+		for _, child := range node.Nodes {
+			condition, childArgs, err := compileNode(child)
+			if err != nil {
+				return "", nil, err
+			}
+			conditions = append(conditions, condition)
+			args = append(args, childArgs...)
+		}
+		return "(" + strings.Join(conditions, " and ") + ")", args, nil
+	case search.Not:
+		condition, args, err := compileNode(node.Node)
+		if err != nil {
+			return "", nil, err
+		}
+		return "not " + condition, args, nil
+	case search.Text:
+		term, _ := booleanTerm(node)
+		if term == "" {
+			return "true", nil, nil
+		}
+		return "(" + fmt.Sprintf(matchExpression, searchModes[SearchModeBoolean]) + ")", []interface{}{term}, nil
+	case search.Feed:
+		if id, err := strconv.ParseInt(node.Value, 10, 64); err == nil {
+			return "(f.id = ?)", []interface{}{id}, nil
+		}
+		return "(f.feed_title = ?)", []interface{}{node.Value}, nil
+	case search.Category:
+		if id, err := strconv.ParseInt(node.Value, 10, 64); err == nil {
+			return "(f.category_id = ?)", []interface{}{id}, nil
+		}
+		return "(f.category_id in (select c.id from category c where c.title = ? and c.deleted_at is null))", []interface{}{node.Value}, nil
+	case search.Author:
+		return "(coalesce(e.author, '') like ?)", []interface{}{"%" + likeEscaper.Replace(node.Value) + "%"}, nil
+	case search.Unread:
+		return "(e.read_at is null)", nil, nil
+	case search.Starred:
+		return "(e.starred_at is not null)", nil, nil
+	case search.HasEnclosure:
+		return "(e.enclosure is not null)", nil, nil
+	case search.Before:
+		return "(e.published_at < ?)", []interface{}{node.Time}, nil
+	case search.After:
+		return "(e.published_at >= ?)", []interface{}{node.Time}, nil
+	}
+	return "", nil, fmt.Errorf("unknown search node %T", node)
+}
+
+// booleanTerm returns text as a term of boolean mode full-text search, along with its words. Text of
+// many words is searched as a phrase, characters that are operators in boolean mode are skipped.
+func booleanTerm(text search.Text) (string, []string) {
+	words := searchWordRe.FindAllString(text.Value, -1)
+	switch {
+	case len(words) == 0:
+		return "", nil
+	case text.Phrase || len(words) > 1:
+		return `"` + strings.Join(words, " ") + `"`, words
+	case text.Prefix:
+		return words[0] + "*", words
+	}
+	return words[0], words
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/search"
+)
+
+func TestCompileSearch(t *testing.T) {
+	const (
+		booleanMatch = "match(e.title, e.summary, e.author) against (? in boolean mode)"
+		naturalMatch = "match(e.title, e.summary, e.author) against (? in natural language mode)"
+	)
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query []search.Node
+		mode  string
+
+		expectedScore      string
+		expectedConditions []string
+		expectedArgs       []interface{}
+		expectedRanked     bool
+	}{{
+		name:               "boolean mode words",
+		query:              []search.Node{search.Text{Value: "go"}, search.Text{Value: "gener", Prefix: true}, search.Text{Value: "release notes", Phrase: true}},
+		mode:               SearchModeBoolean,
+		expectedScore:      booleanMatch,
+		expectedConditions: []string{booleanMatch + " > 0"},
+		expectedArgs:       []interface{}{`+go +gener* +"release notes"`, `+go +gener* +"release notes"`},
+		expectedRanked:     true,
+	}, {
+		name:               "natural mode requires phrases",
+		query:              []search.Node{search.Text{Value: "go"}, search.Text{Value: "release-notes"}},
+		mode:               SearchModeNatural,
+		expectedScore:      naturalMatch,
+		expectedConditions: []string{booleanMatch, naturalMatch + " > 0"},
+		expectedArgs:       []interface{}{"go release notes", `"release notes"`, "go release notes"},
+		expectedRanked:     true,
+	}, {
+		name: "filters",
+		query: []search.Node{
+			search.Feed{Value: "12"},
+			search.Feed{Value: "Go blog"},
+			search.Category{Value: "3"},
+			search.Category{Value: "News"},
+			search.Author{Value: "100%"},
+			search.Unread{},
+			search.Starred{},
+			search.HasEnclosure{},
+			search.Before{Time: date},
+			search.After{Time: date},
+		},
+		mode:          SearchModeNatural,
+		expectedScore: "0",
+		expectedConditions: []string{
+			"(f.id = ?)",
+			"(f.feed_title = ?)",
+			"(f.category_id = ?)",
+			"(f.category_id in (select c.id from category c where c.title = ? and c.deleted_at is null))",
+			"(coalesce(e.author, '') like ?)",
+			"(e.read_at is null)",
+			"(e.starred_at is not null)",
+			"(e.enclosure is not null)",
+			"(e.published_at < ?)",
+			"(e.published_at >= ?)",
+		},
+		expectedArgs: []interface{}{int64(12), "Go blog", int64(3), "News", `%100\%%`, date, date},
+	}, {
+		name:               "negation",
+		query:              []search.Node{search.Not{Node: search.Text{Value: "java"}}, search.Not{Node: search.Unread{}}},
+		mode:               SearchModeNatural,
+		expectedScore:      "0",
+		expectedConditions: []string{"not (" + booleanMatch + ")", "not (e.read_at is null)"},
+		expectedArgs:       []interface{}{"java"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := compileSearch(search.And{Nodes: tt.query}, searchModes[tt.mode])
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			if compiled.score != tt.expectedScore {
+				t.Errorf("Expected score to be '%s', but got '%s'", tt.expectedScore, compiled.score)
+			}
+			if !reflect.DeepEqual(compiled.conditions, tt.expectedConditions) {
+				t.Errorf("Expected conditions to be '%v', but got '%v'", tt.expectedConditions, compiled.conditions)
+			}
+			if !reflect.DeepEqual(compiled.args(), tt.expectedArgs) {
+				t.Errorf("Expected args to be '%v', but got '%v'", tt.expectedArgs, compiled.args())
+			}
+			if compiled.ranked != tt.expectedRanked {
+				t.Errorf("Expected ranked to be '%v', but got '%v'", tt.expectedRanked, compiled.ranked)
+			}
+		})
+	}
+}
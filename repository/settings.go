@@ -13,6 +13,8 @@ const (
 
 	getSettingsQuery = "select value from settings where `key` = ?;"
 	setSettingsQuery = "update settings set value = ? where `key` = ?;"
+	// optional settings don't have their rows until they are saved for the first time
+	upsertSettingsQuery = "insert into settings (`key`, value) values (?, ?) on duplicate key update value = values(value);"
 )
 
 type settingsRepository struct {
@@ -45,6 +47,22 @@ func (r *settingsRepository) Get(ctx context.Context, key string) (string, error
 	return value, nil
 }
 
+// SetAll saves all given values, creating missing keys.
+func (r *settingsRepository) SetAll(ctx context.Context, values map[string]string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot begin transction: %w", err)
+	}
+	defer tx.Commit()
+	for key, value := range values {
+		if _, err := tx.ExecContext(ctx, upsertSettingsQuery, key, value); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("cannot save value of key %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
 func (r *settingsRepository) GetUser(ctx context.Context) (User, error) {
 	username, err := r.Get(ctx, usernameKey)
 	if err != nil {
@@ -68,11 +86,11 @@ func (r *settingsRepository) SaveUser(ctx context.Context, user User) error {
 	defer tx.Commit()
 	if err := r.SetTx(ctx, tx, usernameKey, user.Name); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("cannot update username: %w")
+		return fmt.Errorf("cannot update username: %w", err)
 	}
 	if err := r.SetTx(ctx, tx, passwordKey, string(user.Password)); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("cannot update username: %w")
+		return fmt.Errorf("cannot update password: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	selectSharesQuery = `
+select *
+from share
+where deleted_at is null and (expires_at is null or expires_at > ?)
+order by created_at desc, id desc;`
+	getShareQuery        = `select * from share where deleted_at is null and id = ?;`
+	getShareByTokenQuery = `select * from share where deleted_at is null and (expires_at is null or expires_at > ?) and token = ?;`
+	createShareQuery     = `insert into share (entry_id, token, expires_at, created_at)
+values (:entry_id, :token, :expires_at, :created_at);`
+	updateShareQuery = `update share set expires_at = :expires_at, deleted_at = :deleted_at where id = :id and deleted_at is null;`
+)
+
+type shareRepository struct {
+	db *sqlx.DB
+}
+
+func NewShareRepository(db *sqlx.DB) *shareRepository {
+	return &shareRepository{
+		db: db,
+	}
+}
+
+// List returns shares that haven't been revoked and haven't expired at given time, most recent first.
+func (r *shareRepository) List(ctx context.Context, now time.Time) ([]Share, error) {
+	shares := []Share{}
+	if err := r.db.SelectContext(ctx, &shares, selectSharesQuery, now); err != nil {
+		return nil, fmt.Errorf("cannot select shares: %w", err)
+	}
+	return shares, nil
+}
+
+func (r *shareRepository) Get(ctx context.Context, id int64) (Share, error) {
+	share := Share{}
+	if err := r.db.GetContext(ctx, &share, getShareQuery, id); err != nil {
+		return Share{}, fmt.Errorf("cannot fetch share (id=%d): %w", id, err)
+	}
+	return share, nil
+}
+
+// GetByToken returns share with given token, when it hasn't been revoked and hasn't expired at given time.
+func (r *shareRepository) GetByToken(ctx context.Context, token string, now time.Time) (Share, error) {
+	share := Share{}
+	if err := r.db.GetContext(ctx, &share, getShareByTokenQuery, now, token); err != nil {
+		return Share{}, fmt.Errorf("cannot fetch share by token: %w", err)
+	}
+	return share, nil
+}
+
+func (r *shareRepository) Create(ctx context.Context, share Share) (int64, error) {
+	res, err := r.db.NamedExecContext(ctx, createShareQuery, share)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create share: %w", err)
+	}
+	lastInsertedID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	return lastInsertedID, nil
+}
+
+func (r *shareRepository) Update(ctx context.Context, share Share) error {
+	if _, err := r.db.NamedExecContext(ctx, updateShareQuery, share); err != nil {
+		return fmt.Errorf("cannot update share: %w", err)
+	}
+	return nil
+}
@@ -27,6 +27,24 @@ func (ni NullString) MarshalJSON() ([]byte, error) {
 	return json.Marshal(ni.String)
 }
 
+type NullInt64 struct {
+	sql.NullInt64
+}
+
+func NewNullInt64(value int64) NullInt64 {
+	return NullInt64{NullInt64: sql.NullInt64{
+		Int64: value,
+		Valid: true,
+	}}
+}
+
+func (ni NullInt64) MarshalJSON() ([]byte, error) {
+	if !ni.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ni.Int64)
+}
+
 type NullTime struct {
 	sql.NullTime
 }
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// last_insert_id(id) makes the id of already existing tag available as last inserted id
+	upsertUserTagQuery = `insert into user_tag (name, created_at) values (?, ?) on duplicate key update id = last_insert_id(id);`
+	tagEntriesQuery    = `insert ignore into entry_user_tag (entry_id, user_tag_id) select id, ? from entry where id in (?);`
+	untagEntriesQuery  = `
+delete eut
+from entry_user_tag eut
+join user_tag t on t.id = eut.user_tag_id
+where t.name = ? and eut.entry_id in (?);`
+	// tags without entries are listed too, deleted entries aren't counted
+	selectUserTagsQuery = `
+select t.*, count(e.id) entries
+from user_tag t
+left join entry_user_tag eut on eut.user_tag_id = t.id
+left join entry e on e.id = eut.entry_id and e.deleted_at is null
+group by t.id
+order by t.name asc;`
+	getUserTagQuery           = `select * from user_tag where id = ?;`
+	getUserTagByNameQuery     = `select * from user_tag where name = ?;`
+	selectEntriesUserTagQuery = `
+select eut.entry_id, t.name
+from entry_user_tag eut
+join user_tag t on t.id = eut.user_tag_id
+where eut.entry_id in (?)
+order by t.name asc;`
+	renameUserTagQuery       = `update user_tag set name = ? where id = ?;`
+	moveEntryUserTagsQuery   = `insert ignore into entry_user_tag (entry_id, user_tag_id) select entry_id, ? from entry_user_tag where user_tag_id = ?;`
+	deleteEntryUserTagsQuery = `delete from entry_user_tag where user_tag_id = ?;`
+	deleteUserTagQuery       = `delete from user_tag where id = ?;`
+	renameRulesTagQuery      = `update rule set tag = ? where tag = ? and deleted_at is null;`
+)
+
+type userTagRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserTagRepository(db *sqlx.DB) *userTagRepository {
+	return &userTagRepository{
+		db: db,
+	}
+}
+
+// TagEntry adds tag with given name to the entry, the tag is created when it doesn't exist.
+func (r *userTagRepository) TagEntry(ctx context.Context, entryID int64, name string) error {
+	return r.TagEntries(ctx, []int64{entryID}, name)
+}
+
+// TagEntries adds tag with given name to the entries, the tag is created when it doesn't exist.
+func (r *userTagRepository) TagEntries(ctx context.Context, entryIDs []int64, name string) error {
+	res, err := r.db.ExecContext(ctx, upsertUserTagQuery, name, time.Now())
+	if err != nil {
+		return fmt.Errorf("cannot create user tag: %w", err)
+	}
+	tagID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	query, args, err := sqlx.In(tagEntriesQuery, tagID, entryIDs)
+	if err != nil {
+		return fmt.Errorf("cannot prepare query: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("cannot tag entries: %w", err)
+	}
+	return nil
+}
+
+// UntagEntries removes tag with given name from the entries, the tag itself is kept.
+func (r *userTagRepository) UntagEntries(ctx context.Context, entryIDs []int64, name string) error {
+	query, args, err := sqlx.In(untagEntriesQuery, name, entryIDs)
+	if err != nil {
+		return fmt.Errorf("cannot prepare query: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("cannot untag entries: %w", err)
+	}
+	return nil
+}
+
+// List returns all tags with number of their entries, ordered by name.
+func (r *userTagRepository) List(ctx context.Context) ([]UserTag, error) {
+	userTags := []UserTag{}
+	if err := r.db.SelectContext(ctx, &userTags, selectUserTagsQuery); err != nil {
+		return nil, fmt.Errorf("cannot select user tags: %w", err)
+	}
+	return userTags, nil
+}
+
+func (r *userTagRepository) Get(ctx context.Context, id int64) (UserTag, error) {
+	userTag := UserTag{}
+	if err := r.db.GetContext(ctx, &userTag, getUserTagQuery, id); err != nil {
+		return UserTag{}, fmt.Errorf("cannot fetch user tag (id=%d): %w", id, err)
+	}
+	return userTag, nil
+}
+
+// ListForEntries returns names of tags given to the entries.
+func (r *userTagRepository) ListForEntries(ctx context.Context, entryIDs []int64) ([]EntryUserTag, error) {
+	entryUserTags := []EntryUserTag{}
+	if len(entryIDs) == 0 {
+		return entryUserTags, nil
+	}
+	query, args, err := sqlx.In(selectEntriesUserTagQuery, entryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare query: %w", err)
+	}
+	if err := r.db.SelectContext(ctx, &entryUserTags, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("cannot select user tags for entries: %w", err)
+	}
+	return entryUserTags, nil
+}
+
+// Rename changes tag's name, when tag with the new name already exists both tags are merged into it.
+// Rules tagging entries with old name are updated.
+func (r *userTagRepository) Rename(ctx context.Context, id int64, name string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot begin transction: %w", err)
+	}
+	// rolling back is a no-op once the transaction is committed
+	defer tx.Rollback()
+	userTag := UserTag{}
+	if err := tx.GetContext(ctx, &userTag, getUserTagQuery, id); err != nil {
+		return fmt.Errorf("cannot fetch user tag (id=%d): %w", id, err)
+	}
+	target := UserTag{}
+	err = tx.GetContext(ctx, &target, getUserTagByNameQuery, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("cannot fetch user tag (name=%s): %w", name, err)
+	}
+	// names are compared case insensitively, so the tag itself is found when only case is changed
+	if err == nil && target.ID != id {
+		if err := r.merge(ctx, tx, id, target.ID); err != nil {
+			return err
+		}
+	} else if _, err := tx.ExecContext(ctx, renameUserTagQuery, name, id); err != nil {
+		return fmt.Errorf("cannot rename user tag: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, renameRulesTagQuery, name, userTag.Name); err != nil {
+		return fmt.Errorf("cannot update rules: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+	return nil
+}
+
+// merge moves entries of the tag to the target tag and deletes it.
+func (r *userTagRepository) merge(ctx context.Context, tx *sqlx.Tx, id, targetID int64) error {
+	if _, err := tx.ExecContext(ctx, moveEntryUserTagsQuery, targetID, id); err != nil {
+		return fmt.Errorf("cannot move tagged entries: %w", err)
+	}
+	return r.deleteTx(ctx, tx, id)
+}
+
+// Delete removes tag from all entries and deletes it.
+func (r *userTagRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot begin transction: %w", err)
+	}
+	defer tx.Rollback()
+	userTag := UserTag{}
+	if err := tx.GetContext(ctx, &userTag, getUserTagQuery, id); err != nil {
+		return fmt.Errorf("cannot fetch user tag (id=%d): %w", id, err)
+	}
+	if err := r.deleteTx(ctx, tx, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *userTagRepository) deleteTx(ctx context.Context, tx *sqlx.Tx, id int64) error {
+	if _, err := tx.ExecContext(ctx, deleteEntryUserTagsQuery, id); err != nil {
+		return fmt.Errorf("cannot untag entries: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, deleteUserTagQuery, id); err != nil {
+		return fmt.Errorf("cannot delete user tag: %w", err)
+	}
+	return nil
+}
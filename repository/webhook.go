@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	selectWebhooksQuery = `select * from webhook where deleted_at is null order by id asc;`
+	getWebhookQuery     = `select * from webhook where deleted_at is null and id = ?;`
+	createWebhookQuery  = `insert into webhook (title, url, feed_id, category_id, rule_id, payload_template, secret, created_at)
+values (:title, :url, :feed_id, :category_id, :rule_id, :payload_template, :secret, :created_at);`
+	updateWebhookQuery = `
+update webhook
+set title = :title, url = :url, feed_id = :feed_id, category_id = :category_id, rule_id = :rule_id,
+payload_template = :payload_template, secret = :secret, updated_at = :updated_at, deleted_at = :deleted_at
+where id = :id and deleted_at is null;`
+)
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *webhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) List(ctx context.Context) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	if err := r.db.SelectContext(ctx, &webhooks, selectWebhooksQuery); err != nil {
+		return nil, fmt.Errorf("cannot select webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Get(ctx context.Context, id int64) (Webhook, error) {
+	webhook := Webhook{}
+	if err := r.db.GetContext(ctx, &webhook, getWebhookQuery, id); err != nil {
+		return Webhook{}, fmt.Errorf("cannot fetch webhook (id=%d): %w", id, err)
+	}
+	return webhook, nil
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook Webhook) (int64, error) {
+	res, err := r.db.NamedExecContext(ctx, createWebhookQuery, webhook)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create webhook: %w", err)
+	}
+	lastInsertedID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	return lastInsertedID, nil
+}
+
+func (r *webhookRepository) Update(ctx context.Context, webhook Webhook) error {
+	if _, err := r.db.NamedExecContext(ctx, updateWebhookQuery, webhook); err != nil {
+		return fmt.Errorf("cannot update webhook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	selectWebhookDeliveriesQuery = `
+select *
+from webhook_delivery
+where webhook_id = ?
+order by created_at desc, id desc
+limit ?;`
+	// deliveries are attempted in order they were queued
+	selectDueWebhookDeliveriesQuery = `
+select *
+from webhook_delivery
+where status = 'pending' and next_attempt_at <= ?
+order by next_attempt_at asc, id asc
+limit ?;`
+	getWebhookDeliveryQuery    = `select * from webhook_delivery where webhook_id = ? and id = ?;`
+	createWebhookDeliveryQuery = `insert into webhook_delivery (webhook_id, entry_id, event, payload, status, next_attempt_at, created_at)
+values (:webhook_id, :entry_id, :event, :payload, :status, :next_attempt_at, :created_at);`
+	updateWebhookDeliveryQuery = `
+update webhook_delivery
+set status = :status, attempts = :attempts, next_attempt_at = :next_attempt_at, status_code = :status_code,
+error = :error, attempted_at = :attempted_at, delivered_at = :delivered_at
+where id = :id;`
+)
+
+type webhookDeliveryRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookDeliveryRepository(db *sqlx.DB) *webhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db: db,
+	}
+}
+
+// List returns up to limit latest deliveries of webhook, most recent first.
+func (r *webhookDeliveryRepository) List(ctx context.Context, webhookID int64, limit int) ([]WebhookDelivery, error) {
+	deliveries := []WebhookDelivery{}
+	if err := r.db.SelectContext(ctx, &deliveries, selectWebhookDeliveriesQuery, webhookID, limit); err != nil {
+		return nil, fmt.Errorf("cannot select webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ListDue returns up to limit pending deliveries that should be attempted at given time.
+func (r *webhookDeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error) {
+	deliveries := []WebhookDelivery{}
+	if err := r.db.SelectContext(ctx, &deliveries, selectDueWebhookDeliveriesQuery, now, limit); err != nil {
+		return nil, fmt.Errorf("cannot select due webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepository) Get(ctx context.Context, webhookID, id int64) (WebhookDelivery, error) {
+	delivery := WebhookDelivery{}
+	if err := r.db.GetContext(ctx, &delivery, getWebhookDeliveryQuery, webhookID, id); err != nil {
+		return WebhookDelivery{}, fmt.Errorf("cannot fetch webhook delivery (id=%d): %w", id, err)
+	}
+	return delivery, nil
+}
+
+func (r *webhookDeliveryRepository) Create(ctx context.Context, delivery WebhookDelivery) (int64, error) {
+	res, err := r.db.NamedExecContext(ctx, createWebhookDeliveryQuery, delivery)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create webhook delivery: %w", err)
+	}
+	lastInsertedID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("cannot fetch last inserted id: %w", err)
+	}
+	return lastInsertedID, nil
+}
+
+func (r *webhookDeliveryRepository) Update(ctx context.Context, delivery WebhookDelivery) error {
+	if _, err := r.db.NamedExecContext(ctx, updateWebhookDeliveryQuery, delivery); err != nil {
+		return fmt.Errorf("cannot update webhook delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Package sanitize makes HTML content of feed entries safe to be served outside of the application.
+package sanitize
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedAttrs maps allowed elements to their allowed attributes.
+var allowedAttrs = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: nil,
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         nil,
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          nil,
+	atom.S:          nil,
+	atom.Small:      nil,
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+}
+
+// droppedElements are removed together with their content, other disallowed elements are replaced
+// by their content.
+var droppedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Math:     true,
+	atom.Head:     true,
+	atom.Title:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+var allowedSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// HTML returns content with only allowed elements and attributes kept. Relative links and images are
+// resolved against base, when it's given, links to other schemes than http(s) and mailto are removed.
+func HTML(content string, base *url.URL) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	b := strings.Builder{}
+	open := []atom.Atom{}
+	dropDepth := 0
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				return ""
+			}
+			break
+		}
+		token := tokenizer.Token()
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[token.DataAtom] {
+				if tokenType == html.StartTagToken {
+					dropDepth++
+				}
+				continue
+			}
+			attrs, ok := allowedAttrs[token.DataAtom]
+			if !ok || dropDepth > 0 {
+				continue
+			}
+			token.Attr = filterAttrs(token, attrs, base)
+			if token.DataAtom == atom.Img && len(token.Attr) == 0 {
+				continue
+			}
+			if token.DataAtom == atom.A {
+				token.Attr = append(token.Attr, html.Attribute{Key: "rel", Val: "noopener noreferrer nofollow"})
+			}
+			if isVoid(token.DataAtom) {
+				token.Type = html.SelfClosingTagToken
+			} else {
+				token.Type = html.StartTagToken
+				open = append(open, token.DataAtom)
+			}
+			b.WriteString(token.String())
+		case html.EndTagToken:
+			if droppedElements[token.DataAtom] {
+				if dropDepth > 0 {
+					dropDepth--
+				}
+				continue
+			}
+			if dropDepth > 0 {
+				continue
+			}
+			// elements left open inside of the closed one are closed with it
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.DataAtom {
+					for _, element := range reversed(open[i:]) {
+						b.WriteString("</" + element.String() + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		case html.TextToken:
+			if dropDepth == 0 {
+				b.WriteString(html.EscapeString(token.Data))
+			}
+		}
+	}
+	for _, element := range reversed(open) {
+		b.WriteString("</" + element.String() + ">")
+	}
+	return b.String()
+}
+
+func filterAttrs(token html.Token, allowed []string, base *url.URL) []html.Attribute {
+	attrs := []html.Attribute{}
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !contains(allowed, attr.Key) {
+			continue
+		}
+		if attr.Key == "href" || attr.Key == "src" {
+			link, ok := safeURL(attr.Val, base)
+			if !ok {
+				if attr.Key == "src" {
+					return nil
+				}
+				continue
+			}
+			attr.Val = link
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// safeURL resolves link against base and checks its scheme, relative links are kept as they are when
+// base isn't given.
+func safeURL(link string, base *url.URL) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		parsed = base.ResolveReference(parsed)
+	}
+	if parsed.Scheme != "" && !allowedSchemes[strings.ToLower(parsed.Scheme)] {
+		return "", false
+	}
+	if parsed.Scheme == "" && parsed.Opaque != "" {
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+func isVoid(element atom.Atom) bool {
+	return element == atom.Br || element == atom.Hr || element == atom.Img
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func reversed(elements []atom.Atom) []atom.Atom {
+	result := make([]atom.Atom, 0, len(elements))
+	for i := len(elements) - 1; i >= 0; i-- {
+		result = append(result, elements[i])
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package sanitize
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/post.html")
+	tests := []struct {
+		name    string
+		content string
+		base    *url.URL
+
+		expected string
+	}{{
+		name:     "allowed elements",
+		content:  `<p>Some <strong>bold</strong> and <em>emphasised</em> text<br></p>`,
+		expected: `<p>Some <strong>bold</strong> and <em>emphasised</em> text<br/></p>`,
+	}, {
+		name:     "scripts and styles are dropped with content",
+		content:  `<p>text</p><script>alert("x")</script><style>p {color: red}</style><iframe src="https://example.com"><p>inner</p></iframe>`,
+		expected: `<p>text</p>`,
+	}, {
+		name:     "unknown elements are replaced by their content",
+		content:  `<section><font color="red">text</font></section>`,
+		expected: `text`,
+	}, {
+		name:     "event handlers and styles are removed",
+		content:  `<p onclick="alert(1)" style="color: red" class="x">text</p>`,
+		expected: `<p>text</p>`,
+	}, {
+		name:     "javascript links are removed",
+		content:  `<a href=" JavaScript:alert(1)">link</a>`,
+		expected: `<a rel="noopener noreferrer nofollow">link</a>`,
+	}, {
+		name:     "images with unsafe source are removed",
+		content:  `<img src="data:image/svg+xml;base64,AAAA" alt="x"><img src="/a.png" alt="a">`,
+		base:     base,
+		expected: `<img src="https://example.com/a.png" alt="a"/>`,
+	}, {
+		name:     "relative links are resolved",
+		content:  `<a href="other.html" title="Other">other</a>`,
+		base:     base,
+		expected: `<a href="https://example.com/blog/other.html" title="Other" rel="noopener noreferrer nofollow">other</a>`,
+	}, {
+		name:     "unclosed elements are closed",
+		content:  `<ul><li><b>one</li><li>two`,
+		expected: `<ul><li><b>one</b></li><li>two</li></ul>`,
+	}, {
+		name:     "stray closing tags are dropped",
+		content:  `text</p></div>`,
+		expected: `text`,
+	}, {
+		name:     "text is escaped",
+		content:  `1 &lt; 2 &amp;&amp; <b>"quoted"</b>`,
+		expected: `1 &lt; 2 &amp;&amp; <b>&#34;quoted&#34;</b>`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := HTML(tt.content, tt.base); result != tt.expected {
+				t.Errorf("Expected result to be '%s', but got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
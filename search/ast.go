@@ -0,0 +1,74 @@
+// Package search parses queries used to search entries, like:
+//
+//	golang -java "release notes" feed:12 category:News author:rob is:unread has:enclosure after:2020-01-01
+//
+// Query is a list of terms, all of which must match. Term prefixed with "-" is negated.
+package search
+
+import "time"
+
+// Node is a part of parsed query.
+type Node interface {
+	node()
+}
+
+// And matches entries matching all of its nodes.
+type And struct {
+	Nodes []Node
+}
+
+// Not matches entries not matching its node.
+type Not struct {
+	Node Node
+}
+
+// Text matches entries containing the word or, for phrases, all words next to each other, in their
+// title, summary or author. Prefix matches words starting with the value.
+type Text struct {
+	Value  string
+	Phrase bool
+	Prefix bool
+}
+
+// Feed matches entries from the feed with given id or title.
+type Feed struct {
+	Value string
+}
+
+// Category matches entries from feeds in the category with given id or title.
+type Category struct {
+	Value string
+}
+
+// Author matches entries with author containing the value.
+type Author struct {
+	Value string
+}
+
+type Unread struct{}
+
+type Starred struct{}
+
+type HasEnclosure struct{}
+
+// Before matches entries published before the time.
+type Before struct {
+	Time time.Time
+}
+
+// After matches entries published at or after the time.
+type After struct {
+	Time time.Time
+}
+
+func (And) node()          {}
+func (Not) node()          {}
+func (Text) node()         {}
+func (Feed) node()         {}
+func (Category) node()     {}
+func (Author) node()       {}
+func (Unread) node()       {}
+func (Starred) node()      {}
+func (HasEnclosure) node() {}
+func (Before) node()       {}
+func (After) node()        {}
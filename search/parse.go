@@ -0,0 +1,223 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const dateLayout = "2006-01-02"
+
+// ParseError describes why query is invalid, Pos is a position of the invalid term counted in characters
+// from 1.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Pos, e.Msg)
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+// Parse parses query into nodes that must all match, it fails on empty queries.
+func Parse(query string) (And, error) {
+	p := &parser{input: query}
+	result := And{}
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			break
+		}
+		node, err := p.parseTerm()
+		if err != nil {
+			return And{}, err
+		}
+		result.Nodes = append(result.Nodes, node)
+	}
+	if len(result.Nodes) == 0 {
+		return And{}, p.errorf(0, "empty query")
+	}
+	return result, nil
+}
+
+func (p *parser) parseTerm() (Node, error) {
+	start := p.pos
+	negated := false
+	if p.peek() == '-' {
+		negated = true
+		p.pos++
+		if p.eof() || unicode.IsSpace(p.peek()) {
+			return nil, p.errorf(start, "missing term after '-'")
+		}
+	}
+
+	var node Node
+	var err error
+	if p.peek() == '"' {
+		node, err = p.parsePhrase()
+	} else {
+		node, err = p.parseWord()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if negated {
+		return Not{Node: node}, nil
+	}
+	return node, nil
+}
+
+func (p *parser) parsePhrase() (Node, error) {
+	start := p.pos
+	value, err := p.readQuoted()
+	if err != nil {
+		return nil, err
+	}
+	if strings.IndexFunc(value, isWordRune) == -1 {
+		return nil, p.errorf(start, "no words to search in phrase '%s'", value)
+	}
+	return Text{Value: value, Phrase: true}, nil
+}
+
+func (p *parser) parseWord() (Node, error) {
+	start := p.pos
+	word := p.readWord()
+	if i := strings.Index(word, ":"); i > 0 {
+		key := strings.ToLower(word[:i])
+		if newFilter, ok := filters[key]; ok {
+			value := word[i+1:]
+			if value == "" && p.peek() == '"' {
+				var err error
+				if value, err = p.readQuoted(); err != nil {
+					return nil, err
+				}
+			}
+			if value == "" {
+				return nil, p.errorf(start, "missing value of '%s:'", key)
+			}
+			node, err := newFilter(value)
+			if err != nil {
+				return nil, p.errorf(start, "%s", err)
+			}
+			return node, nil
+		}
+	}
+
+	text := Text{Value: word}
+	if strings.HasSuffix(word, "*") {
+		text.Value, text.Prefix = strings.TrimRight(word, "*"), true
+	}
+	if strings.IndexFunc(text.Value, isWordRune) == -1 {
+		return nil, p.errorf(start, "no words to search in '%s'", word)
+	}
+	return text, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_'
+}
+
+// filters creates nodes from values of "key:value" terms.
+var filters = map[string]func(value string) (Node, error){
+	"feed": func(value string) (Node, error) {
+		return Feed{Value: value}, nil
+	},
+	"category": func(value string) (Node, error) {
+		return Category{Value: value}, nil
+	},
+	"author": func(value string) (Node, error) {
+		return Author{Value: value}, nil
+	},
+	"is": func(value string) (Node, error) {
+		switch strings.ToLower(value) {
+		case "unread":
+			return Unread{}, nil
+		case "starred":
+			return Starred{}, nil
+		}
+		return nil, fmt.Errorf("unknown value of 'is:' '%s', expected 'unread' or 'starred'", value)
+	},
+	"has": func(value string) (Node, error) {
+		if strings.ToLower(value) == "enclosure" {
+			return HasEnclosure{}, nil
+		}
+		return nil, fmt.Errorf("unknown value of 'has:' '%s', expected 'enclosure'", value)
+	},
+	"before": func(value string) (Node, error) {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", value)
+		}
+		return Before{Time: date}, nil
+	},
+	"after": func(value string) (Node, error) {
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", value)
+		}
+		return After{Time: date}, nil
+	},
+}
+
+// readQuoted reads value enclosed in double quotes.
+func (p *parser) readQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+	end := strings.IndexByte(p.input[p.pos:], '"')
+	if end == -1 {
+		return "", p.errorf(start, "missing closing quote")
+	}
+	value := strings.TrimSpace(p.input[p.pos : p.pos+end])
+	p.pos += end + 1
+	if value == "" {
+		return "", p.errorf(start, "empty phrase")
+	}
+	return value, nil
+}
+
+// readWord reads until next space or quote.
+func (p *parser) readWord() string {
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if unicode.IsSpace(r) || r == '"' {
+			break
+		}
+		p.advance()
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.advance()
+	}
+}
+
+func (p *parser) advance() {
+	_, size := utf8.DecodeRuneInString(p.input[p.pos:])
+	p.pos += size
+}
+
+func (p *parser) peek() rune {
+	r, _ := utf8.DecodeRuneInString(p.input[p.pos:])
+	return r
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{
+		Pos: utf8.RuneCountInString(p.input[:pos]) + 1,
+		Msg: fmt.Sprintf(format, args...),
+	}
+}
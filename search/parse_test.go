@@ -0,0 +1,119 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+
+		expectedQuery And
+	}{{
+		name:          "words",
+		query:         "  golang  release ",
+		expectedQuery: And{Nodes: []Node{Text{Value: "golang"}, Text{Value: "release"}}},
+	}, {
+		name:  "phrases, prefixes and negation",
+		query: `"release notes" gener* -java -"old news"`,
+		expectedQuery: And{Nodes: []Node{
+			Text{Value: "release notes", Phrase: true},
+			Text{Value: "gener", Prefix: true},
+			Not{Node: Text{Value: "java"}},
+			Not{Node: Text{Value: "old news", Phrase: true}},
+		}},
+	}, {
+		name:  "filters",
+		query: `feed:12 Category:"Tech news" author:rob is:unread is:Starred has:enclosure before:2020-02-01 after:2020-01-01`,
+		expectedQuery: And{Nodes: []Node{
+			Feed{Value: "12"},
+			Category{Value: "Tech news"},
+			Author{Value: "rob"},
+			Unread{},
+			Starred{},
+			HasEnclosure{},
+			Before{Time: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+			After{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+	}, {
+		name:          "negated filter",
+		query:         "-is:unread",
+		expectedQuery: And{Nodes: []Node{Not{Node: Unread{}}}},
+	}, {
+		name:          "unknown key is a word",
+		query:         "https://example.com",
+		expectedQuery: And{Nodes: []Node{Text{Value: "https://example.com"}}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			if !reflect.DeepEqual(query, tt.expectedQuery) {
+				t.Errorf("Expected query to be '%#v', but got '%#v'", tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+
+		expectedError string
+	}{{
+		name:          "empty query",
+		query:         "   ",
+		expectedError: "invalid query at position 1: empty query",
+	}, {
+		name:          "missing closing quote",
+		query:         `go "release notes`,
+		expectedError: "invalid query at position 4: missing closing quote",
+	}, {
+		name:          "empty phrase",
+		query:         `go ""`,
+		expectedError: "invalid query at position 4: empty phrase",
+	}, {
+		name:          "dangling negation",
+		query:         "go - java",
+		expectedError: "invalid query at position 4: missing term after '-'",
+	}, {
+		name:          "missing value",
+		query:         "źdźbło feed:",
+		expectedError: "invalid query at position 8: missing value of 'feed:'",
+	}, {
+		name:          "unknown is value",
+		query:         "is:read",
+		expectedError: "invalid query at position 1: unknown value of 'is:' 'read', expected 'unread' or 'starred'",
+	}, {
+		name:          "unknown has value",
+		query:         "has:image",
+		expectedError: "invalid query at position 1: unknown value of 'has:' 'image', expected 'enclosure'",
+	}, {
+		name:          "invalid date",
+		query:         "before:yesterday",
+		expectedError: "invalid query at position 1: invalid date 'yesterday', expected YYYY-MM-DD",
+	}, {
+		name:          "no words",
+		query:         "go *",
+		expectedError: "invalid query at position 4: no words to search in '*'",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected error to be ParseError, but got '%v'", err)
+			}
+			if err.Error() != tt.expectedError {
+				t.Errorf("Expected error to be '%s', but got '%s'", tt.expectedError, err.Error())
+			}
+		})
+	}
+}
@@ -2,12 +2,17 @@ package updater
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/Alkemic/webrss/events"
 	"github.com/Alkemic/webrss/feed_fetcher"
+	"github.com/Alkemic/webrss/httpclient"
 	"github.com/Alkemic/webrss/repository"
 )
 
@@ -16,52 +21,135 @@ type feedRepository interface {
 }
 
 type webrssService interface {
-	SaveEntries(ctx context.Context, feedID int64, entries []repository.Entry) error
+	SaveEntries(ctx context.Context, feedID int64, entries []repository.Entry) (int, int, error)
+	RecordFetch(ctx context.Context, fetchLog repository.FetchLog) error
+	PublishUnreadCounts(ctx context.Context) error
 }
 
 type feedFetcher interface {
 	Fetch(ctx context.Context, url string) (feed_fetcher.Feed, error)
 }
 
+type updaterMetrics interface {
+	ObserveUpdaterRun(duration time.Duration)
+	ObserveFetch(feedID int64, outcome string, duration time.Duration)
+	AddIngestedEntries(count int)
+}
+
+type eventPublisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// FeedEvent is published after feed was updated, or fetching or saving it failed.
+type FeedEvent struct {
+	FeedID         int64  `json:"feed_id"`
+	NewEntries     int    `json:"new_entries"`
+	UpdatedEntries int    `json:"updated_entries"`
+	Error          string `json:"error,omitempty"`
+}
+
 type UpdateService struct {
 	feedRepository feedRepository
 	webrssService  webrssService
 	feedFetcher    feedFetcher
+	metrics        updaterMetrics
+	events         eventPublisher
 	logger         *log.Logger
 }
 
-func New(feedRepository feedRepository, webrssService webrssService, feedFetcher feedFetcher, logger *log.Logger) UpdateService {
+func New(feedRepository feedRepository, webrssService webrssService, feedFetcher feedFetcher, metrics updaterMetrics,
+	events eventPublisher, logger *log.Logger) UpdateService {
 	return UpdateService{
 		feedRepository: feedRepository,
 		webrssService:  webrssService,
 		feedFetcher:    feedFetcher,
+		metrics:        metrics,
+		events:         events,
 		logger:         logger,
 	}
 }
 
+// fetchOutcome classifies result of fetching a feed for metrics.
+func fetchOutcome(fetchErr, saveErr error) string {
+	switch {
+	case saveErr != nil:
+		return "save_error"
+	case fetchErr == nil:
+		return "success"
+	case errors.Is(fetchErr, httpclient.ErrConnectTimeout), errors.Is(fetchErr, httpclient.ErrHeaderTimeout),
+		errors.Is(fetchErr, httpclient.ErrTimeout):
+		return "timeout"
+	case errors.Is(fetchErr, httpclient.ErrBodyTooLarge), errors.Is(fetchErr, httpclient.ErrDecompressionBomb):
+		return "too_large"
+	case errors.Is(fetchErr, httpclient.ErrForbiddenAddress):
+		return "forbidden"
+	default:
+		return "fetch_error"
+	}
+}
+
 func (u UpdateService) Run(ctx context.Context) error {
+	defer func(startedAt time.Time) {
+		u.metrics.ObserveUpdaterRun(time.Since(startedAt))
+	}(time.Now())
 	g, ctx := errgroup.WithContext(ctx)
 	feeds, err := u.feedRepository.List(ctx)
 	if err != nil {
 		return fmt.Errorf("cannot select feeds: %w", err)
 	}
+	var created int64
 	for _, feed := range feeds {
 		feed := feed
 		g.Go(func() error {
+			startedAt := time.Now()
 			feeder, err := u.feedFetcher.Fetch(ctx, feed.FeedUrl)
+			fetchLog := repository.FetchLog{
+				FeedID:     feed.ID,
+				FetchedAt:  repository.NewTime(startedAt),
+				StatusCode: feeder.StatusCode(),
+				Bytes:      feeder.Size(),
+			}
 			if err != nil {
 				u.logger.Printf("error fetching feed %s: %v\n", feed.FeedUrl, err)
+				fetchLog.Finish(time.Since(startedAt), err)
+				u.recordFetch(ctx, fetchLog)
+				u.metrics.ObserveFetch(feed.ID, fetchOutcome(err, nil), time.Since(startedAt))
+				u.events.Publish(events.FeedError, FeedEvent{FeedID: feed.ID, Error: err.Error()})
 				return nil
 			}
 			entries := feeder.Entries(ctx)
-			if err := u.webrssService.SaveEntries(ctx, feed.ID, entries); err != nil {
+			feedCreated, updated, err := u.webrssService.SaveEntries(ctx, feed.ID, entries)
+			fetchLog.Items, fetchLog.NewEntries, fetchLog.UpdatedEntries = len(entries), feedCreated, updated
+			fetchLog.Finish(time.Since(startedAt), err)
+			u.recordFetch(ctx, fetchLog)
+			u.metrics.ObserveFetch(feed.ID, fetchOutcome(nil, err), time.Since(startedAt))
+			u.metrics.AddIngestedEntries(feedCreated)
+			atomic.AddInt64(&created, int64(feedCreated))
+			event := FeedEvent{FeedID: feed.ID, NewEntries: feedCreated, UpdatedEntries: updated}
+			if err != nil {
+				event.Error = err.Error()
+				u.events.Publish(events.FeedError, event)
 				return fmt.Errorf("cannot save entry: %w", err)
 			}
+			u.events.Publish(events.FeedUpdated, event)
 			return nil
 		})
 	}
-	if err := g.Wait(); err != nil {
+	err = g.Wait()
+	if created > 0 {
+		// group's context is canceled once Wait returns
+		if err := u.webrssService.PublishUnreadCounts(context.Background()); err != nil {
+			u.logger.Println("cannot publish unread counts: ", err)
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("got error during processing feeds: %w", err)
 	}
 	return nil
 }
+
+func (u UpdateService) recordFetch(ctx context.Context, fetchLog repository.FetchLog) {
+	if err := u.webrssService.RecordFetch(ctx, fetchLog); err != nil {
+		u.logger.Printf("cannot record fetch of feed %d: %v\n", fetchLog.FeedID, err)
+	}
+}
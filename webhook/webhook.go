@@ -0,0 +1,170 @@
+// Package webhook delivers queued webhook calls, retrying failed ones with growing delays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+const (
+	// MaxAttempts is the number of attempts after which delivery fails.
+	MaxAttempts = 6
+	// retryDelay is the delay after the first failed attempt, it doubles after every next one.
+	retryDelay = time.Minute
+	// dueBatch is the number of deliveries attempted in a single run.
+	dueBatch = 50
+	// maxErrorBody is the number of bytes of failed response kept in the log.
+	maxErrorBody = 1024
+
+	SignatureHeader = "X-WebRSS-Signature"
+	EventHeader     = "X-WebRSS-Event"
+	DeliveryHeader  = "X-WebRSS-Delivery"
+)
+
+type webhookRepository interface {
+	Get(ctx context.Context, id int64) (repository.Webhook, error)
+}
+
+type deliveryRepository interface {
+	ListDue(ctx context.Context, now time.Time, limit int) ([]repository.WebhookDelivery, error)
+	Update(ctx context.Context, delivery repository.WebhookDelivery) error
+}
+
+type Dispatcher struct {
+	nowFn              func() time.Time
+	logger             *log.Logger
+	webhookRepository  webhookRepository
+	deliveryRepository deliveryRepository
+	httpClient         *http.Client
+}
+
+func New(logger *log.Logger, webhookRepository webhookRepository, deliveryRepository deliveryRepository,
+	httpClient *http.Client) Dispatcher {
+	return Dispatcher{
+		nowFn:              time.Now,
+		logger:             logger,
+		webhookRepository:  webhookRepository,
+		deliveryRepository: deliveryRepository,
+		httpClient:         httpClient,
+	}
+}
+
+// Signature returns hex encoded HMAC-SHA256 of payload, sent as "sha256=<signature>" in SignatureHeader.
+func Signature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliverDue attempts pending deliveries that are due.
+func (d Dispatcher) DeliverDue(ctx context.Context) error {
+	deliveries, err := d.deliveryRepository.ListDue(ctx, d.nowFn(), dueBatch)
+	if err != nil {
+		return fmt.Errorf("cannot fetch due deliveries: %w", err)
+	}
+	for _, delivery := range deliveries {
+		if err := d.deliver(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver attempts delivery and records its result, failed delivery is retried later until it runs out
+// of attempts.
+func (d Dispatcher) deliver(ctx context.Context, delivery repository.WebhookDelivery) error {
+	now := d.nowFn()
+	delivery.Attempts++
+	delivery.AttemptedAt = repository.NewNullTime(now)
+	delivery.StatusCode = repository.NullInt64{}
+	delivery.Error = repository.NullString{}
+
+	webhook, err := d.webhookRepository.Get(ctx, delivery.WebhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		delivery.Attempts = MaxAttempts
+		err = errors.New("webhook was deleted")
+	} else if err != nil {
+		return fmt.Errorf("cannot fetch webhook: %w", err)
+	} else {
+		var statusCode int
+		statusCode, err = d.call(ctx, webhook, delivery)
+		if statusCode != 0 {
+			delivery.StatusCode = repository.NewNullInt64(int64(statusCode))
+		}
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = repository.WebhookDeliveryDelivered
+		delivery.NextAttemptAt = repository.NullTime{}
+		delivery.DeliveredAt = repository.NewNullTime(now)
+	case delivery.Attempts >= MaxAttempts:
+		d.logger.Printf("webhook delivery %d failed: %v\n", delivery.ID, err)
+		delivery.Status = repository.WebhookDeliveryFailed
+		delivery.NextAttemptAt = repository.NullTime{}
+		delivery.Error = repository.NewNullString(err.Error())
+	default:
+		d.logger.Printf("webhook delivery %d failed, will be retried: %v\n", delivery.ID, err)
+		delivery.NextAttemptAt = repository.NewNullTime(now.Add(retryDelay << (delivery.Attempts - 1)))
+		delivery.Error = repository.NewNullString(err.Error())
+	}
+	if err := d.deliveryRepository.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("cannot record delivery: %w", err)
+	}
+	return nil
+}
+
+// call posts signed payload to webhook's URL, returns response status code, responses other than 2xx are
+// reported as errors.
+func (d Dispatcher) call(ctx context.Context, webhook repository.Webhook, delivery repository.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, "sha256="+Signature(webhook.Secret, payload))
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("cannot call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d: %s", resp.StatusCode, body)
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// Run delivers due deliveries every interval.
+func (d Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := d.DeliverDue(ctx); err != nil {
+			d.logger.Println("cannot deliver webhooks: ", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
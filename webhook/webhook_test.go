@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type webhookRepositoryMock struct {
+	webhooks []repository.Webhook
+}
+
+func (m *webhookRepositoryMock) Get(ctx context.Context, id int64) (repository.Webhook, error) {
+	for _, webhook := range m.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return repository.Webhook{}, sql.ErrNoRows
+}
+
+type deliveryRepositoryMock struct {
+	deliveries        []repository.WebhookDelivery
+	updatedDeliveries []repository.WebhookDelivery
+}
+
+func (m *deliveryRepositoryMock) ListDue(ctx context.Context, now time.Time, limit int) ([]repository.WebhookDelivery, error) {
+	return m.deliveries, nil
+}
+
+func (m *deliveryRepositoryMock) Update(ctx context.Context, delivery repository.WebhookDelivery) error {
+	m.updatedDeliveries = append(m.updatedDeliveries, delivery)
+	return nil
+}
+
+func TestDispatcher_DeliverDue(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	payload := `{"entry": {"id": 1}}`
+	tests := []struct {
+		name       string
+		webhookID  int64
+		attempts   int
+		statusCode int
+
+		expectedStatus        string
+		expectedAttempts      int
+		expectedStatusCode    repository.NullInt64
+		expectedNextAttemptAt repository.NullTime
+		expectedError         bool
+	}{{
+		name:               "delivered",
+		webhookID:          1,
+		statusCode:         http.StatusNoContent,
+		expectedStatus:     repository.WebhookDeliveryDelivered,
+		expectedAttempts:   1,
+		expectedStatusCode: repository.NewNullInt64(http.StatusNoContent),
+	}, {
+		name:                  "retried after first failure",
+		webhookID:             1,
+		statusCode:            http.StatusInternalServerError,
+		expectedStatus:        repository.WebhookDeliveryPending,
+		expectedAttempts:      1,
+		expectedStatusCode:    repository.NewNullInt64(http.StatusInternalServerError),
+		expectedNextAttemptAt: repository.NewNullTime(now.Add(time.Minute)),
+		expectedError:         true,
+	}, {
+		name:                  "retried with growing delay",
+		webhookID:             1,
+		attempts:              2,
+		statusCode:            http.StatusBadGateway,
+		expectedStatus:        repository.WebhookDeliveryPending,
+		expectedAttempts:      3,
+		expectedStatusCode:    repository.NewNullInt64(http.StatusBadGateway),
+		expectedNextAttemptAt: repository.NewNullTime(now.Add(4 * time.Minute)),
+		expectedError:         true,
+	}, {
+		name:               "failed after the last attempt",
+		webhookID:          1,
+		attempts:           MaxAttempts - 1,
+		statusCode:         http.StatusNotFound,
+		expectedStatus:     repository.WebhookDeliveryFailed,
+		expectedAttempts:   MaxAttempts,
+		expectedStatusCode: repository.NewNullInt64(http.StatusNotFound),
+		expectedError:      true,
+	}, {
+		name:             "deleted webhook",
+		webhookID:        2,
+		expectedStatus:   repository.WebhookDeliveryFailed,
+		expectedAttempts: MaxAttempts,
+		expectedError:    true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, _ := ioutil.ReadAll(req.Body)
+				if string(body) != payload {
+					t.Errorf("Expected body to be '%s', but got '%s'", payload, body)
+				}
+				expectedSignature := "sha256=" + Signature("secret", []byte(payload))
+				if signature := req.Header.Get(SignatureHeader); signature != expectedSignature {
+					t.Errorf("Expected signature to be '%s', but got '%s'", expectedSignature, signature)
+				}
+				if event := req.Header.Get(EventHeader); event != repository.WebhookEventEntryCreated {
+					t.Errorf("Expected event to be '%s', but got '%s'", repository.WebhookEventEntryCreated, event)
+				}
+				if id := req.Header.Get(DeliveryHeader); id != "7" {
+					t.Errorf("Expected delivery id to be '7', but got '%s'", id)
+				}
+				rw.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			deliveryRepository := &deliveryRepositoryMock{deliveries: []repository.WebhookDelivery{{
+				ID:        7,
+				WebhookID: tt.webhookID,
+				Event:     repository.WebhookEventEntryCreated,
+				Payload:   payload,
+				Status:    repository.WebhookDeliveryPending,
+				Attempts:  tt.attempts,
+			}}}
+			d := New(log.New(ioutil.Discard, "", 0), &webhookRepositoryMock{webhooks: []repository.Webhook{
+				{ID: 1, URL: server.URL, Secret: "secret"},
+			}}, deliveryRepository, server.Client())
+			d.nowFn = func() time.Time { return now }
+
+			if err := d.DeliverDue(context.Background()); err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if len(deliveryRepository.updatedDeliveries) != 1 {
+				t.Fatalf("Expected '1' updated delivery, but got '%d'", len(deliveryRepository.updatedDeliveries))
+			}
+			delivery := deliveryRepository.updatedDeliveries[0]
+			if delivery.Status != tt.expectedStatus {
+				t.Errorf("Expected status to be '%s', but got '%s'", tt.expectedStatus, delivery.Status)
+			}
+			if delivery.Attempts != tt.expectedAttempts {
+				t.Errorf("Expected attempts to be '%d', but got '%d'", tt.expectedAttempts, delivery.Attempts)
+			}
+			if delivery.StatusCode != tt.expectedStatusCode {
+				t.Errorf("Expected status code to be '%v', but got '%v'", tt.expectedStatusCode, delivery.StatusCode)
+			}
+			if delivery.NextAttemptAt != tt.expectedNextAttemptAt {
+				t.Errorf("Expected next attempt to be '%v', but got '%v'", tt.expectedNextAttemptAt, delivery.NextAttemptAt)
+			}
+			if delivery.Error.Valid != tt.expectedError {
+				t.Errorf("Expected error to be set '%t', but got '%v'", tt.expectedError, delivery.Error)
+			}
+			if !delivery.AttemptedAt.Valid || !delivery.AttemptedAt.Time.Equal(now) {
+				t.Errorf("Expected attempted at to be '%v', but got '%v'", now, delivery.AttemptedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+var ErrInvalidAnnotation = errors.New("invalid annotation")
+
+type annotationRepository interface {
+	List(ctx context.Context) ([]repository.Annotation, error)
+	ListForEntry(ctx context.Context, entryID int64) ([]repository.Annotation, error)
+	Get(ctx context.Context, entryID, id int64) (repository.Annotation, error)
+	Create(ctx context.Context, annotation repository.Annotation) (int64, error)
+	Update(ctx context.Context, annotation repository.Annotation) error
+}
+
+var (
+	markdownTextEscaper = strings.NewReplacer(`[`, `\[`, `]`, `\]`)
+	markdownLinkEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+)
+
+// validateAnnotation checks whether highlight has its quote and position, and whether note isn't empty.
+func validateAnnotation(annotation repository.Annotation) error {
+	switch annotation.Kind {
+	case repository.AnnotationKindHighlight:
+		if strings.TrimSpace(annotation.Quote.String) == "" {
+			return fmt.Errorf("%w: missing quote", ErrInvalidAnnotation)
+		}
+		if !annotation.Offset.Valid || annotation.Offset.Int64 < 0 {
+			return fmt.Errorf("%w: missing offset of quote", ErrInvalidAnnotation)
+		}
+	case repository.AnnotationKindNote:
+		if strings.TrimSpace(annotation.Note.String) == "" {
+			return fmt.Errorf("%w: missing note", ErrInvalidAnnotation)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind '%s'", ErrInvalidAnnotation, annotation.Kind)
+	}
+	return nil
+}
+
+func (s WebRSSService) ListEntryAnnotations(ctx context.Context, entryID int64) ([]repository.Annotation, error) {
+	annotations, err := s.annotationRepository.ListForEntry(ctx, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+func (s WebRSSService) GetAnnotation(ctx context.Context, entryID, id int64) (repository.Annotation, error) {
+	annotation, err := s.annotationRepository.Get(ctx, entryID, id)
+	if err != nil {
+		return repository.Annotation{}, fmt.Errorf("error fetching annotation: %w", err)
+	}
+	return annotation, nil
+}
+
+// CreateAnnotation annotates existing entry, returns created annotation.
+func (s WebRSSService) CreateAnnotation(ctx context.Context, annotation repository.Annotation) (repository.Annotation, error) {
+	if err := validateAnnotation(annotation); err != nil {
+		return repository.Annotation{}, err
+	}
+	if _, err := s.entryRepository.Get(ctx, annotation.EntryID); err != nil {
+		return repository.Annotation{}, fmt.Errorf("error fetching annotated entry: %w", err)
+	}
+	annotation.CreatedAt = repository.NewTime(s.nowFn())
+	id, err := s.annotationRepository.Create(ctx, annotation)
+	if err != nil {
+		return repository.Annotation{}, fmt.Errorf("error creating annotation: %w", err)
+	}
+	annotation.ID = id
+	return annotation, nil
+}
+
+func (s WebRSSService) UpdateAnnotation(ctx context.Context, annotation repository.Annotation) error {
+	if err := validateAnnotation(annotation); err != nil {
+		return err
+	}
+	annotation.UpdatedAt = repository.NewNullTime(s.nowFn())
+	if err := s.annotationRepository.Update(ctx, annotation); err != nil {
+		return fmt.Errorf("error updating annotation: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) DeleteAnnotation(ctx context.Context, entryID, id int64) error {
+	annotation, err := s.GetAnnotation(ctx, entryID, id)
+	if err != nil {
+		return fmt.Errorf("cannot fetch annotation for delete: %w", err)
+	}
+	now := repository.NewNullTime(s.nowFn())
+	annotation.UpdatedAt = now
+	annotation.DeletedAt = now
+	if err := s.annotationRepository.Update(ctx, annotation); err != nil {
+		return fmt.Errorf("error deleting annotation: %w", err)
+	}
+	return nil
+}
+
+// ListAnnotations returns annotations of all entries, most recent first, with their entries and feeds.
+func (s WebRSSService) ListAnnotations(ctx context.Context) ([]repository.Annotation, error) {
+	annotations, err := s.annotationRepository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching annotations: %w", err)
+	}
+	ids := []int64{}
+	seen := map[int64]bool{}
+	for _, annotation := range annotations {
+		if !seen[annotation.EntryID] {
+			seen[annotation.EntryID] = true
+			ids = append(ids, annotation.EntryID)
+		}
+	}
+	entries, err := s.entryRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching annotated entries: %w", err)
+	}
+	if err := s.attachFeeds(ctx, entries); err != nil {
+		return nil, fmt.Errorf("cannot fetch feeds for entries: %w", err)
+	}
+	entriesByID := make(map[int64]*repository.Entry, len(entries))
+	for i := range entries {
+		entriesByID[entries[i].ID] = &entries[i]
+	}
+	for i := range annotations {
+		annotations[i].Entry = entriesByID[annotations[i].EntryID]
+	}
+	return annotations, nil
+}
+
+// ExportAnnotations returns all annotations as Markdown document.
+func (s WebRSSService) ExportAnnotations(ctx context.Context) (string, error) {
+	annotations, err := s.ListAnnotations(ctx)
+	if err != nil {
+		return "", err
+	}
+	return annotationsMarkdown(annotations), nil
+}
+
+type annotatedEntry struct {
+	entry       *repository.Entry
+	annotations []repository.Annotation
+}
+
+type annotatedFeed struct {
+	feed    repository.Feed
+	entries []*annotatedEntry
+}
+
+// annotationsMarkdown renders annotations grouped by feed, sorted by title, and by entry, newest first.
+// Highlights are rendered as quotes in order they appear in the entry, followed by notes.
+func annotationsMarkdown(annotations []repository.Annotation) string {
+	feeds := []*annotatedFeed{}
+	feedsByID := map[int64]*annotatedFeed{}
+	entriesByID := map[int64]*annotatedEntry{}
+	for _, annotation := range annotations {
+		if annotation.Entry == nil {
+			continue
+		}
+		entry, ok := entriesByID[annotation.EntryID]
+		if !ok {
+			feed, ok := feedsByID[annotation.Entry.FeedID]
+			if !ok {
+				feed = &annotatedFeed{feed: annotation.Entry.Feed}
+				feedsByID[annotation.Entry.FeedID] = feed
+				feeds = append(feeds, feed)
+			}
+			entry = &annotatedEntry{entry: annotation.Entry}
+			entriesByID[annotation.EntryID] = entry
+			feed.entries = append(feed.entries, entry)
+		}
+		entry.annotations = append(entry.annotations, annotation)
+	}
+
+	sort.SliceStable(feeds, func(i, j int) bool {
+		return strings.ToLower(feeds[i].feed.FeedTitle) < strings.ToLower(feeds[j].feed.FeedTitle)
+	})
+	b := strings.Builder{}
+	b.WriteString("# Annotations\n")
+	for _, feed := range feeds {
+		fmt.Fprintf(&b, "\n## %s\n", feed.feed.FeedTitle)
+		sort.SliceStable(feed.entries, func(i, j int) bool {
+			return feed.entries[i].entry.PublishedAt.Time.After(feed.entries[j].entry.PublishedAt.Time)
+		})
+		for _, entry := range feed.entries {
+			fmt.Fprintf(&b, "\n### [%s](%s)\n", markdownTextEscaper.Replace(entry.entry.Title),
+				markdownLinkEscaper.Replace(entry.entry.Link))
+			sort.SliceStable(entry.annotations, func(i, j int) bool {
+				a, b := entry.annotations[i], entry.annotations[j]
+				if a.Offset.Valid != b.Offset.Valid {
+					return a.Offset.Valid
+				}
+				if a.Offset.Int64 != b.Offset.Int64 {
+					return a.Offset.Int64 < b.Offset.Int64
+				}
+				return a.ID < b.ID
+			})
+			for _, annotation := range entry.annotations {
+				if annotation.Kind == repository.AnnotationKindHighlight {
+					b.WriteString("\n> " + strings.Join(strings.Split(strings.TrimSpace(annotation.Quote.String), "\n"), "\n> ") + "\n")
+				}
+				if note := strings.TrimSpace(annotation.Note.String); note != "" {
+					b.WriteString("\n" + note + "\n")
+				}
+			}
+		}
+	}
+	return b.String()
+}
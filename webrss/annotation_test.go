@@ -0,0 +1,133 @@
+package webrss
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+func TestValidateAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotation  repository.Annotation
+		expectedErr error
+	}{{
+		name: "highlight",
+		annotation: repository.Annotation{
+			Kind:   repository.AnnotationKindHighlight,
+			Quote:  repository.NewNullString("quote"),
+			Offset: repository.NewNullInt64(0),
+		},
+	}, {
+		name: "highlight with note",
+		annotation: repository.Annotation{
+			Kind:   repository.AnnotationKindHighlight,
+			Quote:  repository.NewNullString("quote"),
+			Offset: repository.NewNullInt64(12),
+			Note:   repository.NewNullString("note"),
+		},
+	}, {
+		name: "highlight without quote",
+		annotation: repository.Annotation{
+			Kind:   repository.AnnotationKindHighlight,
+			Quote:  repository.NewNullString("  "),
+			Offset: repository.NewNullInt64(0),
+		},
+		expectedErr: ErrInvalidAnnotation,
+	}, {
+		name: "highlight without offset",
+		annotation: repository.Annotation{
+			Kind:  repository.AnnotationKindHighlight,
+			Quote: repository.NewNullString("quote"),
+		},
+		expectedErr: ErrInvalidAnnotation,
+	}, {
+		name:       "note",
+		annotation: repository.Annotation{Kind: repository.AnnotationKindNote, Note: repository.NewNullString("note")},
+	}, {
+		name:        "empty note",
+		annotation:  repository.Annotation{Kind: repository.AnnotationKindNote},
+		expectedErr: ErrInvalidAnnotation,
+	}, {
+		name:        "unknown kind",
+		annotation:  repository.Annotation{Kind: "bookmark", Note: repository.NewNullString("note")},
+		expectedErr: ErrInvalidAnnotation,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAnnotation(tt.annotation); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestAnnotationsMarkdown(t *testing.T) {
+	goFeed := repository.Feed{ID: 1, FeedTitle: "The Go Blog"}
+	lwnFeed := repository.Feed{ID: 2, FeedTitle: "LWN"}
+	olderEntry := &repository.Entry{
+		ID: 10, FeedID: 1, Feed: goFeed, Title: "Go [1.13]", Link: "https://blog.golang.org/go1.13 (release)",
+		PublishedAt: repository.NewTime(time.Date(2019, 9, 3, 0, 0, 0, 0, time.UTC)),
+	}
+	newerEntry := &repository.Entry{
+		ID: 11, FeedID: 1, Feed: goFeed, Title: "Go 1.14", Link: "https://blog.golang.org/go1.14",
+		PublishedAt: repository.NewTime(time.Date(2020, 2, 25, 0, 0, 0, 0, time.UTC)),
+	}
+	lwnEntry := &repository.Entry{
+		ID: 12, FeedID: 2, Feed: lwnFeed, Title: "Kernel release", Link: "https://lwn.net/1",
+		PublishedAt: repository.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	annotations := []repository.Annotation{{
+		ID: 5, EntryID: 10, Entry: olderEntry, Kind: repository.AnnotationKindNote,
+		Note: repository.NewNullString("Worth upgrading."),
+	}, {
+		ID: 4, EntryID: 10, Entry: olderEntry, Kind: repository.AnnotationKindHighlight,
+		Quote: repository.NewNullString("error wrapping\nwith %w"), Offset: repository.NewNullInt64(40),
+		Note: repository.NewNullString("Use it everywhere."),
+	}, {
+		ID: 3, EntryID: 10, Entry: olderEntry, Kind: repository.AnnotationKindHighlight,
+		Quote: repository.NewNullString("modules"), Offset: repository.NewNullInt64(10),
+	}, {
+		ID: 2, EntryID: 12, Entry: lwnEntry, Kind: repository.AnnotationKindNote,
+		Note: repository.NewNullString("Read later."),
+	}, {
+		ID: 1, EntryID: 11, Entry: newerEntry, Kind: repository.AnnotationKindHighlight,
+		Quote: repository.NewNullString("defer is fast"), Offset: repository.NewNullInt64(0),
+	}, {
+		ID: 0, EntryID: 99, Kind: repository.AnnotationKindNote, Note: repository.NewNullString("missing entry"),
+	}}
+
+	expected := `# Annotations
+
+## LWN
+
+### [Kernel release](https://lwn.net/1)
+
+Read later.
+
+## The Go Blog
+
+### [Go 1.14](https://blog.golang.org/go1.14)
+
+> defer is fast
+
+### [Go \[1.13\]](https://blog.golang.org/go1.13%20%28release%29)
+
+> modules
+
+> error wrapping
+> with %w
+
+Use it everywhere.
+
+Worth upgrading.
+`
+	if document := annotationsMarkdown(annotations); document != expected {
+		t.Errorf("Expected document to be '%s', but got '%s'", expected, document)
+	}
+	if document := annotationsMarkdown(nil); document != "# Annotations\n" {
+		t.Errorf("Expected empty document, but got '%s'", document)
+	}
+}
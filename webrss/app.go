@@ -2,6 +2,7 @@ package webrss
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/Alkemic/webrss/account"
 	"github.com/Alkemic/webrss/config"
+	"github.com/Alkemic/webrss/metrics"
 	"github.com/Alkemic/webrss/repository"
 )
 
@@ -21,34 +23,68 @@ type handler interface {
 	GetRoutes() *route.RegexpRouter
 }
 
+// publicHandler has also routes available without logging in.
+type publicHandler interface {
+	handler
+	GetPublicRoutes() *route.RegexpRouter
+}
+
 type feedsUpdater interface {
 	Run(ctx context.Context) error
 }
 
+type appMetrics interface {
+	Handler() http.Handler
+	TimeTrack(f http.HandlerFunc) http.HandlerFunc
+}
+
 type App struct {
-	logger          *log.Logger
-	cfg             *config.Config
-	routes          *route.RegexpRouter
-	categoryHandler handler
-	feedHandler     handler
-	entryHandler    handler
-	feedsUpdater    feedsUpdater
-	updaterInterval time.Duration
+	logger             *log.Logger
+	cfg                *config.Config
+	routes             *metrics.Router
+	categoryHandler    handler
+	feedHandler        handler
+	entryHandler       handler
+	ruleHandler        handler
+	savedSearchHandler handler
+	userTagHandler     handler
+	shareHandler       publicHandler
+	digestHandler      handler
+	webhookHandler     handler
+	eventsHandler      handler
+	exportHandler      handler
+	readLaterHandler   handler
+	feedsUpdater       feedsUpdater
+	updaterInterval    time.Duration
+	metrics            appMetrics
 
 	onExit []func()
 }
 
 func New(logger *log.Logger, cfg *config.Config, categoryHandler handler, feedHandler handler, entryHandler handler,
-	authenticateHandler *account.AuthenticateHandler, authenticateMiddleware *account.Middleware, feedsUpdater feedsUpdater, updaterInterval time.Duration) App {
+	ruleHandler handler, savedSearchHandler handler, userTagHandler handler, shareHandler publicHandler, digestHandler handler,
+	webhookHandler handler, eventsHandler handler, exportHandler handler, readLaterHandler handler,
+	authenticateHandler *account.AuthenticateHandler, authenticateMiddleware *account.Middleware, feedsUpdater feedsUpdater,
+	updaterInterval time.Duration, metricsService appMetrics) App {
 	app := App{
-		logger:          logger,
-		cfg:             cfg,
-		routes:          route.New(),
-		categoryHandler: categoryHandler,
-		feedHandler:     feedHandler,
-		entryHandler:    entryHandler,
-		feedsUpdater:    feedsUpdater,
-		updaterInterval: updaterInterval,
+		logger:             logger,
+		cfg:                cfg,
+		routes:             metrics.NewRouter(),
+		categoryHandler:    categoryHandler,
+		feedHandler:        feedHandler,
+		entryHandler:       entryHandler,
+		ruleHandler:        ruleHandler,
+		savedSearchHandler: savedSearchHandler,
+		userTagHandler:     userTagHandler,
+		shareHandler:       shareHandler,
+		digestHandler:      digestHandler,
+		webhookHandler:     webhookHandler,
+		eventsHandler:      eventsHandler,
+		exportHandler:      exportHandler,
+		readLaterHandler:   readLaterHandler,
+		feedsUpdater:       feedsUpdater,
+		updaterInterval:    updaterInterval,
+		metrics:            metricsService,
 	}
 
 	setHeaders := middleware.SetHeaders(map[string]string{
@@ -61,10 +97,23 @@ func New(logger *log.Logger, cfg *config.Config, categoryHandler handler, feedHa
 	app.routes.Add("^/api/category", categoryHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
 	app.routes.Add("^/api/entry", entryHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
 	app.routes.Add("^/api/feed", feedHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/rule", ruleHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/saved_search", savedSearchHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/user_tag", userTagHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/share", shareHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/share", shareHandler.GetPublicRoutes())
+	app.routes.Add("^/api/digest", digestHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/webhook", webhookHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/events", eventsHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/export", exportHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
+	app.routes.Add("^/api/read_later", readLaterHandler.GetRoutes().AddMiddleware(authenticateMiddleware.LoginRequiredMiddleware))
 	app.routes.Add("^/favicon.ico$", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "static/images/favicon.ico")
 	})
 	app.routes.Add("^/api/user/$", setHeaders(authenticateMiddleware.LoginRequiredMiddleware(authenticateHandler.Edit)))
+	if cfg.MetricsBindAddr == "" && cfg.MetricsToken != "" {
+		app.routes.Add("^/metrics$", tokenRequired(cfg.MetricsToken)(metricsService.Handler().ServeHTTP))
+	}
 	app.routes.Add("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 	app.routes.Add("/", authenticateMiddleware.LoginRequiredMiddleware(func(rw http.ResponseWriter, req *http.Request) {
 		tmpl := template.Must(template.New("index.html").Funcs(map[string]interface{}{
@@ -90,9 +139,30 @@ func New(logger *log.Logger, cfg *config.Config, categoryHandler handler, feedHa
 	return app
 }
 
+// tokenRequired allows only requests with "Authorization: Bearer <token>" header.
+func tokenRequired(token string) func(http.HandlerFunc) http.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(f http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), expected) != 1 {
+				http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			f(rw, req)
+		}
+	}
+}
+
 func (a App) Run() error {
 	defer a.execOnExit()
-	handler := middleware.TimeTrack(a.logger)(middleware.PanicInterceptorWithLogger(a.logger)(a.routes.ServeHTTP))
+	if a.cfg.MetricsBindAddr != "" {
+		go func() {
+			if err := http.ListenAndServe(a.cfg.MetricsBindAddr, a.metrics.Handler()); err != http.ErrServerClosed {
+				a.logger.Println("metrics server exited with error: ", err)
+			}
+		}()
+	}
+	handler := middleware.TimeTrack(a.logger)(a.metrics.TimeTrack(middleware.PanicInterceptorWithLogger(a.logger)(a.routes.ServeHTTP)))
 	if err := http.ListenAndServe(a.cfg.BindAdr, handler); err != http.ErrServerClosed {
 		return fmt.Errorf("exited with error: %w", err)
 	}
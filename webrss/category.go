@@ -25,19 +25,42 @@ func (s WebRSSService) ListCategories(ctx context.Context, params ...string) ([]
 	for i, category := range categories {
 		ids = append(ids, category.ID)
 		categories[i].Feeds = make([]repository.Feed, 0)
+		categories[i].SavedSearches = make([]repository.SavedSearch, 0)
 	}
 
 	feeds, err := s.feedRepository.ListForCategories(ctx, ids)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching feeds for categories: %w", err)
 	}
+	seen, err := s.getLastSeen(ctx)
+	if err != nil {
+		return nil, err
+	}
 	for _, feed := range feeds {
+		if feed.LatestEntryAt.Valid && feed.LatestEntryAt.Time.After(seen.feed(feed)) {
+			feed.NewEntries = 1
+		}
 		for i, category := range categories {
 			if category.ID == feed.CategoryID {
 				categories[i].Feeds = append(categories[i].Feeds, feed)
 			}
 		}
 	}
+
+	savedSearches, err := s.savedSearchRepository.ListForCategories(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saved searches for categories: %w", err)
+	}
+	if err := s.attachSearchStats(ctx, savedSearches, seen); err != nil {
+		return nil, err
+	}
+	for _, savedSearch := range savedSearches {
+		for i, category := range categories {
+			if category.ID == savedSearch.CategoryID {
+				categories[i].SavedSearches = append(categories[i].SavedSearches, savedSearch)
+			}
+		}
+	}
 	return categories, nil
 }
 
@@ -79,6 +102,18 @@ func (s WebRSSService) DeleteCategory(ctx context.Context, id int64) error {
 			return fmt.Errorf("error deleteing feed: %w", err)
 		}
 	}
+
+	savedSearches, err := s.savedSearchRepository.ListForCategories(ctx, []int64{category.ID})
+	if err != nil {
+		s.transactionRepository.Rollback(ctx)
+		return fmt.Errorf("error fetching saved searches: %w", err)
+	}
+	for _, savedSearch := range savedSearches {
+		if err := s.DeleteSavedSearch(ctx, savedSearch.ID); err != nil {
+			s.transactionRepository.Rollback(ctx)
+			return fmt.Errorf("error deleting saved search: %w", err)
+		}
+	}
 	return nil
 }
 
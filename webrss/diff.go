@@ -0,0 +1,113 @@
+package webrss
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+
+	// maxDiffCells limits size of the table used to find common words, when texts differ too much,
+	// whole text is reported as replaced.
+	maxDiffCells = 1 << 20
+)
+
+type DiffOp struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// splitWords splits text into words and whitespace between them, so joining them gives back the text.
+func splitWords(text string) []string {
+	var tokens []string
+	start, inSpace := 0, false
+	for i, r := range text {
+		if space := unicode.IsSpace(r); i > start && space != inSpace {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		inSpace = unicode.IsSpace(r)
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// diffWords returns word level diff turning a into b.
+func diffWords(a, b string) []DiffOp {
+	aWords, bWords := splitWords(a), splitWords(b)
+
+	prefix := 0
+	for prefix < len(aWords) && prefix < len(bWords) && aWords[prefix] == bWords[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(aWords)-prefix && suffix < len(bWords)-prefix &&
+		aWords[len(aWords)-1-suffix] == bWords[len(bWords)-1-suffix] {
+		suffix++
+	}
+
+	var ops []DiffOp
+	add := func(op string, words ...string) {
+		if len(words) == 0 {
+			return
+		}
+		text := strings.Join(words, "")
+		if len(ops) > 0 && ops[len(ops)-1].Op == op {
+			ops[len(ops)-1].Text += text
+			return
+		}
+		ops = append(ops, DiffOp{Op: op, Text: text})
+	}
+
+	add(DiffEqual, aWords[:prefix]...)
+	aMiddle, bMiddle := aWords[prefix:len(aWords)-suffix], bWords[prefix:len(bWords)-suffix]
+	if len(aMiddle)*len(bMiddle) > maxDiffCells {
+		add(DiffDelete, aMiddle...)
+		add(DiffInsert, bMiddle...)
+	} else {
+		// lengths[i][j] is the length of the longest common subsequence of aMiddle[i:] and bMiddle[j:]
+		lengths := make([][]int32, len(aMiddle)+1)
+		for i := range lengths {
+			lengths[i] = make([]int32, len(bMiddle)+1)
+		}
+		for i := len(aMiddle) - 1; i >= 0; i-- {
+			for j := len(bMiddle) - 1; j >= 0; j-- {
+				if aMiddle[i] == bMiddle[j] {
+					lengths[i][j] = lengths[i+1][j+1] + 1
+				} else if lengths[i+1][j] >= lengths[i][j+1] {
+					lengths[i][j] = lengths[i+1][j]
+				} else {
+					lengths[i][j] = lengths[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(aMiddle) && j < len(bMiddle) {
+			switch {
+			case aMiddle[i] == bMiddle[j]:
+				add(DiffEqual, aMiddle[i])
+				i++
+				j++
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				add(DiffDelete, aMiddle[i])
+				i++
+			default:
+				add(DiffInsert, bMiddle[j])
+				j++
+			}
+		}
+		add(DiffDelete, aMiddle[i:]...)
+		add(DiffInsert, bMiddle[j:]...)
+	}
+	add(DiffEqual, aWords[len(aWords)-suffix:]...)
+
+	if ops == nil {
+		ops = []DiffOp{}
+	}
+	return ops
+}
@@ -0,0 +1,54 @@
+package webrss
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []DiffOp
+	}{{
+		name:     "same texts",
+		a:        "Go 1.15 is released",
+		b:        "Go 1.15 is released",
+		expected: []DiffOp{{Op: DiffEqual, Text: "Go 1.15 is released"}},
+	}, {
+		name:     "empty texts",
+		expected: []DiffOp{},
+	}, {
+		name: "changed word",
+		a:    "Go 1.15 is released",
+		b:    "Go 1.16 is released",
+		expected: []DiffOp{
+			{Op: DiffEqual, Text: "Go "},
+			{Op: DiffDelete, Text: "1.15"},
+			{Op: DiffInsert, Text: "1.16"},
+			{Op: DiffEqual, Text: " is released"},
+		},
+	}, {
+		name: "inserted and deleted words",
+		a:    "Go is released today",
+		b:    "Go 1.16 is released",
+		expected: []DiffOp{
+			{Op: DiffEqual, Text: "Go "},
+			{Op: DiffInsert, Text: "1.16 "},
+			{Op: DiffEqual, Text: "is released"},
+			{Op: DiffDelete, Text: " today"},
+		},
+	}, {
+		name:     "text added to empty one",
+		a:        "",
+		b:        "correction",
+		expected: []DiffOp{{Op: DiffInsert, Text: "correction"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ops := diffWords(tt.a, tt.b); !reflect.DeepEqual(ops, tt.expected) {
+				t.Errorf("Expected diff to be '%+v', but got '%+v'", tt.expected, ops)
+			}
+		})
+	}
+}
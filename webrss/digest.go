@@ -0,0 +1,103 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+const (
+	// DigestModeUnread lists all unread entries.
+	DigestModeUnread = "unread"
+	// DigestModeHighlights lists only starred entries and entries matched by star or tag rules.
+	DigestModeHighlights = "highlights"
+
+	// digestMaxEntries is the number of entries considered for a single digest.
+	digestMaxEntries = 1000
+)
+
+var ErrInvalidDigestMode = errors.New("invalid digest mode")
+
+// Digest holds entries created in [Since, CreatedAt) grouped by categories, in categories order.
+type Digest struct {
+	Mode       string
+	Since      time.Time
+	CreatedAt  time.Time
+	Categories []DigestCategory
+	Entries    int
+}
+
+type DigestCategory struct {
+	Category repository.Category
+	Entries  []repository.Entry
+}
+
+// BuildDigest collects entries created since given time, newest first, with their feeds.
+func (s WebRSSService) BuildDigest(ctx context.Context, mode string, since time.Time) (Digest, error) {
+	if mode != DigestModeUnread && mode != DigestModeHighlights {
+		return Digest{}, fmt.Errorf("%w: '%s'", ErrInvalidDigestMode, mode)
+	}
+	digest := Digest{Mode: mode, Since: since, CreatedAt: s.nowFn()}
+	entries, err := s.entryRepository.ListCreatedSince(ctx, since, mode == DigestModeUnread, digestMaxEntries)
+	if err != nil {
+		return Digest{}, fmt.Errorf("error fetching entries: %w", err)
+	}
+	if mode == DigestModeHighlights {
+		if entries, err = s.highlightedEntries(ctx, entries); err != nil {
+			return Digest{}, err
+		}
+	}
+	if len(entries) == 0 {
+		return digest, nil
+	}
+	if err := s.attachFeeds(ctx, entries); err != nil {
+		return Digest{}, fmt.Errorf("cannot fetch feeds for entries: %w", err)
+	}
+	categories, err := s.categoryRepository.List(ctx)
+	if err != nil {
+		return Digest{}, fmt.Errorf("error fetching categories: %w", err)
+	}
+	entriesByCategory := map[int64][]repository.Entry{}
+	for _, entry := range entries {
+		entriesByCategory[entry.Feed.CategoryID] = append(entriesByCategory[entry.Feed.CategoryID], entry)
+	}
+	for _, category := range categories {
+		if categoryEntries := entriesByCategory[category.ID]; len(categoryEntries) > 0 {
+			digest.Categories = append(digest.Categories, DigestCategory{Category: category, Entries: categoryEntries})
+			digest.Entries += len(categoryEntries)
+		}
+	}
+	return digest, nil
+}
+
+// highlightedEntries returns starred entries and entries matched by rules that star or tag them.
+func (s WebRSSService) highlightedEntries(ctx context.Context, entries []repository.Entry) ([]repository.Entry, error) {
+	matchersByFeed := map[int64][]ruleMatcher{}
+	highlighted := []repository.Entry{}
+	for _, entry := range entries {
+		if entry.StarredAt.Valid {
+			highlighted = append(highlighted, entry)
+			continue
+		}
+		matchers, ok := matchersByFeed[entry.FeedID]
+		if !ok {
+			rules, err := s.ruleRepository.ListForFeed(ctx, entry.FeedID)
+			if err != nil {
+				return nil, fmt.Errorf("error fetching rules for feed: %w", err)
+			}
+			matchers = s.newRuleMatchers(rules)
+			matchersByFeed[entry.FeedID] = matchers
+		}
+		for _, matcher := range matchers {
+			action := matcher.rule.Action
+			if (action == repository.RuleActionStar || action == repository.RuleActionTag) && matcher.matches(entry) {
+				highlighted = append(highlighted, entry)
+				break
+			}
+		}
+	}
+	return highlighted, nil
+}
@@ -0,0 +1,133 @@
+package webrss
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type categoryRepositoryMock struct {
+	categories []repository.Category
+}
+
+func (m *categoryRepositoryMock) List(ctx context.Context, params ...string) ([]repository.Category, error) {
+	return m.categories, nil
+}
+
+func (m *categoryRepositoryMock) Get(ctx context.Context, id int64) (repository.Category, error) {
+	for _, category := range m.categories {
+		if category.ID == id {
+			return category, nil
+		}
+	}
+	return repository.Category{}, sql.ErrNoRows
+}
+
+func (m *categoryRepositoryMock) Update(ctx context.Context, category repository.Category) error {
+	panic("implement me!")
+}
+
+func (m *categoryRepositoryMock) GetNextByOrder(ctx context.Context, order int) (repository.Category, error) {
+	panic("implement me!")
+}
+
+func (m *categoryRepositoryMock) GetPrevByOrder(ctx context.Context, order int) (repository.Category, error) {
+	panic("implement me!")
+}
+
+func (m *categoryRepositoryMock) Create(ctx context.Context, category repository.Category) error {
+	panic("implement me!")
+}
+
+func (m *categoryRepositoryMock) SelectMaxOrder(ctx context.Context) (int, error) {
+	panic("implement me!")
+}
+
+func TestFeedService_BuildDigest(t *testing.T) {
+	since := time.Date(2020, 1, 9, 0, 0, 0, 0, time.UTC)
+	createdAt := repository.NewTime(since.Add(time.Hour))
+	entries := []repository.Entry{
+		{ID: 1, FeedID: 1, Title: "Unread", CreatedAt: createdAt},
+		{ID: 2, FeedID: 2, Title: "Unread golang", CreatedAt: createdAt},
+		{ID: 3, FeedID: 1, Title: "Starred", CreatedAt: createdAt, StarredAt: repository.NewNullTime(since),
+			ReadAt: repository.NewNullTime(since)},
+		{ID: 4, FeedID: 3, Title: "Read golang", CreatedAt: createdAt, ReadAt: repository.NewNullTime(since)},
+		{ID: 5, FeedID: 1, Title: "Old", CreatedAt: repository.NewTime(since.Add(-time.Hour))},
+	}
+	rules := []repository.Rule{
+		{ID: 1, Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: "golang",
+			Action: repository.RuleActionTag, Tag: repository.NewNullString("go")},
+		{ID: 2, Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: "unread",
+			Action: repository.RuleActionRead},
+	}
+	tests := []struct {
+		name string
+		mode string
+
+		// category id => entry ids
+		expectedCategories map[int64][]int64
+		expectedEntries    int
+	}{{
+		name:               "unread entries",
+		mode:               DigestModeUnread,
+		expectedCategories: map[int64][]int64{1: {1}, 2: {2}},
+		expectedEntries:    2,
+	}, {
+		name:               "starred and matched by rules",
+		mode:               DigestModeHighlights,
+		expectedCategories: map[int64][]int64{1: {3}, 2: {2, 4}},
+		expectedEntries:    3,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := WebRSSService{
+				nowFn:           time.Now,
+				logger:          log.New(ioutil.Discard, "", 0),
+				entryRepository: &entryRepositoryMock{entries: entries},
+				feedRepository: &feedRepositoryMock{feeds: []repository.Feed{
+					{ID: 1, CategoryID: 1}, {ID: 2, CategoryID: 2}, {ID: 3, CategoryID: 2},
+				}},
+				categoryRepository: &categoryRepositoryMock{categories: []repository.Category{
+					{ID: 2, Title: "Second"}, {ID: 1, Title: "First"}, {ID: 3, Title: "Empty"},
+				}},
+				ruleRepository:     &ruleRepositoryMock{listForFeedResp: rules},
+				lastSeenRepository: &lastSeenRepositoryMock{},
+			}
+			digest, err := s.BuildDigest(context.Background(), tt.mode, since)
+			if err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if digest.Entries != tt.expectedEntries {
+				t.Errorf("Expected entries to be '%d', but got '%d'", tt.expectedEntries, digest.Entries)
+			}
+			categories := map[int64][]int64{}
+			order := []int64{}
+			for _, category := range digest.Categories {
+				order = append(order, category.Category.ID)
+				for _, entry := range category.Entries {
+					categories[category.Category.ID] = append(categories[category.Category.ID], entry.ID)
+				}
+			}
+			if !reflect.DeepEqual(categories, tt.expectedCategories) {
+				t.Errorf("Expected categories to be '%v', but got '%v'", tt.expectedCategories, categories)
+			}
+			if !reflect.DeepEqual(order, []int64{2, 1}) {
+				t.Errorf("Expected categories to be in order '%v', but got '%v'", []int64{2, 1}, order)
+			}
+		})
+	}
+}
+
+func TestFeedService_BuildDigest_invalidMode(t *testing.T) {
+	s := WebRSSService{nowFn: time.Now}
+	if _, err := s.BuildDigest(context.Background(), "all", time.Now()); !errors.Is(err, ErrInvalidDigestMode) {
+		t.Errorf("Expected err to be '%v', but got '%v'", ErrInvalidDigestMode, err)
+	}
+}
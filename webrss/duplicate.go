@@ -0,0 +1,169 @@
+package webrss
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+const (
+	// duplicateWindow is how far back entries from other feeds are compared by title.
+	duplicateWindow        = 72 * time.Hour
+	maxDuplicateCandidates = 1000
+	// normalizeLinksBatch is the number of entries normalized at once by NormalizeLinks.
+	normalizeLinksBatch = 500
+	// minTitleWords is the minimal number of words in a title for it to be compared, short titles
+	// like "Weekly update" are too common to be matched.
+	minTitleWords            = 4
+	titleSimilarityThreshold = 0.8
+	maxNormalizedLinkLength  = 255
+)
+
+// trackingParams are query params that don't change what the link points to.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"ref":     true,
+	"ref_src": true,
+	"_hsenc":  true,
+	"_hsmkt":  true,
+}
+
+// normalizeLink returns link stripped of scheme, "www." prefix, fragment, trailing slash and tracking
+// params, with remaining params sorted. Empty string is returned for links that aren't absolute URLs.
+func normalizeLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	query := u.Query()
+	for key := range query {
+		if trackingParams[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	normalized := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if encoded := query.Encode(); encoded != "" {
+		normalized += "?" + encoded
+	}
+	if runes := []rune(normalized); len(runes) > maxNormalizedLinkLength {
+		normalized = string(runes[:maxNormalizedLinkLength])
+	}
+	return normalized
+}
+
+func titleWords(title string) map[string]struct{} {
+	words := map[string]struct{}{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		words[word] = struct{}{}
+	}
+	return words
+}
+
+// titleSimilarity returns Jaccard index of words in both titles.
+func titleSimilarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for word := range a {
+		if _, ok := b[word]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// canonicalID returns id of the entry that given entry's duplicates are linked to.
+func canonicalID(entry repository.Entry) int64 {
+	if entry.CanonicalID.Valid {
+		return entry.CanonicalID.Int64
+	}
+	return entry.ID
+}
+
+// duplicateFinder looks for entries from other feeds that are the same story as new entries of a feed,
+// entries to compare titles with are fetched once, on first use.
+type duplicateFinder struct {
+	entryRepository entryRepository
+	feedID          int64
+	since           time.Time
+	candidates      []repository.Entry
+	loaded          bool
+}
+
+func (f *duplicateFinder) find(ctx context.Context, entry repository.Entry) (repository.Entry, bool, error) {
+	if entry.NormalizedLink != "" {
+		duplicate, err := f.entryRepository.GetByNormalizedLink(ctx, entry.NormalizedLink, f.feedID)
+		if err == nil {
+			return duplicate, true, nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return repository.Entry{}, false, fmt.Errorf("error fetching entry by link: %w", err)
+		}
+	}
+
+	words := titleWords(entry.Title)
+	if len(words) < minTitleWords {
+		return repository.Entry{}, false, nil
+	}
+	if !f.loaded {
+		candidates, err := f.entryRepository.ListDuplicateCandidates(ctx, f.feedID, f.since, maxDuplicateCandidates)
+		if err != nil {
+			return repository.Entry{}, false, fmt.Errorf("error fetching duplicate candidates: %w", err)
+		}
+		f.candidates, f.loaded = candidates, true
+	}
+	var duplicate repository.Entry
+	bestSimilarity := 0.0
+	for _, candidate := range f.candidates {
+		if similarity := titleSimilarity(words, titleWords(candidate.Title)); similarity > bestSimilarity {
+			duplicate, bestSimilarity = candidate, similarity
+		}
+	}
+	return duplicate, bestSimilarity >= titleSimilarityThreshold, nil
+}
+
+// NormalizeLinks fills normalized links of entries created before they were introduced, so they can be
+// matched by link. Returns number of updated entries.
+func (s WebRSSService) NormalizeLinks(ctx context.Context) (int, error) {
+	var updated int
+	var lastID int64
+	for {
+		entries, err := s.entryRepository.ListWithoutNormalizedLink(ctx, lastID, normalizeLinksBatch)
+		if err != nil {
+			return updated, fmt.Errorf("error fetching entries: %w", err)
+		}
+		if len(entries) == 0 {
+			return updated, nil
+		}
+		for _, entry := range entries {
+			lastID = entry.ID
+			link := normalizeLink(entry.Link)
+			if link == "" {
+				continue
+			}
+			if err := s.entryRepository.UpdateNormalizedLink(ctx, entry.ID, link); err != nil {
+				return updated, fmt.Errorf("error updating entry: %w", err)
+			}
+			updated++
+		}
+	}
+}
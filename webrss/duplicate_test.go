@@ -0,0 +1,99 @@
+package webrss
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+func TestNormalizeLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		expected string
+	}{{
+		name:     "scheme and www are dropped",
+		link:     "https://www.Example.com/post",
+		expected: "example.com/post",
+	}, {
+		name:     "http and https are the same",
+		link:     "http://example.com/post/",
+		expected: "example.com/post",
+	}, {
+		name:     "tracking params and fragment are dropped",
+		link:     "https://example.com/post?utm_source=rss&utm_medium=feed&fbclid=abc#comments",
+		expected: "example.com/post",
+	}, {
+		name:     "remaining params are sorted",
+		link:     "https://example.com/index.php?p=12&lang=en&ref=feed",
+		expected: "example.com/index.php?lang=en&p=12",
+	}, {
+		name:     "non default port is kept",
+		link:     "http://example.com:8080/post",
+		expected: "example.com:8080/post",
+	}, {
+		name:     "relative link",
+		link:     "/post",
+		expected: "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if normalized := normalizeLink(tt.link); normalized != tt.expected {
+				t.Errorf("Expected normalized link to be '%s', but got '%s'", tt.expected, normalized)
+			}
+		})
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected float64
+	}{{
+		name:     "same titles with different case and punctuation",
+		a:        "Go 1.15 is released",
+		b:        "Go 1.15 is Released!",
+		expected: 1,
+	}, {
+		name:     "partially matching titles",
+		a:        "Go 1.15 is released",
+		b:        "Go 1.16 is released",
+		expected: 4.0 / 6.0,
+	}, {
+		name:     "empty title",
+		a:        "",
+		b:        "Go 1.15 is released",
+		expected: 0,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if similarity := titleSimilarity(titleWords(tt.a), titleWords(tt.b)); similarity != tt.expected {
+				t.Errorf("Expected similarity to be '%v', but got '%v'", tt.expected, similarity)
+			}
+		})
+	}
+}
+
+func TestFeedService_NormalizeLinks(t *testing.T) {
+	mockedEntryRepository := &entryRepositoryMock{entries: []repository.Entry{
+		{ID: 1, Link: "https://www.example.com/post/?utm_source=rss"},
+		{ID: 2, Link: "/relative"},
+		{ID: 3, Link: "https://example.com/other", NormalizedLink: "example.com/other"},
+		{ID: 4, Link: "http://example.com/post#comments"},
+	}}
+	s := WebRSSService{entryRepository: mockedEntryRepository}
+	updated, err := s.NormalizeLinks(context.Background())
+	if err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	if updated != 2 {
+		t.Errorf("Expected updated to be '%d', but got '%d'", 2, updated)
+	}
+	expected := map[int64]string{1: "example.com/post", 4: "example.com/post"}
+	if !reflect.DeepEqual(mockedEntryRepository.normalizedLinks, expected) {
+		t.Errorf("Expected normalized links to be '%v', but got '%v'", expected, mockedEntryRepository.normalizedLinks)
+	}
+}
@@ -11,65 +11,158 @@ import (
 type entryRepository interface {
 	Get(ctx context.Context, id int64) (repository.Entry, error)
 	GetByURL(ctx context.Context, url string, feedID int64) (repository.Entry, error)
-	ListForFeed(ctx context.Context, feedID, page int64, perPage int) ([]repository.Entry, error)
-	ListForPhrase(ctx context.Context, phrase string, page int64, perPage int) ([]repository.Entry, error)
+	List(ctx context.Context, filter repository.EntryFilter, page repository.PageRequest) (repository.EntryPage, error)
+	Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (repository.EntryPage, error)
+	ListSearchStats(ctx context.Context, queries []repository.SearchStatsQuery) ([]repository.SearchStats, error)
+	ListLatest(ctx context.Context, feedID, categoryID int64, limit int) ([]repository.Entry, error)
+	ListCreatedSince(ctx context.Context, since time.Time, unread bool, limit int) ([]repository.Entry, error)
+	ListByIDs(ctx context.Context, ids []int64) ([]repository.Entry, error)
+	ListStarred(ctx context.Context, page repository.PageRequest) (repository.EntryPage, error)
+	Star(ctx context.Context, id int64, starredAt time.Time) (bool, error)
+	Unstar(ctx context.Context, id int64) error
+	GetByNormalizedLink(ctx context.Context, link string, feedID int64) (repository.Entry, error)
+	ListDuplicateCandidates(ctx context.Context, feedID int64, since time.Time, limit int) ([]repository.Entry, error)
+	ListWithoutNormalizedLink(ctx context.Context, afterID int64, limit int) ([]repository.Entry, error)
+	UpdateNormalizedLink(ctx context.Context, id int64, link string) error
+	MarkRead(ctx context.Context, ids []int64, readAt time.Time) error
+	MarkUnread(ctx context.Context, ids []int64) error
+	MarkAllRead(ctx context.Context, batch string, readAt, before time.Time, feedID, categoryID int64) (int64, error)
+	UndoMarkAllRead(ctx context.Context, batch string, after time.Time) (int64, error)
 	Update(ctx context.Context, entry repository.Entry) error
-	Create(ctx context.Context, entry repository.Entry) error
+	Create(ctx context.Context, entry repository.Entry) (int64, error)
 }
 
-func (s WebRSSService) ListEntriesForFeed(ctx context.Context, feedID, page int64, perPage int) ([]repository.Entry, error) {
-	entries, err := s.entryRepository.ListForFeed(ctx, feedID, page, perPage)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching entries for feed %d: %w", feedID, err)
+// ListEntries returns entries matching filter from all feeds, newest first unless filter orders them
+// otherwise. Entries of a single feed are listed in feed's order by default.
+func (s WebRSSService) ListEntries(ctx context.Context, filter repository.EntryFilter, page repository.PageRequest) (repository.EntryPage, error) {
+	if filter.SavedSearchID != 0 {
+		return s.listSavedSearchEntries(ctx, filter, page)
 	}
-
-	feed, err := s.feedRepository.Get(ctx, feedID)
+	if filter.FeedID != 0 && filter.Order == "" {
+		feed, err := s.feedRepository.Get(ctx, filter.FeedID)
+		if err != nil {
+			return repository.EntryPage{}, fmt.Errorf("cannot fetch feed for entries: %w", err)
+		}
+		filter.Order = feed.EntryOrder
+	}
+	entries, err := s.entryRepository.List(ctx, filter, page)
 	if err != nil {
-		return nil, fmt.Errorf("cannot fetch feed for entries: %w", err)
+		return repository.EntryPage{}, fmt.Errorf("error fetching entries: %w", err)
 	}
-
-	for i, _ := range entries {
-		entries[i].Feed = feed
-		entries[i].NewEntry = entries[i].CreatedAt.After(feed.LastReadAt)
+	if err := s.attachFeeds(ctx, entries.Entries); err != nil {
+		return repository.EntryPage{}, fmt.Errorf("cannot fetch feeds for entries: %w", err)
 	}
-
-	feed.LastReadAt.Time = time.Now()
-	if err := s.feedRepository.Update(ctx, feed); err != nil {
-		return nil, fmt.Errorf("cannot fetch feed for entries: %w", err)
+	if err := s.attachUserTags(ctx, entries.Entries); err != nil {
+		return repository.EntryPage{}, fmt.Errorf("cannot fetch user tags for entries: %w", err)
 	}
-
 	return entries, nil
 }
 
-func (s WebRSSService) GetEntry(ctx context.Context, id int64) (repository.Entry, error) {
+// GetEntry returns entry, marking it as read if requested.
+func (s WebRSSService) GetEntry(ctx context.Context, id int64, markRead bool) (repository.Entry, error) {
 	entry, err := s.entryRepository.Get(ctx, id)
 	if err != nil {
 		return repository.Entry{}, fmt.Errorf("error getting entry: %w", err)
 	}
 
-	if !entry.ReadAt.Valid {
-		entry.ReadAt.Valid = true
-		entry.ReadAt.Time = time.Now()
-		if err := s.entryRepository.Update(ctx, entry); err != nil {
-			return entry, fmt.Errorf("error updating entry with 'read_at': %w", err)
+	if markRead && !entry.ReadAt.Valid {
+		now := s.nowFn()
+		if err := s.entryRepository.MarkRead(ctx, []int64{entry.ID}, now); err != nil {
+			return entry, fmt.Errorf("error marking entry as read: %w", err)
 		}
+		entry.ReadAt = repository.NewNullTime(now)
+		s.readStateChanged()
 	}
 
-	entry.Feed, err = s.feedRepository.Get(ctx, entry.FeedID)
-	if err != nil {
+	entries := []repository.Entry{entry}
+	if err := s.attachFeeds(ctx, entries); err != nil {
 		return entry, fmt.Errorf("cannot fetch feed for entry: %w", err)
 	}
+	if err := s.attachUserTags(ctx, entries); err != nil {
+		return entry, fmt.Errorf("cannot fetch user tags for entry: %w", err)
+	}
 
-	entry.NewEntry = entry.CreatedAt.After(entry.Feed.LastReadAt)
-
-	return entry, nil
+	return entries[0], nil
 }
 
-func (s WebRSSService) Search(ctx context.Context, phrase string, page int64, perPage int) ([]repository.Entry, error) {
-	entries, err := s.entryRepository.ListForPhrase(ctx, phrase, page, perPage)
+// attachFeeds sets feeds of entries coming from different feeds, including deleted ones.
+func (s WebRSSService) attachFeeds(ctx context.Context, entries []repository.Entry) error {
+	ids := []int64{}
+	seen := map[int64]bool{}
+	for _, entry := range entries {
+		if !seen[entry.FeedID] {
+			seen[entry.FeedID] = true
+			ids = append(ids, entry.FeedID)
+		}
+	}
+	feeds, err := s.feedRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("error fetching feeds: %w", err)
+	}
+	feedsSeen, err := s.getLastSeen(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching entries for phrase %s: %w", phrase, err)
+		return err
 	}
+	feedsByID := make(map[int64]repository.Feed, len(feeds))
+	for _, feed := range feeds {
+		feedsByID[feed.ID] = feed
+	}
+	for i := range entries {
+		entries[i].Feed = feedsByID[entries[i].FeedID]
+		entries[i].NewEntry = entries[i].CreatedAt.Time.After(feedsSeen.feed(entries[i].Feed))
+		entries[i].Updated = isUpdated(entries[i])
+	}
+	return nil
+}
 
+// ListStarredEntries returns starred entries from all feeds, most recently starred first.
+func (s WebRSSService) ListStarredEntries(ctx context.Context, page repository.PageRequest) (repository.EntryPage, error) {
+	entries, err := s.entryRepository.ListStarred(ctx, page)
+	if err != nil {
+		return repository.EntryPage{}, fmt.Errorf("error fetching starred entries: %w", err)
+	}
+	if err := s.attachFeeds(ctx, entries.Entries); err != nil {
+		return repository.EntryPage{}, fmt.Errorf("cannot fetch feeds for entries: %w", err)
+	}
+	if err := s.attachUserTags(ctx, entries.Entries); err != nil {
+		return repository.EntryPage{}, fmt.Errorf("cannot fetch user tags for entries: %w", err)
+	}
 	return entries, nil
 }
+
+// StarEntry stars entry, and sends it to read-later service if sending starred entries is enabled.
+func (s WebRSSService) StarEntry(ctx context.Context, id int64) error {
+	starred, err := s.entryRepository.Star(ctx, id, s.nowFn())
+	if err != nil {
+		return fmt.Errorf("error starring entry: %w", err)
+	}
+	// entry that already was starred, was already sent too
+	if starred {
+		s.sendStarred(ctx, id)
+	}
+	return nil
+}
+
+func (s WebRSSService) UnstarEntry(ctx context.Context, id int64) error {
+	if err := s.entryRepository.Unstar(ctx, id); err != nil {
+		return fmt.Errorf("error unstarring entry: %w", err)
+	}
+	return nil
+}
+
+// MarkEntriesRead marks entries as read, together with their duplicates from other feeds.
+func (s WebRSSService) MarkEntriesRead(ctx context.Context, ids []int64) error {
+	if err := s.entryRepository.MarkRead(ctx, ids, s.nowFn()); err != nil {
+		return fmt.Errorf("error marking entries as read: %w", err)
+	}
+	s.readStateChanged()
+	return nil
+}
+
+func (s WebRSSService) MarkEntriesUnread(ctx context.Context, ids []int64) error {
+	if err := s.entryRepository.MarkUnread(ctx, ids); err != nil {
+		return fmt.Errorf("error marking entries as unread: %w", err)
+	}
+	s.readStateChanged()
+	return nil
+}
@@ -0,0 +1,54 @@
+package webrss
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+func TestFeedService_ListEntries_order(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter repository.EntryFilter
+
+		expectedOrder string
+	}{{
+		name:          "feed's default order",
+		filter:        repository.EntryFilter{FeedID: 1},
+		expectedOrder: repository.EntryOrderOldest,
+	}, {
+		name:          "requested order",
+		filter:        repository.EntryFilter{FeedID: 1, Order: repository.EntryOrderNewest},
+		expectedOrder: repository.EntryOrderNewest,
+	}, {
+		name:          "entries of all feeds",
+		filter:        repository.EntryFilter{Starred: true},
+		expectedOrder: "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedEntryRepository := &entryRepositoryMock{}
+			s := WebRSSService{
+				logger:          log.New(ioutil.Discard, "", 0),
+				entryRepository: mockedEntryRepository,
+				feedRepository: &feedRepositoryMock{
+					feeds: []repository.Feed{{ID: 1, EntryOrder: repository.EntryOrderOldest}},
+				},
+				userTagRepository:  &userTagRepositoryMock{},
+				lastSeenRepository: &lastSeenRepositoryMock{},
+			}
+			if _, err := s.ListEntries(context.Background(), tt.filter, repository.PageRequest{PerPage: 10}); err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if len(mockedEntryRepository.listFilters) != 1 {
+				t.Fatalf("Expected entries to be listed once, but got '%d'", len(mockedEntryRepository.listFilters))
+			}
+			if order := mockedEntryRepository.listFilters[0].Order; order != tt.expectedOrder {
+				t.Errorf("Expected order to be '%s', but got '%s'", tt.expectedOrder, order)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package webrss
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Alkemic/webrss/events"
+	"github.com/Alkemic/webrss/repository"
+)
+
+// unreadCountsDelay is the time read state changes are collected for, before unread counts are published.
+const unreadCountsDelay = time.Second
+
+type eventPublisher interface {
+	Publish(eventType string, data interface{})
+	HasSubscribers() bool
+}
+
+// debouncer runs function once after delay, no matter how many times it was requested meanwhile.
+type debouncer struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	pending bool
+}
+
+func newDebouncer(delay time.Duration) *debouncer {
+	return &debouncer{delay: delay}
+}
+
+func (d *debouncer) run(fn func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pending {
+		return
+	}
+	d.pending = true
+	time.AfterFunc(d.delay, func() {
+		// changes made while fn runs schedule it again
+		d.mu.Lock()
+		d.pending = false
+		d.mu.Unlock()
+		fn()
+	})
+}
+
+// EntryEvent is published for every created entry that isn't hidden.
+type EntryEvent struct {
+	ID          int64           `json:"id"`
+	FeedID      int64           `json:"feed_id"`
+	Title       string          `json:"title"`
+	Link        string          `json:"link"`
+	PublishedAt repository.Time `json:"published_at"`
+}
+
+type UnreadCount struct {
+	FeedID     int64 `json:"id"`
+	CategoryID int64 `json:"category_id"`
+	UnRead     int64 `json:"un_read"`
+}
+
+// UnreadEvent holds unread counts of all feeds.
+type UnreadEvent struct {
+	Feeds []UnreadCount `json:"feeds"`
+}
+
+func (s WebRSSService) publishCreatedEntries(entries []repository.Entry) {
+	for _, entry := range entries {
+		if entry.DeletedAt.Valid {
+			continue
+		}
+		s.events.Publish(events.EntryCreated, EntryEvent{
+			ID:          entry.ID,
+			FeedID:      entry.FeedID,
+			Title:       entry.Title,
+			Link:        entry.Link,
+			PublishedAt: entry.PublishedAt,
+		})
+	}
+}
+
+// PublishUnreadCounts publishes current unread counts of all feeds.
+func (s WebRSSService) PublishUnreadCounts(ctx context.Context) error {
+	categories, err := s.categoryRepository.List(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching categories: %w", err)
+	}
+	ids := make([]int64, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	feeds, err := s.feedRepository.ListForCategories(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("error fetching feeds for categories: %w", err)
+	}
+	event := UnreadEvent{Feeds: make([]UnreadCount, 0, len(feeds))}
+	for _, feed := range feeds {
+		event.Feeds = append(event.Feeds, UnreadCount{FeedID: feed.ID, CategoryID: feed.CategoryID, UnRead: feed.UnRead})
+	}
+	s.events.Publish(events.UnreadUpdated, event)
+	return nil
+}
+
+// readStateChanged publishes unread counts after entries were marked as read or unread, failure doesn't
+// affect the change itself. Counts are published in background, once for all changes made within
+// unreadCountsDelay, and only when someone is subscribed to events.
+func (s WebRSSService) readStateChanged() {
+	if !s.events.HasSubscribers() {
+		return
+	}
+	s.unreadCounts.run(func() {
+		if err := s.PublishUnreadCounts(context.Background()); err != nil {
+			s.logger.Println("cannot publish unread counts: ", err)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package webrss
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/events"
+	"github.com/Alkemic/webrss/repository"
+)
+
+type publishedEvent struct {
+	eventType string
+	data      interface{}
+}
+
+type eventPublisherMock struct {
+	subscribers bool
+	published   []publishedEvent
+	// publishedCh receives events published, when it's set
+	publishedCh chan publishedEvent
+}
+
+func (m *eventPublisherMock) Publish(eventType string, data interface{}) {
+	event := publishedEvent{eventType: eventType, data: data}
+	m.published = append(m.published, event)
+	if m.publishedCh != nil {
+		m.publishedCh <- event
+	}
+}
+
+func (m *eventPublisherMock) HasSubscribers() bool {
+	return m.subscribers
+}
+
+func TestFeedService_publishCreatedEntries(t *testing.T) {
+	now := repository.NewTime(time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC))
+	mockedEventPublisher := &eventPublisherMock{}
+	s := WebRSSService{events: mockedEventPublisher}
+	s.publishCreatedEntries([]repository.Entry{
+		{ID: 1, FeedID: 2, Title: "Visible", Link: "https://example.com/1", PublishedAt: now},
+		{ID: 2, FeedID: 2, Title: "Hidden", Link: "https://example.com/2", DeletedAt: repository.NewNullTime(now.Time)},
+	})
+	expected := []publishedEvent{{eventType: events.EntryCreated, data: EntryEvent{
+		ID: 1, FeedID: 2, Title: "Visible", Link: "https://example.com/1", PublishedAt: now,
+	}}}
+	if !reflect.DeepEqual(mockedEventPublisher.published, expected) {
+		t.Errorf("Expected published events to be '%v', but got '%v'", expected, mockedEventPublisher.published)
+	}
+}
+
+func TestFeedService_PublishUnreadCounts(t *testing.T) {
+	mockedEventPublisher := &eventPublisherMock{}
+	s := WebRSSService{
+		categoryRepository: &categoryRepositoryMock{categories: []repository.Category{{ID: 1}, {ID: 2}}},
+		feedRepository: &feedRepositoryMock{feeds: []repository.Feed{
+			{ID: 1, CategoryID: 1, UnRead: 3}, {ID: 2, CategoryID: 2}, {ID: 3, CategoryID: 3, UnRead: 1},
+		}},
+		events: mockedEventPublisher,
+	}
+	if err := s.PublishUnreadCounts(context.Background()); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	expected := []publishedEvent{{eventType: events.UnreadUpdated, data: UnreadEvent{Feeds: []UnreadCount{
+		{FeedID: 1, CategoryID: 1, UnRead: 3}, {FeedID: 2, CategoryID: 2},
+	}}}}
+	if !reflect.DeepEqual(mockedEventPublisher.published, expected) {
+		t.Errorf("Expected published events to be '%v', but got '%v'", expected, mockedEventPublisher.published)
+	}
+}
+
+func TestFeedService_readStateChanged(t *testing.T) {
+	tests := []struct {
+		name        string
+		subscribers bool
+		changes     int
+
+		expectedPublished int
+	}{{
+		name:              "no subscribers",
+		changes:           3,
+		expectedPublished: 0,
+	}, {
+		name:              "changes published once",
+		subscribers:       true,
+		changes:           3,
+		expectedPublished: 1,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedEventPublisher := &eventPublisherMock{subscribers: tt.subscribers, publishedCh: make(chan publishedEvent, tt.changes)}
+			s := WebRSSService{
+				categoryRepository: &categoryRepositoryMock{},
+				feedRepository:     &feedRepositoryMock{},
+				events:             mockedEventPublisher,
+				unreadCounts:       newDebouncer(10 * time.Millisecond),
+			}
+			for i := 0; i < tt.changes; i++ {
+				s.readStateChanged()
+			}
+			published := 0
+			timeout := time.After(100 * time.Millisecond)
+		loop:
+			for {
+				select {
+				case <-mockedEventPublisher.publishedCh:
+					published++
+				case <-timeout:
+					break loop
+				}
+			}
+			if published != tt.expectedPublished {
+				t.Errorf("Expected published events to be '%d', but got '%d'", tt.expectedPublished, published)
+			}
+		})
+	}
+}
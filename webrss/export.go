@@ -0,0 +1,72 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+// exportEntriesLimit is the maximal number of entries exported at once.
+const exportEntriesLimit = 200
+
+var ErrInvalidExport = errors.New("invalid export")
+
+// ExportSelection selects exported entries, either by their ids, or by being starred and/or being in
+// the category.
+type ExportSelection struct {
+	IDs        []int64
+	Starred    bool
+	CategoryID int64
+}
+
+// ExportEntries returns selected entries with their feeds. Entries selected by ids are returned in
+// the given order, others newest first.
+func (s WebRSSService) ExportEntries(ctx context.Context, selection ExportSelection) ([]repository.Entry, error) {
+	var entries []repository.Entry
+	switch {
+	case len(selection.IDs) > exportEntriesLimit:
+		return nil, fmt.Errorf("%w: more than %d entries selected", ErrInvalidExport, exportEntriesLimit)
+	case len(selection.IDs) > 0:
+		found, err := s.entryRepository.ListByIDs(ctx, selection.IDs)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching entries: %w", err)
+		}
+		byID := make(map[int64]repository.Entry, len(found))
+		for _, entry := range found {
+			if !entry.DeletedAt.Valid {
+				byID[entry.ID] = entry
+			}
+		}
+		for _, id := range selection.IDs {
+			if entry, ok := byID[id]; ok {
+				entries = append(entries, entry)
+				delete(byID, id)
+			}
+		}
+	case selection.Starred && selection.CategoryID == 0:
+		// starred entries are listed like in starred listing, including ones of deleted feeds
+		page, err := s.entryRepository.ListStarred(ctx, repository.PageRequest{PerPage: exportEntriesLimit})
+		if err != nil {
+			return nil, fmt.Errorf("error fetching starred entries: %w", err)
+		}
+		entries = page.Entries
+	case selection.Starred || selection.CategoryID != 0:
+		filter := repository.EntryFilter{Starred: selection.Starred, CategoryID: selection.CategoryID}
+		page, err := s.entryRepository.List(ctx, filter, repository.PageRequest{PerPage: exportEntriesLimit})
+		if err != nil {
+			return nil, fmt.Errorf("error fetching entries: %w", err)
+		}
+		entries = page.Entries
+	default:
+		return nil, fmt.Errorf("%w: no entries selected", ErrInvalidExport)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no entries found", ErrInvalidExport)
+	}
+	if err := s.attachFeeds(ctx, entries); err != nil {
+		return nil, fmt.Errorf("cannot fetch feeds for entries: %w", err)
+	}
+	return entries, nil
+}
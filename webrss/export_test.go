@@ -0,0 +1,96 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+func TestFeedService_ExportEntries(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	entries := []repository.Entry{
+		{ID: 1, FeedID: 1, Title: "First"},
+		{ID: 2, FeedID: 1, Title: "Second"},
+		{ID: 3, FeedID: 1, Title: "Hidden", DeletedAt: repository.NewNullTime(now)},
+	}
+	tooManyIDs := make([]int64, exportEntriesLimit+1)
+	for i := range tooManyIDs {
+		tooManyIDs[i] = int64(i + 1)
+	}
+	tests := []struct {
+		name      string
+		selection ExportSelection
+		listResp  repository.EntryPage
+
+		expectedIDs     []int64
+		expectedFilters []repository.EntryFilter
+		expectedStarred bool
+		expectedErr     error
+	}{{
+		name:        "entries in the given order",
+		selection:   ExportSelection{IDs: []int64{2, 3, 1, 4}},
+		expectedIDs: []int64{2, 1},
+	}, {
+		name:            "starred entries of category",
+		selection:       ExportSelection{Starred: true, CategoryID: 2},
+		listResp:        repository.EntryPage{Entries: []repository.Entry{{ID: 2, FeedID: 1}}},
+		expectedIDs:     []int64{2},
+		expectedFilters: []repository.EntryFilter{{Starred: true, CategoryID: 2}},
+	}, {
+		name:            "starred entries",
+		selection:       ExportSelection{Starred: true},
+		listResp:        repository.EntryPage{Entries: []repository.Entry{{ID: 1, FeedID: 1}}},
+		expectedIDs:     []int64{1},
+		expectedStarred: true,
+	}, {
+		name:            "nothing found",
+		selection:       ExportSelection{Starred: true},
+		expectedStarred: true,
+		expectedErr:     ErrInvalidExport,
+	}, {
+		name:        "nothing selected",
+		expectedErr: ErrInvalidExport,
+	}, {
+		name:        "too many entries selected",
+		selection:   ExportSelection{IDs: tooManyIDs},
+		expectedErr: ErrInvalidExport,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedEntryRepository := &entryRepositoryMock{entries: entries, listResp: tt.listResp}
+			s := WebRSSService{
+				nowFn:              func() time.Time { return now },
+				entryRepository:    mockedEntryRepository,
+				feedRepository:     &feedRepositoryMock{feeds: []repository.Feed{{ID: 1, FeedTitle: "Feed"}}},
+				lastSeenRepository: &lastSeenRepositoryMock{},
+			}
+			exported, err := s.ExportEntries(context.Background(), tt.selection)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(mockedEntryRepository.listFilters, tt.expectedFilters) {
+				t.Errorf("Expected filters to be '%v', but got '%v'", tt.expectedFilters, mockedEntryRepository.listFilters)
+			}
+			if starred := len(mockedEntryRepository.listStarredPages) > 0; starred != tt.expectedStarred {
+				t.Errorf("Expected starred entries to be listed '%v', but got '%v'", tt.expectedStarred, starred)
+			}
+			if err != nil {
+				return
+			}
+			ids := []int64{}
+			for _, entry := range exported {
+				ids = append(ids, entry.ID)
+				if entry.Feed.FeedTitle != "Feed" {
+					t.Errorf("Expected entry to have feed attached, but got '%v'", entry.Feed)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("Expected entries to be '%v', but got '%v'", tt.expectedIDs, ids)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Alkemic/webrss/favicon"
+	"github.com/Alkemic/webrss/feed_fetcher"
 	"github.com/Alkemic/webrss/repository"
 )
 
@@ -22,6 +23,7 @@ func (s WebRSSService) GetFeed(ctx context.Context, id int64) (repository.Feed,
 }
 
 func (s WebRSSService) CreateFeed(ctx context.Context, feedURL string, categoryID int64) error {
+	startedAt := s.nowFn()
 	feeder, err := s.feedFetcher.Fetch(ctx, feedURL)
 	if err != nil {
 		return fmt.Errorf("error fetching feed: %w", err)
@@ -30,6 +32,7 @@ func (s WebRSSService) CreateFeed(ctx context.Context, feedURL string, categoryI
 	entries := feeder.Entries(ctx)
 	now := repository.NewTime(s.nowFn())
 	feed.CategoryID = categoryID
+	feed.EntryOrder = repository.EntryOrderNewest
 	feed.CreatedAt = now
 	feed.LastReadAt = repository.NewTime(time.Date(1900, 1, 1, 1, 1, 1, 1, time.UTC))
 
@@ -42,52 +45,156 @@ func (s WebRSSService) CreateFeed(ctx context.Context, feedURL string, categoryI
 	if err != nil {
 		return fmt.Errorf("error creating new feed: %w", err)
 	}
-	if err := s.SaveEntries(ctx, feedID, entries); err != nil {
+	created, updated, err := s.SaveEntries(ctx, feedID, entries)
+	if err != nil {
 		return fmt.Errorf("error saving entries: %w", err)
 	}
 	if err := s.transactionRepository.Commit(ctx); err != nil {
 		return fmt.Errorf("cannot commit transation when creating new feed: %w", err)
 	}
+
+	fetchLog := newFetchLog(feedID, startedAt, feeder)
+	fetchLog.Items, fetchLog.NewEntries, fetchLog.UpdatedEntries = len(entries), created, updated
+	s.finishFetch(ctx, fetchLog, startedAt, nil)
 	return nil
 }
 
+func newFetchLog(feedID int64, startedAt time.Time, feeder feed_fetcher.Feed) repository.FetchLog {
+	return repository.FetchLog{
+		FeedID:     feedID,
+		FetchedAt:  repository.NewTime(startedAt),
+		StatusCode: feeder.StatusCode(),
+		Bytes:      feeder.Size(),
+	}
+}
+
 func updateEntry(a, b repository.Entry) repository.Entry {
 	a.Author = b.Author
 	a.Summary = b.Summary
 	a.Title = b.Title
+	a.Tags = b.Tags
+	a.Enclosure = b.Enclosure
+	a.NormalizedLink = b.NormalizedLink
 	a.PublishedAt = b.PublishedAt
 	return a
 }
 
-func (s WebRSSService) SaveEntries(ctx context.Context, feedID int64, entries []repository.Entry) error {
+// SaveEntries creates new entries and updates already existing ones, returns number of created and updated entries.
+// Rules are applied only to newly created entries, which are also linked to the same entries from other feeds.
+// Previous content of updated entries is kept as their revision. Webhooks are queued and events are published
+// for created entries, entries starred by rules are sent to read-later service.
+func (s WebRSSService) SaveEntries(ctx context.Context, feedID int64, entries []repository.Entry) (int, int, error) {
+	var created, updated int
 	now := repository.NewTime(s.nowFn())
+	var matchers []ruleMatcher
+	var webhooks []repository.Webhook
+	if len(entries) > 0 {
+		rules, err := s.ruleRepository.ListForFeed(ctx, feedID)
+		if err != nil {
+			return created, updated, fmt.Errorf("error fetching rules: %w", err)
+		}
+		matchers = s.newRuleMatchers(rules)
+		if webhooks, err = s.webhookRepository.List(ctx); err != nil {
+			return created, updated, fmt.Errorf("error fetching webhooks: %w", err)
+		}
+	}
+	createdEntries := []repository.Entry{}
+	duplicates := &duplicateFinder{
+		entryRepository: s.entryRepository,
+		feedID:          feedID,
+		since:           now.Add(-duplicateWindow),
+	}
 	for _, entry := range entries {
+		entry.NormalizedLink = normalizeLink(entry.Link)
 		existingEntry, err := s.entryRepository.GetByURL(ctx, entry.Link, feedID)
 		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("error fetching entry: %w", err)
+			return created, updated, fmt.Errorf("error fetching entry: %w", err)
 		} else if errors.Is(err, sql.ErrNoRows) {
 			entry.FeedID = feedID
 			entry.CreatedAt = now
-			if err := s.entryRepository.Create(ctx, entry); err != nil {
-				return fmt.Errorf("error creating entry: %w", err)
+			tags := applyRules(matchers, &entry, now.Time)
+			duplicate, ok, err := duplicates.find(ctx, entry)
+			if err != nil {
+				return created, updated, fmt.Errorf("error looking for duplicates: %w", err)
+			}
+			if ok {
+				entry.CanonicalID = repository.NewNullInt64(canonicalID(duplicate))
+				if duplicate.ReadAt.Valid && !entry.ReadAt.Valid {
+					entry.ReadAt = repository.NewNullTime(now.Time)
+				}
+			}
+			entryID, err := s.entryRepository.Create(ctx, entry)
+			if err != nil {
+				return created, updated, fmt.Errorf("error creating entry: %w", err)
+			}
+			for _, tag := range tags {
+				if err := s.userTagRepository.TagEntry(ctx, entryID, tag); err != nil {
+					return created, updated, fmt.Errorf("error tagging entry: %w", err)
+				}
 			}
+			entry.ID = entryID
+			createdEntries = append(createdEntries, entry)
+			created++
+		} else if existingEntry.DeletedAt.Valid {
+			// entry was removed, e.g. hidden by rule, so it's neither updated nor counted
+			continue
 		} else {
+			changed := contentChanged(existingEntry, entry)
+			if changed {
+				revision := repository.EntryRevision{
+					EntryID:   existingEntry.ID,
+					Title:     existingEntry.Title,
+					Author:    existingEntry.Author,
+					Summary:   existingEntry.Summary,
+					CreatedAt: now,
+				}
+				if err := s.entryRevisionRepository.Create(ctx, revision); err != nil {
+					return created, updated, fmt.Errorf("error creating entry revision: %w", err)
+				}
+			}
 			entry = updateEntry(existingEntry, entry)
 			entry.UpdatedAt = repository.NewNullTime(s.nowFn())
+			if changed {
+				entry.ChangedAt = repository.NewNullTime(now.Time)
+			}
 			if err := s.entryRepository.Update(ctx, entry); err != nil {
-				return fmt.Errorf("error updating entry: %w", err)
+				return created, updated, fmt.Errorf("error updating entry: %w", err)
+			}
+			if changed {
+				updated++
 			}
 		}
 	}
-	return nil
+	s.publishCreatedEntries(createdEntries)
+	s.sendStarred(ctx, starredIDs(createdEntries)...)
+	if err := s.queueWebhooks(ctx, feedID, webhooks, matchers, createdEntries); err != nil {
+		return created, updated, err
+	}
+	return created, updated, nil
 }
 
+// UpdateFeed fetches feed and saves its entries, every attempt is recorded in fetch log, whichever step
+// fails.
 func (s WebRSSService) UpdateFeed(ctx context.Context, feed repository.Feed) error {
+	startedAt := s.nowFn()
 	feeder, err := s.feedFetcher.Fetch(ctx, feed.FeedUrl)
+	fetchLog := newFetchLog(feed.ID, startedAt, feeder)
 	if err != nil {
+		s.finishFetch(ctx, fetchLog, startedAt, err)
 		return fmt.Errorf("error fetching feed: %w", err)
 	}
 	entries := feeder.Entries(ctx)
+	fetchLog.Items = len(entries)
+	created, updated, err := s.saveFetchedFeed(ctx, feed, feeder, entries)
+	fetchLog.NewEntries, fetchLog.UpdatedEntries = created, updated
+	s.finishFetch(ctx, fetchLog, startedAt, err)
+	return err
+}
+
+// saveFetchedFeed saves feed along with its entries in a single transaction, returns number of created
+// and updated entries.
+func (s WebRSSService) saveFetchedFeed(ctx context.Context, feed repository.Feed, feeder feed_fetcher.Feed,
+	entries []repository.Entry) (int, int, error) {
 	log.Println("favicon url:", feeder.Feed(ctx).SiteFaviconUrl.String)
 
 	if feed.SiteFaviconUrl.String != "" {
@@ -101,21 +208,22 @@ func (s WebRSSService) UpdateFeed(ctx context.Context, feed repository.Feed) err
 	}
 
 	if err := s.transactionRepository.Begin(ctx); err != nil {
-		return fmt.Errorf("cannot start transation when creating new feed: %w", err)
+		return 0, 0, fmt.Errorf("cannot start transation when creating new feed: %w", err)
 	}
 	defer s.transactionRepository.Rollback(ctx)
 
 	feed.UpdatedAt = repository.NewNullTime(s.nowFn())
 	if err := s.feedRepository.Update(ctx, feed); err != nil {
-		return fmt.Errorf("error updating feed: %w", err)
+		return 0, 0, fmt.Errorf("error updating feed: %w", err)
 	}
-	if err := s.SaveEntries(ctx, feed.ID, entries); err != nil {
-		return fmt.Errorf("error saving entries: %w", err)
+	created, updated, err := s.SaveEntries(ctx, feed.ID, entries)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error saving entries: %w", err)
 	}
 	if err := s.transactionRepository.Commit(ctx); err != nil {
-		return fmt.Errorf("cannot commit transation when creating new feed: %w", err)
+		return 0, 0, fmt.Errorf("cannot commit transation when creating new feed: %w", err)
 	}
-	return nil
+	return created, updated, nil
 }
 
 func (s WebRSSService) DeleteFeed(ctx context.Context, feed repository.Feed) error {
@@ -12,10 +12,13 @@ import (
 	"github.com/Alkemic/webrss/repository"
 )
 
-type feedFetcherMock struct{}
+type feedFetcherMock struct {
+	feed feed_fetcher.Feed
+	err  error
+}
 
 func (m feedFetcherMock) Fetch(ctx context.Context, url string) (feed_fetcher.Feed, error) {
-	panic("implement me!")
+	return m.feed, m.err
 }
 
 type entryRepositoryMock struct {
@@ -25,40 +28,267 @@ type entryRepositoryMock struct {
 	updateErr         error
 	createEntries     []repository.Entry
 	createErr         error
+	// normalized link => entry
+	getByNormalizedLinkResp map[string]repository.Entry
+	duplicateCandidates     []repository.Entry
+	markAllReadBefore       time.Time
+	markAllReadResp         int64
+	undoMarkAllReadResp     int64
+	searchStatsQueries      [][]repository.SearchStatsQuery
+	searchStatsResp         repository.SearchStats
+	listFilters             []repository.EntryFilter
+	listStarredPages        []repository.PageRequest
+	listResp                repository.EntryPage
+	entries                 []repository.Entry
+	starredIDs              []int64
+	// entry id => normalized link
+	normalizedLinks map[int64]string
 }
 
 func (m *entryRepositoryMock) Get(ctx context.Context, id int64) (repository.Entry, error) {
-	panic("implement me!")
+	for _, entry := range m.entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return repository.Entry{}, sql.ErrNoRows
 }
 
 func (m *entryRepositoryMock) GetByURL(ctx context.Context, url string, feedID int64) (repository.Entry, error) {
 	return m.getEntryByURLResp[url], m.getEntryByURLErr[url]
 }
 
-func (m *entryRepositoryMock) ListForFeed(ctx context.Context, feedID, page int64, perPage int) ([]repository.Entry, error) {
-	panic("implement me!")
+func (m *entryRepositoryMock) List(ctx context.Context, filter repository.EntryFilter, page repository.PageRequest) (repository.EntryPage, error) {
+	m.listFilters = append(m.listFilters, filter)
+	return m.listResp, nil
 }
 
-func (m *entryRepositoryMock) ListForPhrase(ctx context.Context, phrase string, page int64, perPage int) ([]repository.Entry, error) {
+func (m *entryRepositoryMock) Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (repository.EntryPage, error) {
 	panic("implement me!")
 }
 
+func (m *entryRepositoryMock) ListSearchStats(ctx context.Context, queries []repository.SearchStatsQuery) ([]repository.SearchStats, error) {
+	m.searchStatsQueries = append(m.searchStatsQueries, queries)
+	stats := make([]repository.SearchStats, len(queries))
+	for i := range stats {
+		stats[i] = m.searchStatsResp
+	}
+	return stats, nil
+}
+
 func (m *entryRepositoryMock) Update(ctx context.Context, entry repository.Entry) error {
 	m.updateEntries = append(m.updateEntries, entry)
 	return m.updateErr
 }
 
-func (m *entryRepositoryMock) Create(ctx context.Context, entry repository.Entry) error {
+func (m *entryRepositoryMock) ListByIDs(ctx context.Context, ids []int64) ([]repository.Entry, error) {
+	entries := []repository.Entry{}
+	for _, entry := range m.entries {
+		for _, id := range ids {
+			if entry.ID == id {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (m *entryRepositoryMock) ListLatest(ctx context.Context, feedID, categoryID int64, limit int) ([]repository.Entry, error) {
+	panic("implement me!")
+}
+
+func (m *entryRepositoryMock) ListCreatedSince(ctx context.Context, since time.Time, unread bool, limit int) ([]repository.Entry, error) {
+	entries := []repository.Entry{}
+	for _, entry := range m.entries {
+		if entry.CreatedAt.Time.After(since) && (!unread || !entry.ReadAt.Valid) && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *entryRepositoryMock) GetByNormalizedLink(ctx context.Context, link string, feedID int64) (repository.Entry, error) {
+	if entry, ok := m.getByNormalizedLinkResp[link]; ok {
+		return entry, nil
+	}
+	return repository.Entry{}, sql.ErrNoRows
+}
+
+func (m *entryRepositoryMock) ListDuplicateCandidates(ctx context.Context, feedID int64, since time.Time, limit int) ([]repository.Entry, error) {
+	return m.duplicateCandidates, nil
+}
+
+func (m *entryRepositoryMock) ListWithoutNormalizedLink(ctx context.Context, afterID int64, limit int) ([]repository.Entry, error) {
+	entries := []repository.Entry{}
+	for _, entry := range m.entries {
+		if entry.ID > afterID && entry.NormalizedLink == "" && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (m *entryRepositoryMock) UpdateNormalizedLink(ctx context.Context, id int64, link string) error {
+	if m.normalizedLinks == nil {
+		m.normalizedLinks = map[int64]string{}
+	}
+	m.normalizedLinks[id] = link
+	return nil
+}
+
+func (m *entryRepositoryMock) MarkRead(ctx context.Context, ids []int64, readAt time.Time) error {
+	panic("implement me!")
+}
+
+func (m *entryRepositoryMock) MarkUnread(ctx context.Context, ids []int64) error {
+	panic("implement me!")
+}
+
+func (m *entryRepositoryMock) MarkAllRead(ctx context.Context, batch string, readAt, before time.Time, feedID, categoryID int64) (int64, error) {
+	m.markAllReadBefore = before
+	return m.markAllReadResp, nil
+}
+
+func (m *entryRepositoryMock) UndoMarkAllRead(ctx context.Context, batch string, after time.Time) (int64, error) {
+	return m.undoMarkAllReadResp, nil
+}
+
+func (m *entryRepositoryMock) ListStarred(ctx context.Context, page repository.PageRequest) (repository.EntryPage, error) {
+	m.listStarredPages = append(m.listStarredPages, page)
+	return m.listResp, nil
+}
+
+func (m *entryRepositoryMock) Star(ctx context.Context, id int64, starredAt time.Time) (bool, error) {
+	m.starredIDs = append(m.starredIDs, id)
+	for _, entry := range m.entries {
+		if entry.ID == id && entry.StarredAt.Valid {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (m *entryRepositoryMock) Unstar(ctx context.Context, id int64) error {
+	panic("implement me!")
+}
+
+func (m *entryRepositoryMock) Create(ctx context.Context, entry repository.Entry) (int64, error) {
 	m.createEntries = append(m.createEntries, entry)
-	return m.createErr
+	return int64(len(m.createEntries)), m.createErr
 }
 
-type feedRepositoryMock struct{}
+type ruleRepositoryMock struct {
+	listForFeedResp []repository.Rule
+	rules           []repository.Rule
+}
 
-func (m *feedRepositoryMock) Get(ctx context.Context, id int64) (repository.Feed, error) {
+func (m *ruleRepositoryMock) List(ctx context.Context) ([]repository.Rule, error) {
+	panic("implement me!")
+}
+
+func (m *ruleRepositoryMock) ListForFeed(ctx context.Context, feedID int64) ([]repository.Rule, error) {
+	return m.listForFeedResp, nil
+}
+
+func (m *ruleRepositoryMock) Get(ctx context.Context, id int64) (repository.Rule, error) {
+	for _, rule := range m.rules {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+	return repository.Rule{}, sql.ErrNoRows
+}
+
+func (m *ruleRepositoryMock) Create(ctx context.Context, rule repository.Rule) (int64, error) {
+	panic("implement me!")
+}
+
+func (m *ruleRepositoryMock) Update(ctx context.Context, rule repository.Rule) error {
+	panic("implement me!")
+}
+
+type userTagRepositoryMock struct {
+	// entry id => tag names
+	taggedEntries map[int64][]string
+	renamedTags   map[int64]string
+}
+
+func (m *userTagRepositoryMock) TagEntry(ctx context.Context, entryID int64, name string) error {
+	if m.taggedEntries == nil {
+		m.taggedEntries = map[int64][]string{}
+	}
+	m.taggedEntries[entryID] = append(m.taggedEntries[entryID], name)
+	return nil
+}
+
+func (m *userTagRepositoryMock) TagEntries(ctx context.Context, entryIDs []int64, name string) error {
+	for _, entryID := range entryIDs {
+		m.TagEntry(ctx, entryID, name)
+	}
+	return nil
+}
+
+func (m *userTagRepositoryMock) UntagEntries(ctx context.Context, entryIDs []int64, name string) error {
+	panic("implement me!")
+}
+
+func (m *userTagRepositoryMock) List(ctx context.Context) ([]repository.UserTag, error) {
 	panic("implement me!")
 }
 
+func (m *userTagRepositoryMock) Get(ctx context.Context, id int64) (repository.UserTag, error) {
+	panic("implement me!")
+}
+
+func (m *userTagRepositoryMock) ListForEntries(ctx context.Context, entryIDs []int64) ([]repository.EntryUserTag, error) {
+	entryUserTags := []repository.EntryUserTag{}
+	for _, entryID := range entryIDs {
+		for _, name := range m.taggedEntries[entryID] {
+			entryUserTags = append(entryUserTags, repository.EntryUserTag{EntryID: entryID, Name: name})
+		}
+	}
+	return entryUserTags, nil
+}
+
+func (m *userTagRepositoryMock) Rename(ctx context.Context, id int64, name string) error {
+	if m.renamedTags == nil {
+		m.renamedTags = map[int64]string{}
+	}
+	m.renamedTags[id] = name
+	return nil
+}
+
+func (m *userTagRepositoryMock) Delete(ctx context.Context, id int64) error {
+	panic("implement me!")
+}
+
+type entryRevisionRepositoryMock struct {
+	createdRevisions []repository.EntryRevision
+}
+
+func (m *entryRevisionRepositoryMock) Create(ctx context.Context, revision repository.EntryRevision) error {
+	m.createdRevisions = append(m.createdRevisions, revision)
+	return nil
+}
+
+func (m *entryRevisionRepositoryMock) ListForEntry(ctx context.Context, entryID int64) ([]repository.EntryRevision, error) {
+	panic("implement me!")
+}
+
+type feedRepositoryMock struct {
+	feeds        []repository.Feed
+	updatedFeeds []repository.Feed
+}
+
+func (m *feedRepositoryMock) Get(ctx context.Context, id int64) (repository.Feed, error) {
+	for _, feed := range m.feeds {
+		if feed.ID == id {
+			return feed, nil
+		}
+	}
+	return repository.Feed{}, sql.ErrNoRows
+}
+
 func (m *feedRepositoryMock) List(ctx context.Context) ([]repository.Feed, error) {
 	panic("implement me!")
 }
@@ -68,38 +298,59 @@ func (m *feedRepositoryMock) Create(ctx context.Context, feed repository.Feed) (
 }
 
 func (m *feedRepositoryMock) ListForCategories(ctx context.Context, ids []int64) ([]repository.Feed, error) {
-	panic("implement me!")
+	feeds := []repository.Feed{}
+	for _, feed := range m.feeds {
+		for _, id := range ids {
+			if feed.CategoryID == id {
+				feeds = append(feeds, feed)
+			}
+		}
+	}
+	return feeds, nil
 }
 
-func (m *feedRepositoryMock) Update(ctx context.Context, entry repository.Feed) error {
-	panic("implement me!")
+func (m *feedRepositoryMock) ListByIDs(ctx context.Context, ids []int64) ([]repository.Feed, error) {
+	feeds := []repository.Feed{}
+	for _, id := range ids {
+		if feed, err := m.Get(ctx, id); err == nil {
+			feeds = append(feeds, feed)
+		}
+	}
+	return feeds, nil
+}
+
+func (m *feedRepositoryMock) Update(ctx context.Context, feed repository.Feed) error {
+	m.updatedFeeds = append(m.updatedFeeds, feed)
+	return nil
 }
 
-type transactionRepositoryMock struct{}
+type transactionRepositoryMock struct {
+	commitErr error
+}
 
 func (m *transactionRepositoryMock) Begin(ctx context.Context) error {
-	panic("implement me!")
+	return nil
 }
 
 func (m *transactionRepositoryMock) Commit(ctx context.Context) error {
-	panic("implement me!")
+	return m.commitErr
 }
 
 func (m *transactionRepositoryMock) Rollback(ctx context.Context) error {
-	panic("implement me!")
+	return nil
 }
 
 func TestFeedService_SaveEntries(t *testing.T) {
-	type check func(error, *entryRepositoryMock, *testing.T)
+	type check func(error, *entryRepositoryMock, *userTagRepositoryMock, *entryRevisionRepositoryMock, *testing.T)
 	checks := func(cs ...check) []check { return cs }
-	hasNoError := func(err error, mock *entryRepositoryMock, t *testing.T) {
+	hasNoError := func(err error, mock *entryRepositoryMock, tagMock *userTagRepositoryMock, revisionMock *entryRevisionRepositoryMock, t *testing.T) {
 		t.Helper()
 		if err != nil {
 			t.Errorf("Expected err to be nil, but got '%v'", err)
 		}
 	}
 	hasUpdatedEntries := func(expectedResult []repository.Entry) check {
-		return func(err error, mock *entryRepositoryMock, t *testing.T) {
+		return func(err error, mock *entryRepositoryMock, tagMock *userTagRepositoryMock, revisionMock *entryRevisionRepositoryMock, t *testing.T) {
 			t.Helper()
 			if !reflect.DeepEqual(mock.updateEntries, expectedResult) {
 				t.Errorf("Expected result length to be '%d', but got '%d'", len(expectedResult), len(mock.updateEntries))
@@ -108,7 +359,7 @@ func TestFeedService_SaveEntries(t *testing.T) {
 		}
 	}
 	hasCreatedEntries := func(expectedResult []repository.Entry) check {
-		return func(err error, mock *entryRepositoryMock, t *testing.T) {
+		return func(err error, mock *entryRepositoryMock, tagMock *userTagRepositoryMock, revisionMock *entryRevisionRepositoryMock, t *testing.T) {
 			t.Helper()
 			if !reflect.DeepEqual(mock.createEntries, expectedResult) {
 				t.Errorf("Expected result length to be '%d', but got '%d'", len(expectedResult), len(mock.createEntries))
@@ -117,7 +368,7 @@ func TestFeedService_SaveEntries(t *testing.T) {
 		}
 	}
 	hasError := func(expectedErr error) check {
-		return func(err error, mock *entryRepositoryMock, t *testing.T) {
+		return func(err error, mock *entryRepositoryMock, tagMock *userTagRepositoryMock, revisionMock *entryRevisionRepositoryMock, t *testing.T) {
 			t.Helper()
 			if !errors.Is(err, expectedErr) {
 				t.Errorf("Expected error to be '%v', but got '%v'", expectedErr, err)
@@ -125,24 +376,45 @@ func TestFeedService_SaveEntries(t *testing.T) {
 		}
 	}
 	hasErrorMsg := func(expectedErr string) check {
-		return func(err error, mock *entryRepositoryMock, t *testing.T) {
+		return func(err error, mock *entryRepositoryMock, tagMock *userTagRepositoryMock, revisionMock *entryRevisionRepositoryMock, t *testing.T) {
 			t.Helper()
 			if err.Error() != expectedErr {
 				t.Errorf("Expected error to be '%v', but got '%v'", expectedErr, err)
 			}
 		}
 	}
+	hasTaggedEntries := func(expectedTags map[int64][]string) check {
+		return func(err error, mock *entryRepositoryMock, tagMock *userTagRepositoryMock, revisionMock *entryRevisionRepositoryMock, t *testing.T) {
+			t.Helper()
+			if !reflect.DeepEqual(tagMock.taggedEntries, expectedTags) {
+				t.Errorf("Expected tagged entries to be '%+v', but got '%+v'", expectedTags, tagMock.taggedEntries)
+			}
+		}
+	}
+	hasCreatedRevisions := func(expectedRevisions []repository.EntryRevision) check {
+		return func(err error, mock *entryRepositoryMock, tagMock *userTagRepositoryMock, revisionMock *entryRevisionRepositoryMock, t *testing.T) {
+			t.Helper()
+			if !reflect.DeepEqual(revisionMock.createdRevisions, expectedRevisions) {
+				t.Errorf("Expected created revisions to be '%+v', but got '%+v'", expectedRevisions, revisionMock.createdRevisions)
+			}
+		}
+	}
 	mockedErr := errors.New("mocked error")
+	now := time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)
 	tests := []struct {
 		name    string
 		feedID  int64
 		entries []repository.Entry
+		rules   []repository.Rule
 		// url => entry
 		getEntryByURLResp map[string]repository.Entry
 		getEntryByURLErr  map[string]error
 		updateErr         error
 		createErr         error
-		ctx               context.Context
+		// normalized link => entry
+		getByNormalizedLinkResp map[string]repository.Entry
+		duplicateCandidates     []repository.Entry
+		ctx                     context.Context
 
 		checks []check
 	}{{
@@ -169,6 +441,89 @@ func TestFeedService_SaveEntries(t *testing.T) {
 				FeedID:    12,
 			}}),
 		),
+	}, {
+		name:             "apply rules to new entries",
+		feedID:           12,
+		getEntryByURLErr: map[string]error{"link1": sql.ErrNoRows, "link2": sql.ErrNoRows},
+		rules: []repository.Rule{{
+			Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: "golang, rust",
+			Action: repository.RuleActionStar,
+		}, {
+			Field: repository.RuleFieldAuthor, MatchType: repository.RuleMatchRegex, Pattern: "^spam",
+			Action: repository.RuleActionHide,
+		}, {
+			Field: repository.RuleFieldContent, MatchType: repository.RuleMatchKeyword, Pattern: "release",
+			Action: repository.RuleActionTag, Tag: repository.NewNullString("releases"),
+		}},
+		entries: []repository.Entry{{
+			Title:   "New GoLang release",
+			Summary: repository.NewNullString("release notes"),
+			Link:    "link1",
+		}, {
+			Title:  "title 2",
+			Author: repository.NewNullString("spammer"),
+			Link:   "link2",
+		}},
+		checks: checks(
+			hasNoError,
+			hasCreatedEntries([]repository.Entry{{
+				Title:     "New GoLang release",
+				Summary:   repository.NewNullString("release notes"),
+				Link:      "link1",
+				StarredAt: repository.NewNullTime(now),
+				CreatedAt: repository.NewTime(now),
+				FeedID:    12,
+			}, {
+				Title:     "title 2",
+				Author:    repository.NewNullString("spammer"),
+				Link:      "link2",
+				DeletedAt: repository.NewNullTime(now),
+				CreatedAt: repository.NewTime(now),
+				FeedID:    12,
+			}}),
+			hasTaggedEntries(map[int64][]string{1: {"releases"}}),
+		),
+	}, {
+		name:             "link new entries to duplicates from other feeds",
+		feedID:           12,
+		getEntryByURLErr: map[string]error{"https://www.example.com/post/?utm_source=rss": sql.ErrNoRows, "link2": sql.ErrNoRows},
+		getByNormalizedLinkResp: map[string]repository.Entry{"example.com/post": {
+			ID:          5,
+			CanonicalID: repository.NewNullInt64(3),
+			ReadAt:      repository.NewNullTime(time.Date(2016, 3, 18, 7, 56, 35, 0, time.UTC)),
+		}},
+		duplicateCandidates: []repository.Entry{{
+			ID:    6,
+			Title: "Go 1.6 is released",
+		}, {
+			ID:    7,
+			Title: "Go 1.6 is released, with HTTP/2 support!",
+		}},
+		entries: []repository.Entry{{
+			Title: "post",
+			Link:  "https://www.example.com/post/?utm_source=rss",
+		}, {
+			Title: "Go 1.6 is released with HTTP/2 support",
+			Link:  "link2",
+		}},
+		checks: checks(
+			hasNoError,
+			hasCreatedEntries([]repository.Entry{{
+				Title:          "post",
+				Link:           "https://www.example.com/post/?utm_source=rss",
+				NormalizedLink: "example.com/post",
+				CanonicalID:    repository.NewNullInt64(3),
+				ReadAt:         repository.NewNullTime(now),
+				CreatedAt:      repository.NewTime(now),
+				FeedID:         12,
+			}, {
+				Title:       "Go 1.6 is released with HTTP/2 support",
+				Link:        "link2",
+				CanonicalID: repository.NewNullInt64(7),
+				CreatedAt:   repository.NewTime(now),
+				FeedID:      12,
+			}}),
+		),
 	}, {
 		name:   "update entries",
 		feedID: 13,
@@ -195,8 +550,15 @@ func TestFeedService_SaveEntries(t *testing.T) {
 				Link:      "link1",
 				CreatedAt: repository.NewTime(time.Date(2014, 3, 19, 7, 56, 35, 0, time.UTC)),
 				UpdatedAt: repository.NewNullTime(time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)),
+				ChangedAt: repository.NewNullTime(time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)),
 				FeedID:    12,
 			}}),
+			hasCreatedRevisions([]repository.EntryRevision{{
+				Title:     "title 1",
+				Author:    repository.NewNullString("author 1"),
+				Summary:   repository.NewNullString("summary 1"),
+				CreatedAt: repository.NewTime(time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)),
+			}}),
 		),
 	}, {
 		name:             "update and create entry",
@@ -230,8 +592,15 @@ func TestFeedService_SaveEntries(t *testing.T) {
 				Link:      "link1",
 				CreatedAt: repository.NewTime(time.Date(2014, 3, 19, 7, 56, 35, 0, time.UTC)),
 				UpdatedAt: repository.NewNullTime(time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)),
+				ChangedAt: repository.NewNullTime(time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)),
 				FeedID:    12,
 			}}),
+			hasCreatedRevisions([]repository.EntryRevision{{
+				Title:     "title 1",
+				Author:    repository.NewNullString("author 1"),
+				Summary:   repository.NewNullString("summary 1"),
+				CreatedAt: repository.NewTime(time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)),
+			}}),
 			hasCreatedEntries([]repository.Entry{{
 				Title:     "title 2",
 				Author:    repository.NewNullString("author 2"),
@@ -241,6 +610,25 @@ func TestFeedService_SaveEntries(t *testing.T) {
 				FeedID:    13,
 			}}),
 		),
+	}, {
+		name:   "skip removed entries",
+		feedID: 13,
+		getEntryByURLResp: map[string]repository.Entry{"link1": {
+			Title:     "title 1",
+			Link:      "link1",
+			CreatedAt: repository.NewTime(time.Date(2014, 3, 19, 7, 56, 35, 0, time.UTC)),
+			DeletedAt: repository.NewNullTime(time.Date(2014, 3, 19, 7, 56, 35, 0, time.UTC)),
+			FeedID:    13,
+		}},
+		entries: []repository.Entry{{
+			Title: "new title",
+			Link:  "link1",
+		}},
+		checks: checks(
+			hasNoError,
+			hasUpdatedEntries(nil),
+			hasCreatedRevisions(nil),
+		),
 	}, {
 		name:             "error selecting entry",
 		feedID:           13,
@@ -300,19 +688,30 @@ func TestFeedService_SaveEntries(t *testing.T) {
 				getEntryByURLErr:  tt.getEntryByURLErr,
 				updateErr:         tt.updateErr,
 				createErr:         tt.createErr,
+
+				getByNormalizedLinkResp: tt.getByNormalizedLinkResp,
+				duplicateCandidates:     tt.duplicateCandidates,
 			}
 			mockedFeedRepository := &feedRepositoryMock{}
+			mockedUserTagRepository := &userTagRepositoryMock{}
+			mockedEntryRevisionRepository := &entryRevisionRepositoryMock{}
 			s := WebRSSService{
 				nowFn: func() time.Time {
-					return time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)
+					return now
 				},
-				feedRepository:  mockedFeedRepository,
-				entryRepository: mockedEntryRepository,
-				feedFetcher:     feedFetcherMock{},
+				feedRepository:          mockedFeedRepository,
+				entryRepository:         mockedEntryRepository,
+				entryRevisionRepository: mockedEntryRevisionRepository,
+				ruleRepository:          &ruleRepositoryMock{listForFeedResp: tt.rules},
+				userTagRepository:       mockedUserTagRepository,
+				webhookRepository:       &webhookRepositoryMock{},
+				events:                  &eventPublisherMock{},
+				readLater:               &readLaterServiceMock{},
+				feedFetcher:             feedFetcherMock{},
 			}
-			err := s.SaveEntries(tt.ctx, tt.feedID, tt.entries)
+			_, _, err := s.SaveEntries(tt.ctx, tt.feedID, tt.entries)
 			for _, ch := range tt.checks {
-				ch(err, mockedEntryRepository, t)
+				ch(err, mockedEntryRepository, mockedUserTagRepository, mockedEntryRevisionRepository, t)
 			}
 		})
 	}
@@ -0,0 +1,44 @@
+package webrss
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+// maxFetchLogsPerFeed is the number of fetch logs kept for every feed, older ones are rotated out.
+const maxFetchLogsPerFeed = 200
+
+type fetchLogRepository interface {
+	Create(ctx context.Context, fetchLog repository.FetchLog) error
+	ListForFeed(ctx context.Context, feedID int64, limit int) ([]repository.FetchLog, error)
+	DeleteOld(ctx context.Context, feedID int64, keep int) error
+}
+
+func (s WebRSSService) RecordFetch(ctx context.Context, fetchLog repository.FetchLog) error {
+	if err := s.fetchLogRepository.Create(ctx, fetchLog); err != nil {
+		return fmt.Errorf("error creating fetch log: %w", err)
+	}
+	if err := s.fetchLogRepository.DeleteOld(ctx, fetchLog.FeedID, maxFetchLogsPerFeed); err != nil {
+		return fmt.Errorf("error rotating fetch logs: %w", err)
+	}
+	return nil
+}
+
+// finishFetch records fetch attempt started at given time, with error it failed with, if any.
+func (s WebRSSService) finishFetch(ctx context.Context, fetchLog repository.FetchLog, startedAt time.Time, err error) {
+	fetchLog.Finish(s.nowFn().Sub(startedAt), err)
+	if err := s.RecordFetch(ctx, fetchLog); err != nil {
+		s.logger.Println("cannot record fetch: ", err)
+	}
+}
+
+func (s WebRSSService) ListFetches(ctx context.Context, feedID int64, limit int) ([]repository.FetchLog, error) {
+	fetchLogs, err := s.fetchLogRepository.ListForFeed(ctx, feedID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching fetch logs for feed %d: %w", feedID, err)
+	}
+	return fetchLogs, nil
+}
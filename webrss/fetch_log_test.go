@@ -0,0 +1,156 @@
+package webrss
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+
+	"github.com/Alkemic/webrss/feed_fetcher"
+	"github.com/Alkemic/webrss/repository"
+)
+
+type fetchLogRepositoryMock struct {
+	fetchLogs []repository.FetchLog
+}
+
+func (m *fetchLogRepositoryMock) Create(ctx context.Context, fetchLog repository.FetchLog) error {
+	fetchLog.ID = int64(len(m.fetchLogs) + 1)
+	m.fetchLogs = append(m.fetchLogs, fetchLog)
+	return nil
+}
+
+func (m *fetchLogRepositoryMock) ListForFeed(ctx context.Context, feedID int64, limit int) ([]repository.FetchLog, error) {
+	panic("implement me!")
+}
+
+func (m *fetchLogRepositoryMock) DeleteOld(ctx context.Context, feedID int64, keep int) error {
+	fetchLogs := []repository.FetchLog{}
+	kept := 0
+	for i := len(m.fetchLogs) - 1; i >= 0; i-- {
+		if m.fetchLogs[i].FeedID == feedID {
+			if kept == keep {
+				continue
+			}
+			kept++
+		}
+		fetchLogs = append([]repository.FetchLog{m.fetchLogs[i]}, fetchLogs...)
+	}
+	m.fetchLogs = fetchLogs
+	return nil
+}
+
+func TestFeedService_RecordFetch(t *testing.T) {
+	mockedFetchLogRepository := &fetchLogRepositoryMock{
+		fetchLogs: []repository.FetchLog{{ID: 1, FeedID: 2}},
+	}
+	s := WebRSSService{fetchLogRepository: mockedFetchLogRepository}
+	for i := 0; i < maxFetchLogsPerFeed+5; i++ {
+		if err := s.RecordFetch(context.Background(), repository.FetchLog{FeedID: 1, Items: i}); err != nil {
+			t.Fatalf("Expected err to be nil, but got '%v'", err)
+		}
+	}
+	counts := map[int64]int{}
+	for _, fetchLog := range mockedFetchLogRepository.fetchLogs {
+		counts[fetchLog.FeedID]++
+	}
+	expectedCounts := map[int64]int{1: maxFetchLogsPerFeed, 2: 1}
+	if !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("Expected fetch logs per feed to be '%v', but got '%v'", expectedCounts, counts)
+	}
+	last := mockedFetchLogRepository.fetchLogs[len(mockedFetchLogRepository.fetchLogs)-1]
+	if last.Items != maxFetchLogsPerFeed+4 {
+		t.Errorf("Expected the newest fetch log to be kept, but got '%+v'", last)
+	}
+	if first := mockedFetchLogRepository.fetchLogs[1]; first.Items != 5 {
+		t.Errorf("Expected the oldest fetch logs to be rotated out, but got '%+v'", first)
+	}
+}
+
+func TestFeedService_UpdateFeed_recordsFetch(t *testing.T) {
+	startedAt := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	mockedErr := errors.New("mocked error")
+	feeder := feed_fetcher.New(&gofeed.Feed{Items: []*gofeed.Item{
+		{Title: "title 1", Link: "link1"},
+		{Title: "title 2", Link: "link2"},
+	}}, nil, "https://example.com/feed")
+	tests := []struct {
+		name         string
+		fetchErr     error
+		getByURLResp map[string]repository.Entry
+		getByURLErr  error
+		commitErr    error
+
+		expectedFetchLog repository.FetchLog
+	}{{
+		name:        "entries saved",
+		getByURLErr: sql.ErrNoRows,
+		expectedFetchLog: repository.FetchLog{FeedID: 1, FetchedAt: repository.NewTime(startedAt), DurationMs: 1500,
+			Items: 2, NewEntries: 2},
+	}, {
+		name: "only changed entries counted as updated",
+		getByURLResp: map[string]repository.Entry{
+			"link1": {ID: 1, Title: "title 1", Link: "link1"},
+			"link2": {ID: 2, Title: "old title 2", Link: "link2"},
+		},
+		expectedFetchLog: repository.FetchLog{FeedID: 1, FetchedAt: repository.NewTime(startedAt), DurationMs: 1500,
+			Items: 2, UpdatedEntries: 1},
+	}, {
+		name:     "fetch failed",
+		fetchErr: mockedErr,
+		expectedFetchLog: repository.FetchLog{FeedID: 1, FetchedAt: repository.NewTime(startedAt), DurationMs: 1500,
+			Error: repository.NewNullString("mocked error")},
+	}, {
+		name:        "saving entries failed",
+		getByURLErr: mockedErr,
+		expectedFetchLog: repository.FetchLog{FeedID: 1, FetchedAt: repository.NewTime(startedAt), DurationMs: 1500,
+			Items: 2, Error: repository.NewNullString("error saving entries: error fetching entry: mocked error")},
+	}, {
+		name:      "commit failed",
+		commitErr: mockedErr,
+		expectedFetchLog: repository.FetchLog{FeedID: 1, FetchedAt: repository.NewTime(startedAt), DurationMs: 1500,
+			Items: 2, Error: repository.NewNullString("cannot commit transation when creating new feed: mocked error")},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := startedAt
+			mockedFetchLogRepository := &fetchLogRepositoryMock{}
+			s := WebRSSService{
+				// fetch starts at the first call, and takes 1.5s
+				nowFn: func() time.Time {
+					defer func() { now = startedAt.Add(1500 * time.Millisecond) }()
+					return now
+				},
+				logger: log.New(ioutil.Discard, "", 0),
+				entryRepository: &entryRepositoryMock{
+					getEntryByURLResp: tt.getByURLResp,
+					getEntryByURLErr:  map[string]error{"link1": tt.getByURLErr, "link2": tt.getByURLErr},
+				},
+				feedRepository:          &feedRepositoryMock{},
+				entryRevisionRepository: &entryRevisionRepositoryMock{},
+				transactionRepository:   &transactionRepositoryMock{commitErr: tt.commitErr},
+				fetchLogRepository:      mockedFetchLogRepository,
+				ruleRepository:          &ruleRepositoryMock{},
+				userTagRepository:       &userTagRepositoryMock{},
+				webhookRepository:       &webhookRepositoryMock{},
+				events:                  &eventPublisherMock{},
+				feedFetcher:             feedFetcherMock{feed: feeder, err: tt.fetchErr},
+			}
+			s.UpdateFeed(context.Background(), repository.Feed{ID: 1, FeedUrl: "https://example.com/feed"})
+			if len(mockedFetchLogRepository.fetchLogs) != 1 {
+				t.Fatalf("Expected fetch to be recorded once, but got '%d'", len(mockedFetchLogRepository.fetchLogs))
+			}
+			fetchLog := mockedFetchLogRepository.fetchLogs[0]
+			fetchLog.ID = 0
+			if !reflect.DeepEqual(fetchLog, tt.expectedFetchLog) {
+				t.Errorf("Expected fetch log to be '%+v', but got '%+v'", tt.expectedFetchLog, fetchLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package webrss
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Alkemic/webrss/account"
+	"github.com/Alkemic/webrss/repository"
+)
+
+type lastSeenRepository interface {
+	List(ctx context.Context, device string) ([]repository.LastSeen, error)
+	ListLatest(ctx context.Context) ([]repository.LastSeen, error)
+	SeeFeed(ctx context.Context, device string, feedID int64, seenAt time.Time) error
+	SeeSavedSearch(ctx context.Context, device string, savedSearchID int64, seenAt time.Time) error
+}
+
+// lastSeen holds times feeds and saved searches were last seen on the device, or on any device for those
+// that weren't seen on this one.
+type lastSeen struct {
+	feeds         map[int64]time.Time
+	savedSearches map[int64]time.Time
+}
+
+// feed returns time feed was last seen on the device, or the latest time it was seen on other devices when
+// it wasn't seen on this one. Feed's last read time is used when it wasn't seen on any device.
+func (l lastSeen) feed(feed repository.Feed) time.Time {
+	if seenAt, ok := l.feeds[feed.ID]; ok {
+		return seenAt
+	}
+	return feed.LastReadAt.Time
+}
+
+// savedSearch returns time saved search was last seen, like feed.
+func (l lastSeen) savedSearch(savedSearch repository.SavedSearch) time.Time {
+	if seenAt, ok := l.savedSearches[savedSearch.ID]; ok {
+		return seenAt
+	}
+	return savedSearch.LastReadAt.Time
+}
+
+// getLastSeen returns what was seen on the device the request was made from, falling back to what was
+// seen on any device.
+func (s WebRSSService) getLastSeen(ctx context.Context) (lastSeen, error) {
+	seen := lastSeen{feeds: map[int64]time.Time{}, savedSearches: map[int64]time.Time{}}
+	latest, err := s.lastSeenRepository.ListLatest(ctx)
+	if err != nil {
+		return lastSeen{}, fmt.Errorf("error fetching latest last seen: %w", err)
+	}
+	seen.add(latest)
+	device := account.GetDevice(ctx)
+	if device == "" {
+		return seen, nil
+	}
+	records, err := s.lastSeenRepository.List(ctx, device)
+	if err != nil {
+		return lastSeen{}, fmt.Errorf("error fetching last seen: %w", err)
+	}
+	seen.add(records)
+	return seen, nil
+}
+
+func (l lastSeen) add(records []repository.LastSeen) {
+	for _, record := range records {
+		if record.FeedID.Valid {
+			l.feeds[record.FeedID.Int64] = record.SeenAt.Time
+		}
+		if record.SavedSearchID.Valid {
+			l.savedSearches[record.SavedSearchID.Int64] = record.SeenAt.Time
+		}
+	}
+}
+
+// seenAt returns given time, bounded by current time, or current time when it's not given.
+func (s WebRSSService) seenAt(seenAt time.Time) time.Time {
+	now := s.nowFn()
+	if seenAt.IsZero() || seenAt.After(now) {
+		return now
+	}
+	return seenAt
+}
+
+// MarkFeedSeen marks entries of the feed created until seenAt as no longer new on the device.
+func (s WebRSSService) MarkFeedSeen(ctx context.Context, feedID int64, seenAt time.Time) error {
+	if _, err := s.feedRepository.Get(ctx, feedID); err != nil {
+		return fmt.Errorf("error fetching feed: %w", err)
+	}
+	if err := s.lastSeenRepository.SeeFeed(ctx, account.GetDevice(ctx), feedID, s.seenAt(seenAt)); err != nil {
+		return fmt.Errorf("error marking feed as seen: %w", err)
+	}
+	return nil
+}
+
+// MarkSavedSearchSeen marks entries matching saved search created until seenAt as no longer new on the device.
+func (s WebRSSService) MarkSavedSearchSeen(ctx context.Context, savedSearchID int64, seenAt time.Time) error {
+	if _, err := s.savedSearchRepository.Get(ctx, savedSearchID); err != nil {
+		return fmt.Errorf("error fetching saved search: %w", err)
+	}
+	if err := s.lastSeenRepository.SeeSavedSearch(ctx, account.GetDevice(ctx), savedSearchID, s.seenAt(seenAt)); err != nil {
+		return fmt.Errorf("error marking saved search as seen: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package webrss
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/account"
+	"github.com/Alkemic/webrss/repository"
+)
+
+type lastSeenRepositoryMock struct {
+	// device => last seen records
+	lastSeen  map[string][]repository.LastSeen
+	seenFeeds map[int64]time.Time
+}
+
+func (m *lastSeenRepositoryMock) List(ctx context.Context, device string) ([]repository.LastSeen, error) {
+	return m.lastSeen[device], nil
+}
+
+func (m *lastSeenRepositoryMock) ListLatest(ctx context.Context) ([]repository.LastSeen, error) {
+	latest := map[int64]repository.LastSeen{}
+	for _, records := range m.lastSeen {
+		for _, record := range records {
+			if seen, ok := latest[record.FeedID.Int64]; !ok || record.SeenAt.Time.After(seen.SeenAt.Time) {
+				latest[record.FeedID.Int64] = record
+			}
+		}
+	}
+	records := []repository.LastSeen{}
+	for _, record := range latest {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (m *lastSeenRepositoryMock) SeeFeed(ctx context.Context, device string, feedID int64, seenAt time.Time) error {
+	if m.seenFeeds == nil {
+		m.seenFeeds = map[int64]time.Time{}
+	}
+	m.seenFeeds[feedID] = seenAt
+	return nil
+}
+
+func (m *lastSeenRepositoryMock) SeeSavedSearch(ctx context.Context, device string, savedSearchID int64, seenAt time.Time) error {
+	panic("implement me!")
+}
+
+func deviceContext(device string) context.Context {
+	req := httptest.NewRequest("GET", "/", nil)
+	account.SetDevice(req, device)
+	return req.Context()
+}
+
+func TestFeedService_attachFeeds_newEntries(t *testing.T) {
+	lastReadAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	laptopSeenAt := time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC)
+	phoneSeenAt := time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)
+	seen := map[string][]repository.LastSeen{
+		"laptop": {{FeedID: repository.NewNullInt64(1), SeenAt: repository.NewTime(laptopSeenAt)}},
+		"phone":  {{FeedID: repository.NewNullInt64(1), SeenAt: repository.NewTime(phoneSeenAt)}},
+	}
+	tests := []struct {
+		name     string
+		device   string
+		lastSeen map[string][]repository.LastSeen
+
+		expectedNewEntries []bool
+	}{{
+		name:               "device that has seen the feed",
+		device:             "laptop",
+		lastSeen:           seen,
+		expectedNewEntries: []bool{false, true},
+	}, {
+		name:               "device that hasn't seen the feed",
+		device:             "tablet",
+		lastSeen:           seen,
+		expectedNewEntries: []bool{false, false},
+	}, {
+		name:               "unknown device",
+		lastSeen:           seen,
+		expectedNewEntries: []bool{false, false},
+	}, {
+		name:               "feed not seen on any device",
+		device:             "laptop",
+		expectedNewEntries: []bool{true, true},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := WebRSSService{
+				logger: log.New(ioutil.Discard, "", 0),
+				feedRepository: &feedRepositoryMock{
+					feeds: []repository.Feed{{ID: 1, LastReadAt: repository.NewTime(lastReadAt)}},
+				},
+				lastSeenRepository: &lastSeenRepositoryMock{lastSeen: tt.lastSeen},
+			}
+			entries := []repository.Entry{
+				{ID: 1, FeedID: 1, CreatedAt: repository.NewTime(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC))},
+				{ID: 2, FeedID: 1, CreatedAt: repository.NewTime(time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC))},
+			}
+			if err := s.attachFeeds(deviceContext(tt.device), entries); err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			for i, entry := range entries {
+				if entry.NewEntry != tt.expectedNewEntries[i] {
+					t.Errorf("Expected entry %d to be new '%v', but got '%v'", entry.ID, tt.expectedNewEntries[i], entry.NewEntry)
+				}
+			}
+		})
+	}
+}
+
+func TestFeedService_MarkFeedSeen(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		seenAt time.Time
+
+		expectedSeenAt time.Time
+	}{{
+		name:           "given time",
+		seenAt:         time.Date(2020, 1, 10, 11, 0, 0, 0, time.UTC),
+		expectedSeenAt: time.Date(2020, 1, 10, 11, 0, 0, 0, time.UTC),
+	}, {
+		name:           "missing time",
+		expectedSeenAt: now,
+	}, {
+		name:           "time in the future",
+		seenAt:         time.Date(2020, 1, 11, 0, 0, 0, 0, time.UTC),
+		expectedSeenAt: now,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedLastSeenRepository := &lastSeenRepositoryMock{}
+			s := WebRSSService{
+				nowFn:              func() time.Time { return now },
+				logger:             log.New(ioutil.Discard, "", 0),
+				feedRepository:     &feedRepositoryMock{feeds: []repository.Feed{{ID: 1}}},
+				lastSeenRepository: mockedLastSeenRepository,
+			}
+			if err := s.MarkFeedSeen(deviceContext("laptop"), 1, tt.seenAt); err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if seenAt := mockedLastSeenRepository.seenFeeds[1]; !seenAt.Equal(tt.expectedSeenAt) {
+				t.Errorf("Expected seen at to be '%v', but got '%v'", tt.expectedSeenAt, seenAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// markReadUndoWindow is how long marking entries as read in bulk can be undone.
+const markReadUndoWindow = 5 * time.Minute
+
+var ErrUndoExpired = errors.New("nothing to undo")
+
+type MarkReadResult struct {
+	// UndoToken identifies marked entries when undoing.
+	UndoToken string    `json:"undo_token"`
+	UndoUntil time.Time `json:"undo_until"`
+	Marked    int64     `json:"marked"`
+}
+
+// MarkAllRead marks as read all unread entries created before given time, optionally limited to given feed
+// or category. Zero before means now.
+func (s WebRSSService) MarkAllRead(ctx context.Context, feedID, categoryID int64, before time.Time) (MarkReadResult, error) {
+	now := s.nowFn()
+	if before.IsZero() || before.After(now) {
+		before = now
+	}
+	token := uuid.New().String()
+	marked, err := s.entryRepository.MarkAllRead(ctx, token, now, before, feedID, categoryID)
+	if err != nil {
+		return MarkReadResult{}, fmt.Errorf("error marking all entries as read: %w", err)
+	}
+	if marked > 0 {
+		s.readStateChanged()
+	}
+	return MarkReadResult{
+		UndoToken: token,
+		UndoUntil: now.Add(markReadUndoWindow),
+		Marked:    marked,
+	}, nil
+}
+
+// UndoMarkAllRead marks as unread entries marked by MarkAllRead, unless undo window has passed.
+func (s WebRSSService) UndoMarkAllRead(ctx context.Context, token string) error {
+	restored, err := s.entryRepository.UndoMarkAllRead(ctx, token, s.nowFn().Add(-markReadUndoWindow))
+	if err != nil {
+		return fmt.Errorf("error undoing marking entries as read: %w", err)
+	}
+	if restored == 0 {
+		return ErrUndoExpired
+	}
+	s.readStateChanged()
+	return nil
+}
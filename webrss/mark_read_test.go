@@ -0,0 +1,93 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/events"
+)
+
+func TestFeedService_MarkAllRead(t *testing.T) {
+	now := time.Date(2016, 3, 19, 7, 56, 35, 0, time.UTC)
+	tests := []struct {
+		name   string
+		before time.Time
+
+		expectedBefore time.Time
+	}{{
+		name:           "zero before means now",
+		expectedBefore: now,
+	}, {
+		name:           "before in the past",
+		before:         now.Add(-time.Hour),
+		expectedBefore: now.Add(-time.Hour),
+	}, {
+		name:           "before in the future is limited to now",
+		before:         now.Add(time.Hour),
+		expectedBefore: now,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedEntryRepository := &entryRepositoryMock{markAllReadResp: 3}
+			mockedEventPublisher := &eventPublisherMock{subscribers: true, publishedCh: make(chan publishedEvent, 1)}
+			s := WebRSSService{
+				nowFn:              func() time.Time { return now },
+				entryRepository:    mockedEntryRepository,
+				categoryRepository: &categoryRepositoryMock{},
+				feedRepository:     &feedRepositoryMock{},
+				events:             mockedEventPublisher,
+				unreadCounts:       newDebouncer(0),
+			}
+			result, err := s.MarkAllRead(context.Background(), 1, 0, tt.before)
+			if err != nil {
+				t.Errorf("Expected err to be nil, but got '%v'", err)
+			}
+			if !mockedEntryRepository.markAllReadBefore.Equal(tt.expectedBefore) {
+				t.Errorf("Expected before to be '%v', but got '%v'", tt.expectedBefore, mockedEntryRepository.markAllReadBefore)
+			}
+			if result.Marked != 3 || result.UndoToken == "" || !result.UndoUntil.Equal(now.Add(markReadUndoWindow)) {
+				t.Errorf("Expected result to have marked entries and undo token, but got '%+v'", result)
+			}
+			select {
+			case event := <-mockedEventPublisher.publishedCh:
+				if event.eventType != events.UnreadUpdated {
+					t.Errorf("Expected event type to be '%v', but got '%v'", events.UnreadUpdated, event.eventType)
+				}
+			case <-time.After(time.Second):
+				t.Error("Expected unread counts to be published")
+			}
+		})
+	}
+}
+
+func TestFeedService_UndoMarkAllRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		restored int64
+
+		expectedErr error
+	}{{
+		name:     "entries restored",
+		restored: 3,
+	}, {
+		name:        "nothing restored",
+		restored:    0,
+		expectedErr: ErrUndoExpired,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := WebRSSService{
+				nowFn:              time.Now,
+				entryRepository:    &entryRepositoryMock{undoMarkAllReadResp: tt.restored},
+				categoryRepository: &categoryRepositoryMock{},
+				feedRepository:     &feedRepositoryMock{},
+				events:             &eventPublisherMock{},
+			}
+			if err := s.UndoMarkAllRead(context.Background(), "token"); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package webrss
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type readLaterService interface {
+	Send(ctx context.Context, entry repository.Entry) error
+	AutoSendStarred(ctx context.Context) (bool, error)
+}
+
+// readLaterEntry returns entry with its user tags, which are sent along with it.
+func (s WebRSSService) readLaterEntry(ctx context.Context, id int64) (repository.Entry, error) {
+	entry, err := s.entryRepository.Get(ctx, id)
+	if err != nil {
+		return repository.Entry{}, fmt.Errorf("error getting entry: %w", err)
+	}
+	entries := []repository.Entry{entry}
+	if err := s.attachUserTags(ctx, entries); err != nil {
+		return repository.Entry{}, fmt.Errorf("cannot fetch user tags for entry: %w", err)
+	}
+	return entries[0], nil
+}
+
+// SendEntry saves entry in the configured read-later service.
+func (s WebRSSService) SendEntry(ctx context.Context, id int64) error {
+	entry, err := s.readLaterEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.readLater.Send(ctx, entry); err != nil {
+		return fmt.Errorf("error sending entry: %w", err)
+	}
+	return nil
+}
+
+// sendStarred sends starred entries to read-later service in background when it's enabled, so starring
+// doesn't wait for the service, and its failure doesn't affect starring itself.
+func (s WebRSSService) sendStarred(ctx context.Context, ids ...int64) {
+	if len(ids) == 0 {
+		return
+	}
+	autoSend, err := s.readLater.AutoSendStarred(ctx)
+	if err != nil {
+		s.logger.Printf("cannot send starred entries %v: %v\n", ids, err)
+		return
+	}
+	if !autoSend {
+		return
+	}
+	go func() {
+		for _, id := range ids {
+			if err := s.SendEntry(context.Background(), id); err != nil {
+				s.logger.Printf("cannot send starred entry %d: %v\n", id, err)
+			}
+		}
+	}()
+}
+
+// starredIDs returns ids of entries starred by rules, which weren't hidden by them.
+func starredIDs(entries []repository.Entry) []int64 {
+	ids := []int64{}
+	for _, entry := range entries {
+		if entry.StarredAt.Valid && !entry.DeletedAt.Valid {
+			ids = append(ids, entry.ID)
+		}
+	}
+	return ids
+}
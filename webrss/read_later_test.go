@@ -0,0 +1,171 @@
+package webrss
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type readLaterServiceMock struct {
+	autoSendStarred bool
+	sent            []repository.Entry
+	// sentCh receives entries sent, when it's set
+	sentCh chan repository.Entry
+	err    error
+}
+
+func (m *readLaterServiceMock) Send(ctx context.Context, entry repository.Entry) error {
+	m.sent = append(m.sent, entry)
+	if m.sentCh != nil {
+		m.sentCh <- entry
+	}
+	return m.err
+}
+
+func (m *readLaterServiceMock) AutoSendStarred(ctx context.Context) (bool, error) {
+	return m.autoSendStarred, nil
+}
+
+func TestFeedService_SendEntry(t *testing.T) {
+	entries := []repository.Entry{{ID: 1, Title: "Entry", Link: "https://example.com/entry"}}
+	readLater := &readLaterServiceMock{}
+	s := WebRSSService{
+		logger:            log.New(ioutil.Discard, "", 0),
+		entryRepository:   &entryRepositoryMock{entries: entries},
+		userTagRepository: &userTagRepositoryMock{taggedEntries: map[int64][]string{1: {"go", "later"}}},
+		readLater:         readLater,
+	}
+	if err := s.SendEntry(context.Background(), 1); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	if len(readLater.sent) != 1 {
+		t.Fatalf("Expected entry to be sent once, but got '%d'", len(readLater.sent))
+	}
+	if link := readLater.sent[0].Link; link != entries[0].Link {
+		t.Errorf("Expected link to be '%s', but got '%s'", entries[0].Link, link)
+	}
+	if tags := readLater.sent[0].UserTags; !reflect.DeepEqual(tags, []string{"go", "later"}) {
+		t.Errorf("Expected tags to be '%v', but got '%v'", []string{"go", "later"}, tags)
+	}
+
+	if err := s.SendEntry(context.Background(), 2); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected err to be '%v', but got '%v'", sql.ErrNoRows, err)
+	}
+}
+
+func TestFeedService_StarEntry_sendsStarred(t *testing.T) {
+	tests := []struct {
+		name            string
+		autoSendStarred bool
+		starredAt       repository.NullTime
+		readLaterErr    error
+
+		expectedSent bool
+	}{{
+		name:            "entry sent",
+		autoSendStarred: true,
+		expectedSent:    true,
+	}, {
+		name:            "sending failure doesn't fail starring",
+		autoSendStarred: true,
+		readLaterErr:    errors.New("service unavailable"),
+		expectedSent:    true,
+	}, {
+		name:            "sending disabled",
+		autoSendStarred: false,
+		expectedSent:    false,
+	}, {
+		name:            "already starred entry isn't sent again",
+		autoSendStarred: true,
+		starredAt:       repository.NewNullTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		expectedSent:    false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entryRepository := &entryRepositoryMock{entries: []repository.Entry{
+				{ID: 1, Link: "https://example.com/entry", StarredAt: tt.starredAt},
+			}}
+			readLater := &readLaterServiceMock{
+				autoSendStarred: tt.autoSendStarred,
+				sentCh:          make(chan repository.Entry, 1),
+				err:             tt.readLaterErr,
+			}
+			s := WebRSSService{
+				nowFn:             time.Now,
+				logger:            log.New(ioutil.Discard, "", 0),
+				entryRepository:   entryRepository,
+				userTagRepository: &userTagRepositoryMock{},
+				readLater:         readLater,
+			}
+			if err := s.StarEntry(context.Background(), 1); err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			if !reflect.DeepEqual(entryRepository.starredIDs, []int64{1}) {
+				t.Errorf("Expected starred entries to be '%v', but got '%v'", []int64{1}, entryRepository.starredIDs)
+			}
+			if !tt.expectedSent {
+				if len(readLater.sent) != 0 {
+					t.Errorf("Expected no entries to be sent, but got '%v'", readLater.sent)
+				}
+				return
+			}
+			select {
+			case entry := <-readLater.sentCh:
+				if entry.ID != 1 {
+					t.Errorf("Expected sent entry to be '%d', but got '%d'", 1, entry.ID)
+				}
+			case <-time.After(time.Second):
+				t.Errorf("Expected entry to be sent")
+			}
+		})
+	}
+}
+
+func TestFeedService_SaveEntries_sendsStarred(t *testing.T) {
+	entries := []repository.Entry{{Title: "New release", Link: "link1"}, {Title: "Other news", Link: "link2"}}
+	entryRepository := &entryRepositoryMock{
+		getEntryByURLErr: map[string]error{"link1": sql.ErrNoRows, "link2": sql.ErrNoRows},
+		// entries as they're fetched once created
+		entries: []repository.Entry{{ID: 1, Title: "New release", Link: "link1"}, {ID: 2, Title: "Other news", Link: "link2"}},
+	}
+	readLater := &readLaterServiceMock{
+		autoSendStarred: true,
+		sentCh:          make(chan repository.Entry, 2),
+	}
+	s := WebRSSService{
+		nowFn:           time.Now,
+		logger:          log.New(ioutil.Discard, "", 0),
+		entryRepository: entryRepository,
+		ruleRepository: &ruleRepositoryMock{listForFeedResp: []repository.Rule{{
+			Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: "release",
+			Action: repository.RuleActionStar,
+		}}},
+		userTagRepository: &userTagRepositoryMock{},
+		webhookRepository: &webhookRepositoryMock{},
+		events:            &eventPublisherMock{},
+		readLater:         readLater,
+	}
+	if _, _, err := s.SaveEntries(context.Background(), 1, entries); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	select {
+	case entry := <-readLater.sentCh:
+		if entry.ID != 1 {
+			t.Errorf("Expected sent entry to be '%d', but got '%d'", 1, entry.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected entry to be sent")
+	}
+	select {
+	case entry := <-readLater.sentCh:
+		t.Errorf("Expected only starred entry to be sent, but got '%d'", entry.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,58 @@
+package webrss
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type entryRevisionRepository interface {
+	Create(ctx context.Context, revision repository.EntryRevision) error
+	ListForEntry(ctx context.Context, entryID int64) ([]repository.EntryRevision, error)
+}
+
+// RevisionDiff is entry's revision with changes made to it by the next version of the entry.
+type RevisionDiff struct {
+	repository.EntryRevision
+
+	TitleDiff   []DiffOp `json:"title_diff"`
+	AuthorDiff  []DiffOp `json:"author_diff"`
+	SummaryDiff []DiffOp `json:"summary_diff"`
+}
+
+func contentChanged(a, b repository.Entry) bool {
+	return a.Title != b.Title || a.Author.String != b.Author.String || a.Summary.String != b.Summary.String
+}
+
+// isUpdated reports whether entry's content changed after it was read.
+func isUpdated(entry repository.Entry) bool {
+	return entry.ReadAt.Valid && entry.ChangedAt.Valid && entry.ChangedAt.Time.After(entry.ReadAt.Time)
+}
+
+// ListEntryRevisions returns previous versions of entry, oldest first.
+func (s WebRSSService) ListEntryRevisions(ctx context.Context, entryID int64) ([]RevisionDiff, error) {
+	entry, err := s.entryRepository.Get(ctx, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting entry: %w", err)
+	}
+	revisions, err := s.entryRevisionRepository.ListForEntry(ctx, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching entry revisions: %w", err)
+	}
+
+	diffs := make([]RevisionDiff, 0, len(revisions))
+	for i, revision := range revisions {
+		next := repository.EntryRevision{Title: entry.Title, Author: entry.Author, Summary: entry.Summary}
+		if i+1 < len(revisions) {
+			next = revisions[i+1]
+		}
+		diffs = append(diffs, RevisionDiff{
+			EntryRevision: revision,
+			TitleDiff:     diffWords(revision.Title, next.Title),
+			AuthorDiff:    diffWords(revision.Author.String, next.Author.String),
+			SummaryDiff:   diffWords(revision.Summary.String, next.Summary.String),
+		})
+	}
+	return diffs, nil
+}
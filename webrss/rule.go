@@ -0,0 +1,218 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+// ruleTestEntries is the number of latest entries rule is tested against.
+const ruleTestEntries = 100
+
+var ErrInvalidRule = errors.New("invalid rule")
+
+type ruleRepository interface {
+	List(ctx context.Context) ([]repository.Rule, error)
+	ListForFeed(ctx context.Context, feedID int64) ([]repository.Rule, error)
+	Get(ctx context.Context, id int64) (repository.Rule, error)
+	Create(ctx context.Context, rule repository.Rule) (int64, error)
+	Update(ctx context.Context, rule repository.Rule) error
+}
+
+// ruleMatcher is a rule with its pattern prepared for matching.
+type ruleMatcher struct {
+	rule     repository.Rule
+	keywords []string
+	regexp   *regexp.Regexp
+}
+
+func newRuleMatcher(rule repository.Rule) (ruleMatcher, error) {
+	matcher := ruleMatcher{rule: rule}
+	switch rule.Field {
+	case repository.RuleFieldTitle, repository.RuleFieldAuthor, repository.RuleFieldContent,
+		repository.RuleFieldLink, repository.RuleFieldTags:
+	default:
+		return ruleMatcher{}, fmt.Errorf("%w: unknown field '%s'", ErrInvalidRule, rule.Field)
+	}
+	switch rule.Action {
+	case repository.RuleActionRead, repository.RuleActionStar, repository.RuleActionHide:
+	case repository.RuleActionTag:
+		if strings.TrimSpace(rule.Tag.String) == "" {
+			return ruleMatcher{}, fmt.Errorf("%w: missing tag", ErrInvalidRule)
+		}
+	default:
+		return ruleMatcher{}, fmt.Errorf("%w: unknown action '%s'", ErrInvalidRule, rule.Action)
+	}
+	switch rule.MatchType {
+	case repository.RuleMatchKeyword:
+		for _, keyword := range strings.Split(rule.Pattern, ",") {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+				matcher.keywords = append(matcher.keywords, keyword)
+			}
+		}
+		if len(matcher.keywords) == 0 {
+			return ruleMatcher{}, fmt.Errorf("%w: missing keywords", ErrInvalidRule)
+		}
+	case repository.RuleMatchRegex:
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return ruleMatcher{}, fmt.Errorf("%w: %s", ErrInvalidRule, err)
+		}
+		matcher.regexp = re
+	default:
+		return ruleMatcher{}, fmt.Errorf("%w: unknown match type '%s'", ErrInvalidRule, rule.MatchType)
+	}
+	return matcher, nil
+}
+
+func entryField(entry repository.Entry, field string) string {
+	switch field {
+	case repository.RuleFieldTitle:
+		return entry.Title
+	case repository.RuleFieldAuthor:
+		return entry.Author.String
+	case repository.RuleFieldContent:
+		return entry.Summary.String
+	case repository.RuleFieldLink:
+		return entry.Link
+	case repository.RuleFieldTags:
+		return entry.Tags.String
+	}
+	return ""
+}
+
+// matches reports whether entry matches rule, keywords are matched case insensitive and any of them is enough.
+func (m ruleMatcher) matches(entry repository.Entry) bool {
+	value := entryField(entry, m.rule.Field)
+	if m.regexp != nil {
+		return m.regexp.MatchString(value)
+	}
+	value = strings.ToLower(value)
+	for _, keyword := range m.keywords {
+		if strings.Contains(value, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRule checks rule before it's saved, rule can be limited to either feed or category.
+func validateRule(rule repository.Rule) error {
+	if rule.FeedID.Valid && rule.CategoryID.Valid {
+		return fmt.Errorf("%w: rule can be limited to either feed or category", ErrInvalidRule)
+	}
+	_, err := newRuleMatcher(rule)
+	return err
+}
+
+// newRuleMatchers prepares rules for matching, invalid rules are skipped.
+func (s WebRSSService) newRuleMatchers(rules []repository.Rule) []ruleMatcher {
+	matchers := make([]ruleMatcher, 0, len(rules))
+	for _, rule := range rules {
+		matcher, err := newRuleMatcher(rule)
+		if err != nil {
+			s.logger.Printf("skipping rule %d: %v\n", rule.ID, err)
+			continue
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers
+}
+
+// applyRules applies actions of matching rules to a new entry, returns tags that should be added
+// to the entry once it's saved.
+func applyRules(matchers []ruleMatcher, entry *repository.Entry, now time.Time) []string {
+	var tags []string
+	for _, matcher := range matchers {
+		if !matcher.matches(*entry) {
+			continue
+		}
+		switch matcher.rule.Action {
+		case repository.RuleActionRead:
+			entry.ReadAt = repository.NewNullTime(now)
+		case repository.RuleActionStar:
+			entry.StarredAt = repository.NewNullTime(now)
+		case repository.RuleActionHide:
+			entry.DeletedAt = repository.NewNullTime(now)
+		case repository.RuleActionTag:
+			tags = append(tags, strings.TrimSpace(matcher.rule.Tag.String))
+		}
+	}
+	return tags
+}
+
+func (s WebRSSService) ListRules(ctx context.Context) ([]repository.Rule, error) {
+	rules, err := s.ruleRepository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (s WebRSSService) GetRule(ctx context.Context, id int64) (repository.Rule, error) {
+	rule, err := s.ruleRepository.Get(ctx, id)
+	if err != nil {
+		return repository.Rule{}, fmt.Errorf("error fetching rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s WebRSSService) CreateRule(ctx context.Context, rule repository.Rule) error {
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	rule.CreatedAt = repository.NewTime(s.nowFn())
+	if _, err := s.ruleRepository.Create(ctx, rule); err != nil {
+		return fmt.Errorf("error creating rule: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) UpdateRule(ctx context.Context, rule repository.Rule) error {
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	rule.UpdatedAt = repository.NewNullTime(s.nowFn())
+	if err := s.ruleRepository.Update(ctx, rule); err != nil {
+		return fmt.Errorf("error updating rule: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) DeleteRule(ctx context.Context, id int64) error {
+	rule, err := s.GetRule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("cannot fetch rule for delete: %w", err)
+	}
+	now := repository.NewNullTime(s.nowFn())
+	rule.UpdatedAt = now
+	rule.DeletedAt = now
+	if err := s.ruleRepository.Update(ctx, rule); err != nil {
+		return fmt.Errorf("error deleting rule: %w", err)
+	}
+	return nil
+}
+
+// TestRule returns which of the latest entries within rule's scope would be matched by it.
+func (s WebRSSService) TestRule(ctx context.Context, rule repository.Rule) ([]repository.Entry, error) {
+	matcher, err := newRuleMatcher(rule)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.entryRepository.ListLatest(ctx, rule.FeedID.Int64, rule.CategoryID.Int64, ruleTestEntries)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching latest entries: %w", err)
+	}
+	matched := make([]repository.Entry, 0)
+	for _, entry := range entries {
+		if matcher.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,104 @@
+package webrss
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+func TestRuleMatcher(t *testing.T) {
+	entry := repository.Entry{
+		Title:   "Go 1.15 is released",
+		Author:  repository.NewNullString("The Go Team"),
+		Summary: repository.NewNullString("Release notes"),
+		Link:    "https://blog.golang.org/go1.15",
+		Tags:    repository.NewNullString("go,release"),
+	}
+	tests := []struct {
+		name string
+		rule repository.Rule
+
+		expectedErr   error
+		expectedMatch bool
+	}{{
+		name:          "keyword matches case insensitive",
+		rule:          repository.Rule{Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: "RELEASED", Action: repository.RuleActionRead},
+		expectedMatch: true,
+	}, {
+		name:          "any of keywords matches",
+		rule:          repository.Rule{Field: repository.RuleFieldAuthor, MatchType: repository.RuleMatchKeyword, Pattern: "rust, go team", Action: repository.RuleActionRead},
+		expectedMatch: true,
+	}, {
+		name:          "keyword doesn't match",
+		rule:          repository.Rule{Field: repository.RuleFieldContent, MatchType: repository.RuleMatchKeyword, Pattern: "rust", Action: repository.RuleActionRead},
+		expectedMatch: false,
+	}, {
+		name:          "regex matches link",
+		rule:          repository.Rule{Field: repository.RuleFieldLink, MatchType: repository.RuleMatchRegex, Pattern: `golang\.org/go1\.\d+$`, Action: repository.RuleActionStar},
+		expectedMatch: true,
+	}, {
+		name:          "regex matches tags",
+		rule:          repository.Rule{Field: repository.RuleFieldTags, MatchType: repository.RuleMatchRegex, Pattern: `(^|,)release(,|$)`, Action: repository.RuleActionStar},
+		expectedMatch: true,
+	}, {
+		name:        "invalid regex",
+		rule:        repository.Rule{Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchRegex, Pattern: `(go`, Action: repository.RuleActionRead},
+		expectedErr: ErrInvalidRule,
+	}, {
+		name:        "empty keywords",
+		rule:        repository.Rule{Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: " , ", Action: repository.RuleActionRead},
+		expectedErr: ErrInvalidRule,
+	}, {
+		name:        "tag action without tag",
+		rule:        repository.Rule{Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: "go", Action: repository.RuleActionTag},
+		expectedErr: ErrInvalidRule,
+	}, {
+		name:        "unknown field",
+		rule:        repository.Rule{Field: "feed", MatchType: repository.RuleMatchKeyword, Pattern: "go", Action: repository.RuleActionRead},
+		expectedErr: ErrInvalidRule,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := newRuleMatcher(tt.rule)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if match := matcher.matches(entry); match != tt.expectedMatch {
+				t.Errorf("Expected match to be '%v', but got '%v'", tt.expectedMatch, match)
+			}
+		})
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name string
+		rule repository.Rule
+
+		expectedErr error
+	}{{
+		name: "limited to feed",
+		rule: repository.Rule{FeedID: repository.NewNullInt64(1), Field: repository.RuleFieldTitle,
+			MatchType: repository.RuleMatchKeyword, Pattern: "go", Action: repository.RuleActionRead},
+	}, {
+		name: "limited to both feed and category",
+		rule: repository.Rule{FeedID: repository.NewNullInt64(1), CategoryID: repository.NewNullInt64(2),
+			Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword, Pattern: "go", Action: repository.RuleActionRead},
+		expectedErr: ErrInvalidRule,
+	}, {
+		name:        "invalid matcher",
+		rule:        repository.Rule{Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchRegex, Pattern: `(go`, Action: repository.RuleActionRead},
+		expectedErr: ErrInvalidRule,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRule(tt.rule); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/search"
+)
+
+var ErrInvalidSavedSearch = errors.New("invalid saved search")
+
+type savedSearchRepository interface {
+	List(ctx context.Context) ([]repository.SavedSearch, error)
+	ListForCategories(ctx context.Context, ids []int64) ([]repository.SavedSearch, error)
+	Get(ctx context.Context, id int64) (repository.SavedSearch, error)
+	Create(ctx context.Context, savedSearch repository.SavedSearch) (int64, error)
+	Update(ctx context.Context, savedSearch repository.SavedSearch) error
+}
+
+// savedSearchQuery parses query of saved search, limiting it to unread entries if requested. Matching entries
+// are listed newest first, like entries of a feed.
+func savedSearchQuery(savedSearch repository.SavedSearch, unread bool) (repository.SearchQuery, error) {
+	parsed, err := search.Parse(savedSearch.Query)
+	if err != nil {
+		return repository.SearchQuery{}, fmt.Errorf("%w: %s", ErrInvalidSavedSearch, err)
+	}
+	if unread {
+		parsed.Nodes = append(parsed.Nodes, search.Unread{})
+	}
+	return repository.SearchQuery{
+		Query: parsed,
+		Mode:  savedSearch.Mode,
+		Sort:  repository.SearchSortDate,
+	}, nil
+}
+
+// attachSearchStats sets number of unread entries matching saved searches and whether there are new ones,
+// stats of all saved searches are fetched at once.
+func (s WebRSSService) attachSearchStats(ctx context.Context, savedSearches []repository.SavedSearch, seen lastSeen) error {
+	queries := make([]repository.SearchStatsQuery, 0, len(savedSearches))
+	positions := make([]int, 0, len(savedSearches))
+	for i, savedSearch := range savedSearches {
+		query, err := savedSearchQuery(savedSearch, false)
+		if err != nil {
+			s.logger.Printf("skipping stats of saved search %d: %v\n", savedSearch.ID, err)
+			continue
+		}
+		queries = append(queries, repository.SearchStatsQuery{Query: query, Since: seen.savedSearch(savedSearch)})
+		positions = append(positions, i)
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+	stats, err := s.entryRepository.ListSearchStats(ctx, queries)
+	if err != nil {
+		return fmt.Errorf("error fetching stats of saved searches: %w", err)
+	}
+	for i, position := range positions {
+		savedSearches[position].UnRead, savedSearches[position].NewEntries = stats[i].UnRead, stats[i].NewEntries
+	}
+	return nil
+}
+
+func (s WebRSSService) ListSavedSearches(ctx context.Context) ([]repository.SavedSearch, error) {
+	savedSearches, err := s.savedSearchRepository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching saved searches: %w", err)
+	}
+	seen, err := s.getLastSeen(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachSearchStats(ctx, savedSearches, seen); err != nil {
+		return nil, err
+	}
+	return savedSearches, nil
+}
+
+func (s WebRSSService) GetSavedSearch(ctx context.Context, id int64) (repository.SavedSearch, error) {
+	savedSearch, err := s.savedSearchRepository.Get(ctx, id)
+	if err != nil {
+		return repository.SavedSearch{}, fmt.Errorf("error fetching saved search: %w", err)
+	}
+	return savedSearch, nil
+}
+
+// CreateSavedSearch saves search, entries already matching it aren't new.
+func (s WebRSSService) CreateSavedSearch(ctx context.Context, savedSearch repository.SavedSearch) error {
+	if _, err := savedSearchQuery(savedSearch, false); err != nil {
+		return err
+	}
+	now := s.nowFn()
+	savedSearch.LastReadAt = repository.NewTime(now)
+	savedSearch.CreatedAt = repository.NewTime(now)
+	if _, err := s.savedSearchRepository.Create(ctx, savedSearch); err != nil {
+		return fmt.Errorf("error creating saved search: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) UpdateSavedSearch(ctx context.Context, savedSearch repository.SavedSearch) error {
+	if _, err := savedSearchQuery(savedSearch, false); err != nil {
+		return err
+	}
+	savedSearch.UpdatedAt = repository.NewNullTime(s.nowFn())
+	if err := s.savedSearchRepository.Update(ctx, savedSearch); err != nil {
+		return fmt.Errorf("error updating saved search: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) DeleteSavedSearch(ctx context.Context, id int64) error {
+	savedSearch, err := s.GetSavedSearch(ctx, id)
+	if err != nil {
+		return fmt.Errorf("cannot fetch saved search for delete: %w", err)
+	}
+	now := repository.NewNullTime(s.nowFn())
+	savedSearch.UpdatedAt = now
+	savedSearch.DeletedAt = now
+	if err := s.savedSearchRepository.Update(ctx, savedSearch); err != nil {
+		return fmt.Errorf("error deleting saved search: %w", err)
+	}
+	return nil
+}
+
+// listSavedSearchEntries returns entries matching saved search, newest first.
+func (s WebRSSService) listSavedSearchEntries(ctx context.Context, filter repository.EntryFilter, page repository.PageRequest) (repository.EntryPage, error) {
+	savedSearch, err := s.GetSavedSearch(ctx, filter.SavedSearchID)
+	if err != nil {
+		return repository.EntryPage{}, err
+	}
+	query, err := savedSearchQuery(savedSearch, filter.Unread)
+	if err != nil {
+		return repository.EntryPage{}, err
+	}
+	return s.Search(ctx, query, page)
+}
@@ -0,0 +1,88 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/search"
+)
+
+func TestSavedSearchQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		savedSearch repository.SavedSearch
+		unread      bool
+
+		expectedQuery repository.SearchQuery
+		expectedErr   error
+	}{{
+		name:        "query",
+		savedSearch: repository.SavedSearch{Query: "webrss is:starred", Mode: repository.SearchModeBoolean},
+		expectedQuery: repository.SearchQuery{
+			Query: search.And{Nodes: []search.Node{search.Text{Value: "webrss"}, search.Starred{}}},
+			Mode:  repository.SearchModeBoolean,
+			Sort:  repository.SearchSortDate,
+		},
+	}, {
+		name:        "unread entries",
+		savedSearch: repository.SavedSearch{Query: "CVE", Mode: repository.SearchModeNatural},
+		unread:      true,
+		expectedQuery: repository.SearchQuery{
+			Query: search.And{Nodes: []search.Node{search.Text{Value: "CVE"}, search.Unread{}}},
+			Mode:  repository.SearchModeNatural,
+			Sort:  repository.SearchSortDate,
+		},
+	}, {
+		name:        "invalid query",
+		savedSearch: repository.SavedSearch{Query: `"CVE`, Mode: repository.SearchModeNatural},
+		expectedErr: ErrInvalidSavedSearch,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := savedSearchQuery(tt.savedSearch, tt.unread)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(query, tt.expectedQuery) {
+				t.Errorf("Expected query to be '%v', but got '%v'", tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestFeedService_attachSearchStats(t *testing.T) {
+	mockedEntryRepository := &entryRepositoryMock{
+		searchStatsResp: repository.SearchStats{UnRead: 4, NewEntries: 1},
+	}
+	s := WebRSSService{
+		logger:          log.New(ioutil.Discard, "", 0),
+		entryRepository: mockedEntryRepository,
+	}
+	savedSearches := []repository.SavedSearch{
+		{ID: 1, Query: `"broken`, Mode: repository.SearchModeNatural},
+		{ID: 2, Query: "webrss", Mode: repository.SearchModeNatural},
+		{ID: 3, Query: "golang", Mode: repository.SearchModeBoolean},
+	}
+	if err := s.attachSearchStats(context.Background(), savedSearches, lastSeen{}); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	if savedSearches[0].UnRead != 0 || savedSearches[0].NewEntries != 0 {
+		t.Errorf("Expected stats of invalid query to be empty, but got '%v'", savedSearches[0])
+	}
+	for _, savedSearch := range savedSearches[1:] {
+		if savedSearch.UnRead != 4 || savedSearch.NewEntries != 1 {
+			t.Errorf("Expected stats to be set, but got '%v'", savedSearch)
+		}
+	}
+	if len(mockedEntryRepository.searchStatsQueries) != 1 {
+		t.Fatalf("Expected stats to be fetched once, but got '%d'", len(mockedEntryRepository.searchStatsQueries))
+	}
+	if len(mockedEntryRepository.searchStatsQueries[0]) != 2 {
+		t.Errorf("Expected stats of two queries to be fetched, but got '%d'", len(mockedEntryRepository.searchStatsQueries[0]))
+	}
+}
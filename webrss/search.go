@@ -0,0 +1,142 @@
+package webrss
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/search"
+)
+
+const (
+	// snippetLength is the approximate length of snippet in bytes.
+	snippetLength = 200
+	// snippetLeadingWords is the number of words shown before the first match.
+	snippetLeadingWords = 8
+)
+
+var (
+	tagRe  = regexp.MustCompile(`<[^>]*>`)
+	wordRe = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+)
+
+// Search returns page of entries matching query, each one with a snippet of its summary where searched
+// words are highlighted.
+func (s WebRSSService) Search(ctx context.Context, query repository.SearchQuery, page repository.PageRequest) (repository.EntryPage, error) {
+	entries, err := s.entryRepository.Search(ctx, query, page)
+	if err != nil {
+		return repository.EntryPage{}, fmt.Errorf("error searching entries: %w", err)
+	}
+	if err := s.attachFeeds(ctx, entries.Entries); err != nil {
+		return repository.EntryPage{}, fmt.Errorf("cannot fetch feeds for entries: %w", err)
+	}
+	if err := s.attachUserTags(ctx, entries.Entries); err != nil {
+		return repository.EntryPage{}, fmt.Errorf("cannot fetch user tags for entries: %w", err)
+	}
+
+	terms := searchTerms(query)
+	for i := range entries.Entries {
+		entries.Entries[i].Snippet = snippet(entries.Entries[i].Summary.String, terms)
+	}
+	return entries, nil
+}
+
+// searchTerm is a lowercased word of the search phrase, prefix terms match words starting with them.
+type searchTerm struct {
+	word   string
+	prefix bool
+}
+
+func (t searchTerm) matches(word string) bool {
+	if t.prefix {
+		return strings.HasPrefix(word, t.word)
+	}
+	return word == t.word
+}
+
+// searchTerms returns words that should be highlighted in results, words of negated terms are skipped.
+func searchTerms(query repository.SearchQuery) []searchTerm {
+	var terms []searchTerm
+	for _, node := range query.Query.Nodes {
+		text, ok := node.(search.Text)
+		if !ok {
+			continue
+		}
+		words := wordRe.FindAllString(strings.ToLower(text.Value), -1)
+		for i, word := range words {
+			terms = append(terms, searchTerm{word: word, prefix: text.Prefix && i == len(words)-1})
+		}
+	}
+	return terms
+}
+
+// plainText returns summary stripped of HTML tags, with entities decoded and whitespace collapsed.
+func plainText(summary string) string {
+	text := html.UnescapeString(tagRe.ReplaceAllString(summary, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// snippet returns HTML escaped fragment of summary around the first matched term, with matched words
+// wrapped in <mark>. Beginning of the summary is returned when no term matches.
+func snippet(summary string, terms []searchTerm) string {
+	text := plainText(summary)
+	words := wordRe.FindAllStringIndex(text, -1)
+	matched := make([]bool, len(words))
+	first := -1
+	for i, loc := range words {
+		word := strings.ToLower(text[loc[0]:loc[1]])
+		for _, term := range terms {
+			if term.matches(word) {
+				matched[i] = true
+				break
+			}
+		}
+		if matched[i] && first == -1 {
+			first = i
+		}
+	}
+
+	start, startWord := 0, 0
+	if first > snippetLeadingWords {
+		startWord = first - snippetLeadingWords
+		start = words[startWord][0]
+	}
+	end := len(text)
+	if end-start > snippetLength {
+		end = start + snippetLength
+		for i := startWord; i < len(words); i++ {
+			if words[i][1] > end {
+				if words[i][0] > start {
+					end = words[i][0]
+				}
+				break
+			}
+		}
+		for end > start && !utf8.RuneStart(text[end]) {
+			end--
+		}
+	}
+
+	b := strings.Builder{}
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	pos := start
+	for i := startWord; i < len(words) && words[i][1] <= end; i++ {
+		if !matched[i] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:words[i][0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[words[i][0]:words[i][1]]) + "</mark>")
+		pos = words[i][1]
+	}
+	b.WriteString(html.EscapeString(strings.TrimRight(text[pos:end], " ")))
+	if end < len(text) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
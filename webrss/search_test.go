@@ -0,0 +1,84 @@
+package webrss
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Alkemic/webrss/repository"
+	"github.com/Alkemic/webrss/search"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		query search.And
+
+		expectedTerms []searchTerm
+	}{{
+		name:          "words",
+		query:         search.And{Nodes: []search.Node{search.Text{Value: "Go"}, search.Text{Value: "Release-notes"}}},
+		expectedTerms: []searchTerm{{word: "go"}, {word: "release"}, {word: "notes"}},
+	}, {
+		name: "phrase and prefix",
+		query: search.And{Nodes: []search.Node{
+			search.Text{Value: "release notes", Phrase: true},
+			search.Text{Value: "gener", Prefix: true},
+		}},
+		expectedTerms: []searchTerm{{word: "release"}, {word: "notes"}, {word: "gener", prefix: true}},
+	}, {
+		name: "skips negated words and filters",
+		query: search.And{Nodes: []search.Node{
+			search.Text{Value: "go"},
+			search.Not{Node: search.Text{Value: "java"}},
+			search.Author{Value: "rob"},
+		}},
+		expectedTerms: []searchTerm{{word: "go"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terms := searchTerms(repository.SearchQuery{Query: tt.query})
+			if !reflect.DeepEqual(terms, tt.expectedTerms) {
+				t.Errorf("Expected terms to be '%v', but got '%v'", tt.expectedTerms, terms)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		terms   []searchTerm
+
+		expectedSnippet string
+	}{{
+		name:            "highlights matched words",
+		summary:         "<p>Go 1.15 is <b>released</b> &amp; ready</p>",
+		terms:           []searchTerm{{word: "go"}, {word: "releas", prefix: true}},
+		expectedSnippet: "<mark>Go</mark> 1.15 is <mark>released</mark> &amp; ready",
+	}, {
+		name:            "no match",
+		summary:         "Nothing <to> see",
+		terms:           []searchTerm{{word: "go"}},
+		expectedSnippet: "Nothing see",
+	}, {
+		name:            "escapes text",
+		summary:         "a &lt;script&gt; tag",
+		terms:           []searchTerm{{word: "tag"}},
+		expectedSnippet: "a &lt;script&gt; <mark>tag</mark>",
+	}, {
+		name:            "match far in long summary",
+		summary:         strings.Repeat("word ", 50) + "needle " + strings.Repeat("rest ", 50),
+		terms:           []searchTerm{{word: "needle"}},
+		expectedSnippet: "… " + strings.Repeat("word ", 8) + "<mark>needle</mark>" + strings.TrimRight(strings.Repeat(" rest", 30), " ") + " …",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := snippet(tt.summary, tt.terms)
+			if snippet != tt.expectedSnippet {
+				t.Errorf("Expected snippet to be '%s', but got '%s'", tt.expectedSnippet, snippet)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ type feedRepository interface {
 	Get(ctx context.Context, id int64) (repository.Feed, error)
 	Create(ctx context.Context, feed repository.Feed) (int64, error)
 	ListForCategories(ctx context.Context, ids []int64) ([]repository.Feed, error)
+	ListByIDs(ctx context.Context, ids []int64) ([]repository.Feed, error)
 	List(ctx context.Context) ([]repository.Feed, error)
 	Update(ctx context.Context, entry repository.Feed) error
 }
@@ -39,30 +40,66 @@ type transactionRepository interface {
 }
 
 type WebRSSService struct {
-	nowFn                 func() time.Time
-	logger                *log.Logger
-	feedRepository        feedRepository
-	entryRepository       entryRepository
-	categoryRepository    categoryRepository
-	transactionRepository transactionRepository
-	feedFetcher           feedFetcher
-	httpClient            *http.Client
+	nowFn                     func() time.Time
+	logger                    *log.Logger
+	feedRepository            feedRepository
+	entryRepository           entryRepository
+	entryRevisionRepository   entryRevisionRepository
+	categoryRepository        categoryRepository
+	transactionRepository     transactionRepository
+	fetchLogRepository        fetchLogRepository
+	ruleRepository            ruleRepository
+	userTagRepository         userTagRepository
+	savedSearchRepository     savedSearchRepository
+	annotationRepository      annotationRepository
+	lastSeenRepository        lastSeenRepository
+	shareRepository           shareRepository
+	webhookRepository         webhookRepository
+	webhookDeliveryRepository webhookDeliveryRepository
+	events                    eventPublisher
+	unreadCounts              *debouncer
+	readLater                 readLaterService
+	feedFetcher               feedFetcher
+	httpClient                *http.Client
 }
 
 func NewService(
 	logger *log.Logger,
 	categoryRepository categoryRepository, feedRepository feedRepository,
-	entryRepository entryRepository, transactionRepository transactionRepository,
-	httpClient *http.Client, feedFetcher feedFetcher,
+	entryRepository entryRepository, entryRevisionRepository entryRevisionRepository,
+	transactionRepository transactionRepository,
+	fetchLogRepository fetchLogRepository, ruleRepository ruleRepository, userTagRepository userTagRepository,
+	savedSearchRepository savedSearchRepository, annotationRepository annotationRepository,
+	lastSeenRepository lastSeenRepository, shareRepository shareRepository,
+	webhookRepository webhookRepository, webhookDeliveryRepository webhookDeliveryRepository,
+	events eventPublisher, readLater readLaterService, httpClient *http.Client, feedFetcher feedFetcher,
 ) *WebRSSService {
 	return &WebRSSService{
-		nowFn:                 time.Now,
-		logger:                logger,
-		feedRepository:        feedRepository,
-		entryRepository:       entryRepository,
-		categoryRepository:    categoryRepository,
-		transactionRepository: transactionRepository,
-		httpClient:            httpClient,
-		feedFetcher:           feedFetcher,
+		nowFn:                     time.Now,
+		logger:                    logger,
+		feedRepository:            feedRepository,
+		entryRepository:           entryRepository,
+		entryRevisionRepository:   entryRevisionRepository,
+		categoryRepository:        categoryRepository,
+		transactionRepository:     transactionRepository,
+		fetchLogRepository:        fetchLogRepository,
+		ruleRepository:            ruleRepository,
+		userTagRepository:         userTagRepository,
+		savedSearchRepository:     savedSearchRepository,
+		annotationRepository:      annotationRepository,
+		lastSeenRepository:        lastSeenRepository,
+		shareRepository:           shareRepository,
+		webhookRepository:         webhookRepository,
+		webhookDeliveryRepository: webhookDeliveryRepository,
+		events:                    events,
+		unreadCounts:              newDebouncer(unreadCountsDelay),
+		readLater:                 readLater,
+		httpClient:                httpClient,
+		feedFetcher:               feedFetcher,
 	}
 }
+
+// Now returns current time of the service's clock.
+func (s WebRSSService) Now() time.Time {
+	return s.nowFn()
+}
@@ -0,0 +1,123 @@
+package webrss
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+var ErrInvalidShare = errors.New("invalid share")
+
+// shareTokenBytes is a number of random bytes share token is made of.
+const shareTokenBytes = 32
+
+type shareRepository interface {
+	List(ctx context.Context, now time.Time) ([]repository.Share, error)
+	Get(ctx context.Context, id int64) (repository.Share, error)
+	GetByToken(ctx context.Context, token string, now time.Time) (repository.Share, error)
+	Create(ctx context.Context, share repository.Share) (int64, error)
+	Update(ctx context.Context, share repository.Share) error
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateShare creates public link to entry, valid until given time or until it's revoked when time is zero.
+func (s WebRSSService) CreateShare(ctx context.Context, entryID int64, expiresAt time.Time) (repository.Share, error) {
+	now := s.nowFn()
+	share := repository.Share{
+		EntryID:   entryID,
+		CreatedAt: repository.NewTime(now),
+	}
+	if !expiresAt.IsZero() {
+		if !expiresAt.After(now) {
+			return repository.Share{}, fmt.Errorf("%w: expiration time has already passed", ErrInvalidShare)
+		}
+		share.ExpiresAt = repository.NewNullTime(expiresAt)
+	}
+	if _, err := s.entryRepository.Get(ctx, entryID); err != nil {
+		return repository.Share{}, fmt.Errorf("error fetching shared entry: %w", err)
+	}
+	token, err := newShareToken()
+	if err != nil {
+		return repository.Share{}, fmt.Errorf("error creating share: %w", err)
+	}
+	share.Token = token
+	id, err := s.shareRepository.Create(ctx, share)
+	if err != nil {
+		return repository.Share{}, fmt.Errorf("error creating share: %w", err)
+	}
+	share.ID = id
+	return share, nil
+}
+
+// ListShares returns active shares, most recent first, with their entries and feeds.
+func (s WebRSSService) ListShares(ctx context.Context) ([]repository.Share, error) {
+	shares, err := s.shareRepository.List(ctx, s.nowFn())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching shares: %w", err)
+	}
+	ids := []int64{}
+	seen := map[int64]bool{}
+	for _, share := range shares {
+		if !seen[share.EntryID] {
+			seen[share.EntryID] = true
+			ids = append(ids, share.EntryID)
+		}
+	}
+	entries, err := s.entryRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching shared entries: %w", err)
+	}
+	if err := s.attachFeeds(ctx, entries); err != nil {
+		return nil, fmt.Errorf("cannot fetch feeds for entries: %w", err)
+	}
+	entriesByID := make(map[int64]*repository.Entry, len(entries))
+	for i := range entries {
+		entriesByID[entries[i].ID] = &entries[i]
+	}
+	for i := range shares {
+		shares[i].Entry = entriesByID[shares[i].EntryID]
+	}
+	return shares, nil
+}
+
+func (s WebRSSService) RevokeShare(ctx context.Context, id int64) error {
+	share, err := s.shareRepository.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("cannot fetch share for revoke: %w", err)
+	}
+	share.DeletedAt = repository.NewNullTime(s.nowFn())
+	if err := s.shareRepository.Update(ctx, share); err != nil {
+		return fmt.Errorf("error revoking share: %w", err)
+	}
+	return nil
+}
+
+// GetSharedEntry returns entry, with its feed, shared with given token. Revoked and expired shares are
+// reported as missing.
+func (s WebRSSService) GetSharedEntry(ctx context.Context, token string) (repository.Entry, error) {
+	share, err := s.shareRepository.GetByToken(ctx, token, s.nowFn())
+	if err != nil {
+		return repository.Entry{}, fmt.Errorf("error fetching share: %w", err)
+	}
+	entry, err := s.entryRepository.Get(ctx, share.EntryID)
+	if err != nil {
+		return repository.Entry{}, fmt.Errorf("error getting shared entry: %w", err)
+	}
+	entries := []repository.Entry{entry}
+	if err := s.attachFeeds(ctx, entries); err != nil {
+		return repository.Entry{}, fmt.Errorf("cannot fetch feed for entry: %w", err)
+	}
+	return entries[0], nil
+}
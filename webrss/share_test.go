@@ -0,0 +1,172 @@
+package webrss
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type shareRepositoryMock struct {
+	shares        []repository.Share
+	createdShares []repository.Share
+}
+
+func (m *shareRepositoryMock) List(ctx context.Context, now time.Time) ([]repository.Share, error) {
+	panic("implement me!")
+}
+
+func (m *shareRepositoryMock) Get(ctx context.Context, id int64) (repository.Share, error) {
+	panic("implement me!")
+}
+
+func (m *shareRepositoryMock) GetByToken(ctx context.Context, token string, now time.Time) (repository.Share, error) {
+	for _, share := range m.shares {
+		if share.Token == token && !share.DeletedAt.Valid && (!share.ExpiresAt.Valid || share.ExpiresAt.Time.After(now)) {
+			return share, nil
+		}
+	}
+	return repository.Share{}, sql.ErrNoRows
+}
+
+func (m *shareRepositoryMock) Create(ctx context.Context, share repository.Share) (int64, error) {
+	m.createdShares = append(m.createdShares, share)
+	return int64(len(m.createdShares)), nil
+}
+
+func (m *shareRepositoryMock) Update(ctx context.Context, share repository.Share) error {
+	panic("implement me!")
+}
+
+func TestFeedService_CreateShare(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		entryID   int64
+		expiresAt time.Time
+
+		expectedErr       error
+		expectedExpiresAt repository.NullTime
+	}{{
+		name:    "share without expiration",
+		entryID: 1,
+	}, {
+		name:              "share with expiration",
+		entryID:           1,
+		expiresAt:         now.Add(time.Hour),
+		expectedExpiresAt: repository.NewNullTime(now.Add(time.Hour)),
+	}, {
+		name:        "expiration in the past",
+		entryID:     1,
+		expiresAt:   now.Add(-time.Hour),
+		expectedErr: ErrInvalidShare,
+	}, {
+		name:        "missing entry",
+		entryID:     2,
+		expectedErr: sql.ErrNoRows,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedShareRepository := &shareRepositoryMock{}
+			s := WebRSSService{
+				nowFn:           func() time.Time { return now },
+				logger:          log.New(ioutil.Discard, "", 0),
+				entryRepository: &entryRepositoryMock{entries: []repository.Entry{{ID: 1}}},
+				shareRepository: mockedShareRepository,
+			}
+			share, err := s.CreateShare(context.Background(), tt.entryID, tt.expiresAt)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if tt.expectedErr != nil {
+				if len(mockedShareRepository.createdShares) != 0 {
+					t.Errorf("Expected no share to be created, but got '%v'", mockedShareRepository.createdShares)
+				}
+				return
+			}
+			if share.ID != 1 || share.EntryID != tt.entryID {
+				t.Errorf("Expected share of entry %d, but got '%v'", tt.entryID, share)
+			}
+			if share.ExpiresAt != tt.expectedExpiresAt {
+				t.Errorf("Expected expires at to be '%v', but got '%v'", tt.expectedExpiresAt, share.ExpiresAt)
+			}
+			if len(share.Token) < 43 {
+				t.Errorf("Expected token to have at least 43 characters, but got '%s'", share.Token)
+			}
+		})
+	}
+}
+
+func TestFeedService_CreateShare_uniqueTokens(t *testing.T) {
+	mockedShareRepository := &shareRepositoryMock{}
+	s := WebRSSService{
+		nowFn:           time.Now,
+		logger:          log.New(ioutil.Discard, "", 0),
+		entryRepository: &entryRepositoryMock{entries: []repository.Entry{{ID: 1}}},
+		shareRepository: mockedShareRepository,
+	}
+	tokens := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		share, err := s.CreateShare(context.Background(), 1, time.Time{})
+		if err != nil {
+			t.Fatalf("Expected err to be nil, but got '%v'", err)
+		}
+		if tokens[share.Token] {
+			t.Fatalf("Expected tokens to be unique, but got '%s' twice", share.Token)
+		}
+		tokens[share.Token] = true
+	}
+}
+
+func TestFeedService_GetSharedEntry(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		token string
+
+		expectedErr error
+	}{{
+		name:  "active share",
+		token: "active",
+	}, {
+		name:        "expired share",
+		token:       "expired",
+		expectedErr: sql.ErrNoRows,
+	}, {
+		name:        "revoked share",
+		token:       "revoked",
+		expectedErr: sql.ErrNoRows,
+	}, {
+		name:        "unknown token",
+		token:       "unknown",
+		expectedErr: sql.ErrNoRows,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := WebRSSService{
+				nowFn:           func() time.Time { return now },
+				logger:          log.New(ioutil.Discard, "", 0),
+				entryRepository: &entryRepositoryMock{entries: []repository.Entry{{ID: 1, FeedID: 1}}},
+				feedRepository:  &feedRepositoryMock{feeds: []repository.Feed{{ID: 1, FeedTitle: "Feed"}}},
+				shareRepository: &shareRepositoryMock{shares: []repository.Share{
+					{ID: 1, EntryID: 1, Token: "active", ExpiresAt: repository.NewNullTime(now.Add(time.Hour))},
+					{ID: 2, EntryID: 1, Token: "expired", ExpiresAt: repository.NewNullTime(now.Add(-time.Hour))},
+					{ID: 3, EntryID: 1, Token: "revoked", DeletedAt: repository.NewNullTime(now.Add(-time.Hour))},
+				}},
+				lastSeenRepository: &lastSeenRepositoryMock{},
+			}
+			entry, err := s.GetSharedEntry(context.Background(), tt.token)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if tt.expectedErr == nil && (entry.ID != 1 || entry.Feed.FeedTitle != "Feed") {
+				t.Errorf("Expected entry 1 with its feed, but got '%v'", entry)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+var ErrInvalidUserTag = errors.New("invalid user tag")
+
+type userTagRepository interface {
+	TagEntry(ctx context.Context, entryID int64, name string) error
+	TagEntries(ctx context.Context, entryIDs []int64, name string) error
+	UntagEntries(ctx context.Context, entryIDs []int64, name string) error
+	List(ctx context.Context) ([]repository.UserTag, error)
+	Get(ctx context.Context, id int64) (repository.UserTag, error)
+	ListForEntries(ctx context.Context, entryIDs []int64) ([]repository.EntryUserTag, error)
+	Rename(ctx context.Context, id int64, name string) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// userTagName returns tag name with surrounding whitespace removed, name can't be empty.
+func userTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: empty name", ErrInvalidUserTag)
+	}
+	return name, nil
+}
+
+func (s WebRSSService) ListUserTags(ctx context.Context) ([]repository.UserTag, error) {
+	userTags, err := s.userTagRepository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching user tags: %w", err)
+	}
+	return userTags, nil
+}
+
+// TagEntries adds tag to the entries, the tag is created when it doesn't exist.
+func (s WebRSSService) TagEntries(ctx context.Context, ids []int64, name string) error {
+	name, err := userTagName(name)
+	if err != nil {
+		return err
+	}
+	if err := s.userTagRepository.TagEntries(ctx, ids, name); err != nil {
+		return fmt.Errorf("error tagging entries: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) UntagEntries(ctx context.Context, ids []int64, name string) error {
+	name, err := userTagName(name)
+	if err != nil {
+		return err
+	}
+	if err := s.userTagRepository.UntagEntries(ctx, ids, name); err != nil {
+		return fmt.Errorf("error untagging entries: %w", err)
+	}
+	return nil
+}
+
+// RenameUserTag renames tag, renaming it to the name of another tag merges both of them.
+func (s WebRSSService) RenameUserTag(ctx context.Context, id int64, name string) error {
+	name, err := userTagName(name)
+	if err != nil {
+		return err
+	}
+	if err := s.userTagRepository.Rename(ctx, id, name); err != nil {
+		return fmt.Errorf("error renaming user tag: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) DeleteUserTag(ctx context.Context, id int64) error {
+	if err := s.userTagRepository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("error deleting user tag: %w", err)
+	}
+	return nil
+}
+
+// attachUserTags sets names of tags given to the entries.
+func (s WebRSSService) attachUserTags(ctx context.Context, entries []repository.Entry) error {
+	ids := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	entryUserTags, err := s.userTagRepository.ListForEntries(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("error fetching user tags: %w", err)
+	}
+	namesByEntryID := map[int64][]string{}
+	for _, entryUserTag := range entryUserTags {
+		namesByEntryID[entryUserTag.EntryID] = append(namesByEntryID[entryUserTag.EntryID], entryUserTag.Name)
+	}
+	for i := range entries {
+		entries[i].UserTags = namesByEntryID[entries[i].ID]
+		if entries[i].UserTags == nil {
+			entries[i].UserTags = []string{}
+		}
+	}
+	return nil
+}
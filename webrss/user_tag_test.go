@@ -0,0 +1,86 @@
+package webrss
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+func TestFeedService_TagEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []int64
+		tag  string
+
+		expectedTaggedEntries map[int64][]string
+		expectedErr           error
+	}{{
+		name:                  "tag entries",
+		ids:                   []int64{1, 2},
+		tag:                   " research ",
+		expectedTaggedEntries: map[int64][]string{1: {"research"}, 2: {"research"}},
+	}, {
+		name:        "empty name",
+		ids:         []int64{1},
+		tag:         "  ",
+		expectedErr: ErrInvalidUserTag,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockedUserTagRepository := &userTagRepositoryMock{}
+			s := WebRSSService{
+				logger:            log.New(ioutil.Discard, "", 0),
+				userTagRepository: mockedUserTagRepository,
+			}
+			err := s.TagEntries(context.Background(), tt.ids, tt.tag)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if !reflect.DeepEqual(mockedUserTagRepository.taggedEntries, tt.expectedTaggedEntries) {
+				t.Errorf("Expected tagged entries to be '%v', but got '%v'", tt.expectedTaggedEntries, mockedUserTagRepository.taggedEntries)
+			}
+		})
+	}
+}
+
+func TestFeedService_RenameUserTag(t *testing.T) {
+	mockedUserTagRepository := &userTagRepositoryMock{}
+	s := WebRSSService{
+		logger:            log.New(ioutil.Discard, "", 0),
+		userTagRepository: mockedUserTagRepository,
+	}
+	if err := s.RenameUserTag(context.Background(), 3, "go "); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	if err := s.RenameUserTag(context.Background(), 4, ""); !errors.Is(err, ErrInvalidUserTag) {
+		t.Errorf("Expected err to be '%v', but got '%v'", ErrInvalidUserTag, err)
+	}
+	expectedRenamedTags := map[int64]string{3: "go"}
+	if !reflect.DeepEqual(mockedUserTagRepository.renamedTags, expectedRenamedTags) {
+		t.Errorf("Expected renamed tags to be '%v', but got '%v'", expectedRenamedTags, mockedUserTagRepository.renamedTags)
+	}
+}
+
+func TestFeedService_attachUserTags(t *testing.T) {
+	s := WebRSSService{
+		logger: log.New(ioutil.Discard, "", 0),
+		userTagRepository: &userTagRepositoryMock{
+			taggedEntries: map[int64][]string{1: {"go", "research"}},
+		},
+	}
+	entries := []repository.Entry{{ID: 1}, {ID: 2}}
+	if err := s.attachUserTags(context.Background(), entries); err != nil {
+		t.Fatalf("Expected err to be nil, but got '%v'", err)
+	}
+	expectedUserTags := [][]string{{"go", "research"}, {}}
+	for i, entry := range entries {
+		if !reflect.DeepEqual(entry.UserTags, expectedUserTags[i]) {
+			t.Errorf("Expected user tags of entry %d to be '%v', but got '%v'", entry.ID, expectedUserTags[i], entry.UserTags)
+		}
+	}
+}
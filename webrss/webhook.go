@@ -0,0 +1,307 @@
+package webrss
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+// webhookDeliveriesLimit is the number of the latest deliveries listed for webhook.
+const webhookDeliveriesLimit = 50
+
+// defaultWebhookPayloadTemplate is used by webhooks without their own template.
+const defaultWebhookPayloadTemplate = `{"event": {{ json .Event }}, "entry": {"id": {{ .Entry.ID }}, ` +
+	`"title": {{ json .Entry.Title }}, "link": {{ json .Entry.Link }}, "author": {{ json .Entry.Author.String }}, ` +
+	`"published_at": {{ json .Entry.PublishedAt.Time }}}, "feed": {"id": {{ .Feed.ID }}, ` +
+	`"title": {{ json .Feed.FeedTitle }}, "url": {{ json .Feed.FeedUrl }}}}`
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+var webhookTemplateFuncs = map[string]interface{}{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+type webhookRepository interface {
+	List(ctx context.Context) ([]repository.Webhook, error)
+	Get(ctx context.Context, id int64) (repository.Webhook, error)
+	Create(ctx context.Context, webhook repository.Webhook) (int64, error)
+	Update(ctx context.Context, webhook repository.Webhook) error
+}
+
+type webhookDeliveryRepository interface {
+	List(ctx context.Context, webhookID int64, limit int) ([]repository.WebhookDelivery, error)
+	Get(ctx context.Context, webhookID, id int64) (repository.WebhookDelivery, error)
+	Create(ctx context.Context, delivery repository.WebhookDelivery) (int64, error)
+	Update(ctx context.Context, delivery repository.WebhookDelivery) error
+}
+
+// webhookPayloadData is available in payload templates.
+type webhookPayloadData struct {
+	Event string
+	Entry repository.Entry
+	Feed  repository.Feed
+}
+
+// webhookTemplate parses payload template of webhook, or the default one.
+func webhookTemplate(webhook repository.Webhook) (*template.Template, error) {
+	text := defaultWebhookPayloadTemplate
+	if webhook.PayloadTemplate.Valid && strings.TrimSpace(webhook.PayloadTemplate.String) != "" {
+		text = webhook.PayloadTemplate.String
+	}
+	return template.New("payload").Funcs(webhookTemplateFuncs).Parse(text)
+}
+
+// renderWebhookPayload renders payload of webhook, it has to be valid JSON.
+func renderWebhookPayload(tmpl *template.Template, data webhookPayloadData) (string, error) {
+	b := strings.Builder{}
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	if !json.Valid([]byte(b.String())) {
+		return "", errors.New("payload isn't valid JSON")
+	}
+	return b.String(), nil
+}
+
+// validateWebhook checks webhook's URL, secret, and whether its template renders valid JSON.
+func validateWebhook(webhook repository.Webhook) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: invalid url", ErrInvalidWebhook)
+	}
+	if webhook.Secret == "" {
+		return fmt.Errorf("%w: missing secret", ErrInvalidWebhook)
+	}
+	tmpl, err := webhookTemplate(webhook)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidWebhook, err)
+	}
+	sample := webhookPayloadData{
+		Event: repository.WebhookEventEntryCreated,
+		Entry: repository.Entry{ID: 1, Title: `Entry "title"`, Link: "https://example.com/entry"},
+		Feed:  repository.Feed{ID: 1, FeedTitle: "Feed", FeedUrl: "https://example.com/feed"},
+	}
+	if _, err := renderWebhookPayload(tmpl, sample); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidWebhook, err)
+	}
+	return nil
+}
+
+// validateWebhookScope checks that feed, category and rule webhook is limited to exist, and that they
+// don't exclude each other, otherwise webhook would never be called.
+func (s WebRSSService) validateWebhookScope(ctx context.Context, webhook repository.Webhook) error {
+	var feed repository.Feed
+	if webhook.FeedID.Valid {
+		var err error
+		if feed, err = s.feedRepository.Get(ctx, webhook.FeedID.Int64); errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: unknown feed", ErrInvalidWebhook)
+		} else if err != nil {
+			return fmt.Errorf("error fetching feed: %w", err)
+		}
+	}
+	if webhook.CategoryID.Valid {
+		if _, err := s.categoryRepository.Get(ctx, webhook.CategoryID.Int64); errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: unknown category", ErrInvalidWebhook)
+		} else if err != nil {
+			return fmt.Errorf("error fetching category: %w", err)
+		}
+		if webhook.FeedID.Valid && feed.CategoryID != webhook.CategoryID.Int64 {
+			return fmt.Errorf("%w: feed doesn't belong to category", ErrInvalidWebhook)
+		}
+	}
+	if !webhook.RuleID.Valid {
+		return nil
+	}
+	rule, err := s.ruleRepository.Get(ctx, webhook.RuleID.Int64)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: unknown rule", ErrInvalidWebhook)
+	} else if err != nil {
+		return fmt.Errorf("error fetching rule: %w", err)
+	}
+	ruleCategoryID := rule.CategoryID
+	if rule.FeedID.Valid {
+		if webhook.FeedID.Valid && webhook.FeedID.Int64 != rule.FeedID.Int64 {
+			return fmt.Errorf("%w: rule doesn't apply to feed", ErrInvalidWebhook)
+		}
+		if webhook.CategoryID.Valid && !webhook.FeedID.Valid {
+			ruleFeed, err := s.feedRepository.Get(ctx, rule.FeedID.Int64)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("error fetching feed: %w", err)
+			}
+			ruleCategoryID = repository.NewNullInt64(ruleFeed.CategoryID)
+		}
+	}
+	if ruleCategoryID.Valid {
+		categoryID := webhook.CategoryID
+		if webhook.FeedID.Valid {
+			categoryID = repository.NewNullInt64(feed.CategoryID)
+		}
+		if categoryID.Valid && categoryID.Int64 != ruleCategoryID.Int64 {
+			return fmt.Errorf("%w: rule doesn't apply to category", ErrInvalidWebhook)
+		}
+	}
+	return nil
+}
+
+// webhookMatches reports whether webhook should be called for entry created in feed.
+func webhookMatches(webhook repository.Webhook, feed repository.Feed, entry repository.Entry, matchers []ruleMatcher) bool {
+	if webhook.FeedID.Valid && webhook.FeedID.Int64 != feed.ID {
+		return false
+	}
+	if webhook.CategoryID.Valid && webhook.CategoryID.Int64 != feed.CategoryID {
+		return false
+	}
+	if webhook.RuleID.Valid {
+		for _, matcher := range matchers {
+			if matcher.rule.ID == webhook.RuleID.Int64 {
+				return matcher.matches(entry)
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// queueWebhooks queues deliveries of webhooks matching created entries. Webhooks failing to render their
+// payload are logged as failed deliveries.
+func (s WebRSSService) queueWebhooks(ctx context.Context, feedID int64, webhooks []repository.Webhook,
+	matchers []ruleMatcher, entries []repository.Entry) error {
+	if len(webhooks) == 0 || len(entries) == 0 {
+		return nil
+	}
+	feed, err := s.feedRepository.Get(ctx, feedID)
+	if err != nil {
+		return fmt.Errorf("error fetching feed: %w", err)
+	}
+	now := s.nowFn()
+	for _, webhook := range webhooks {
+		tmpl, tmplErr := webhookTemplate(webhook)
+		for _, entry := range entries {
+			if entry.DeletedAt.Valid || !webhookMatches(webhook, feed, entry, matchers) {
+				continue
+			}
+			delivery := repository.WebhookDelivery{
+				WebhookID:     webhook.ID,
+				EntryID:       entry.ID,
+				Event:         repository.WebhookEventEntryCreated,
+				Status:        repository.WebhookDeliveryPending,
+				NextAttemptAt: repository.NewNullTime(now),
+				CreatedAt:     repository.NewTime(now),
+			}
+			err := tmplErr
+			if err == nil {
+				delivery.Payload, err = renderWebhookPayload(tmpl, webhookPayloadData{
+					Event: delivery.Event,
+					Entry: entry,
+					Feed:  feed,
+				})
+			}
+			if err != nil {
+				s.logger.Printf("cannot render payload of webhook %d: %v\n", webhook.ID, err)
+				delivery.Status = repository.WebhookDeliveryFailed
+				delivery.NextAttemptAt = repository.NullTime{}
+				delivery.Error = repository.NewNullString("cannot render payload: " + err.Error())
+			}
+			if _, err := s.webhookDeliveryRepository.Create(ctx, delivery); err != nil {
+				return fmt.Errorf("error queueing webhook delivery: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s WebRSSService) ListWebhooks(ctx context.Context) ([]repository.Webhook, error) {
+	webhooks, err := s.webhookRepository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (s WebRSSService) GetWebhook(ctx context.Context, id int64) (repository.Webhook, error) {
+	webhook, err := s.webhookRepository.Get(ctx, id)
+	if err != nil {
+		return repository.Webhook{}, fmt.Errorf("error fetching webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s WebRSSService) CreateWebhook(ctx context.Context, webhook repository.Webhook) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	if err := s.validateWebhookScope(ctx, webhook); err != nil {
+		return err
+	}
+	webhook.CreatedAt = repository.NewTime(s.nowFn())
+	if _, err := s.webhookRepository.Create(ctx, webhook); err != nil {
+		return fmt.Errorf("error creating webhook: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) UpdateWebhook(ctx context.Context, webhook repository.Webhook) error {
+	if err := validateWebhook(webhook); err != nil {
+		return err
+	}
+	if err := s.validateWebhookScope(ctx, webhook); err != nil {
+		return err
+	}
+	webhook.UpdatedAt = repository.NewNullTime(s.nowFn())
+	if err := s.webhookRepository.Update(ctx, webhook); err != nil {
+		return fmt.Errorf("error updating webhook: %w", err)
+	}
+	return nil
+}
+
+func (s WebRSSService) DeleteWebhook(ctx context.Context, id int64) error {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return fmt.Errorf("cannot fetch webhook for delete: %w", err)
+	}
+	now := repository.NewNullTime(s.nowFn())
+	webhook.UpdatedAt = now
+	webhook.DeletedAt = now
+	if err := s.webhookRepository.Update(ctx, webhook); err != nil {
+		return fmt.Errorf("error deleting webhook: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the latest deliveries of webhook, most recent first.
+func (s WebRSSService) ListWebhookDeliveries(ctx context.Context, webhookID int64) ([]repository.WebhookDelivery, error) {
+	deliveries, err := s.webhookDeliveryRepository.List(ctx, webhookID, webhookDeliveriesLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhook queues delivery again with the same payload, it's attempted as soon as possible with
+// all retries available.
+func (s WebRSSService) RedeliverWebhook(ctx context.Context, webhookID, id int64) error {
+	delivery, err := s.webhookDeliveryRepository.Get(ctx, webhookID, id)
+	if err != nil {
+		return fmt.Errorf("error fetching webhook delivery: %w", err)
+	}
+	if delivery.Payload == "" {
+		return fmt.Errorf("%w: delivery doesn't have payload", ErrInvalidWebhook)
+	}
+	delivery.Status = repository.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = repository.NewNullTime(s.nowFn())
+	if err := s.webhookDeliveryRepository.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("error queueing webhook delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package webrss
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/webrss/repository"
+)
+
+type webhookRepositoryMock struct {
+	webhooks []repository.Webhook
+}
+
+func (m *webhookRepositoryMock) List(ctx context.Context) ([]repository.Webhook, error) {
+	return m.webhooks, nil
+}
+
+func (m *webhookRepositoryMock) Get(ctx context.Context, id int64) (repository.Webhook, error) {
+	panic("implement me!")
+}
+
+func (m *webhookRepositoryMock) Create(ctx context.Context, webhook repository.Webhook) (int64, error) {
+	panic("implement me!")
+}
+
+func (m *webhookRepositoryMock) Update(ctx context.Context, webhook repository.Webhook) error {
+	panic("implement me!")
+}
+
+type webhookDeliveryRepositoryMock struct {
+	deliveries        []repository.WebhookDelivery
+	createdDeliveries []repository.WebhookDelivery
+	updatedDeliveries []repository.WebhookDelivery
+}
+
+func (m *webhookDeliveryRepositoryMock) List(ctx context.Context, webhookID int64, limit int) ([]repository.WebhookDelivery, error) {
+	panic("implement me!")
+}
+
+func (m *webhookDeliveryRepositoryMock) Get(ctx context.Context, webhookID, id int64) (repository.WebhookDelivery, error) {
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID && delivery.ID == id {
+			return delivery, nil
+		}
+	}
+	return repository.WebhookDelivery{}, sql.ErrNoRows
+}
+
+func (m *webhookDeliveryRepositoryMock) Create(ctx context.Context, delivery repository.WebhookDelivery) (int64, error) {
+	m.createdDeliveries = append(m.createdDeliveries, delivery)
+	return int64(len(m.createdDeliveries)), nil
+}
+
+func (m *webhookDeliveryRepositoryMock) Update(ctx context.Context, delivery repository.WebhookDelivery) error {
+	m.updatedDeliveries = append(m.updatedDeliveries, delivery)
+	return nil
+}
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook repository.Webhook
+
+		expectedErr error
+	}{{
+		name:    "default template",
+		webhook: repository.Webhook{URL: "https://example.com/hook", Secret: "secret"},
+	}, {
+		name: "custom template",
+		webhook: repository.Webhook{URL: "https://example.com/hook", Secret: "secret",
+			PayloadTemplate: repository.NewNullString(`{"text": {{ json .Entry.Title }}}`)},
+	}, {
+		name:        "invalid scheme",
+		webhook:     repository.Webhook{URL: "ftp://example.com/hook", Secret: "secret"},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "missing host",
+		webhook:     repository.Webhook{URL: "https:///hook", Secret: "secret"},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "missing secret",
+		webhook:     repository.Webhook{URL: "https://example.com/hook"},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name: "unparsable template",
+		webhook: repository.Webhook{URL: "https://example.com/hook", Secret: "secret",
+			PayloadTemplate: repository.NewNullString(`{"text": {{ .Entry.Title }`)},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name: "template rendering invalid json",
+		webhook: repository.Webhook{URL: "https://example.com/hook", Secret: "secret",
+			PayloadTemplate: repository.NewNullString(`{"text": "{{ .Entry.Title }}"}`)},
+		expectedErr: ErrInvalidWebhook,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWebhook(tt.webhook); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestFeedService_validateWebhookScope(t *testing.T) {
+	s := WebRSSService{
+		feedRepository:     &feedRepositoryMock{feeds: []repository.Feed{{ID: 1, CategoryID: 1}, {ID: 2, CategoryID: 2}}},
+		categoryRepository: &categoryRepositoryMock{categories: []repository.Category{{ID: 1}, {ID: 2}}},
+		ruleRepository: &ruleRepositoryMock{rules: []repository.Rule{
+			{ID: 1},
+			{ID: 2, FeedID: repository.NewNullInt64(1)},
+			{ID: 3, CategoryID: repository.NewNullInt64(2)},
+		}},
+	}
+	tests := []struct {
+		name    string
+		webhook repository.Webhook
+
+		expectedErr error
+	}{{
+		name:    "all entries",
+		webhook: repository.Webhook{},
+	}, {
+		name:    "feed within category",
+		webhook: repository.Webhook{FeedID: repository.NewNullInt64(1), CategoryID: repository.NewNullInt64(1)},
+	}, {
+		name:    "rule of feed within category",
+		webhook: repository.Webhook{CategoryID: repository.NewNullInt64(1), RuleID: repository.NewNullInt64(2)},
+	}, {
+		name:    "rule of category of feed",
+		webhook: repository.Webhook{FeedID: repository.NewNullInt64(2), RuleID: repository.NewNullInt64(3)},
+	}, {
+		name:        "unknown feed",
+		webhook:     repository.Webhook{FeedID: repository.NewNullInt64(3)},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "unknown category",
+		webhook:     repository.Webhook{CategoryID: repository.NewNullInt64(3)},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "unknown rule",
+		webhook:     repository.Webhook{RuleID: repository.NewNullInt64(4)},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "feed outside of category",
+		webhook:     repository.Webhook{FeedID: repository.NewNullInt64(2), CategoryID: repository.NewNullInt64(1)},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "rule of other feed",
+		webhook:     repository.Webhook{FeedID: repository.NewNullInt64(2), RuleID: repository.NewNullInt64(2)},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "rule of feed outside of category",
+		webhook:     repository.Webhook{CategoryID: repository.NewNullInt64(2), RuleID: repository.NewNullInt64(2)},
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "rule of other category",
+		webhook:     repository.Webhook{FeedID: repository.NewNullInt64(1), RuleID: repository.NewNullInt64(3)},
+		expectedErr: ErrInvalidWebhook,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.validateWebhookScope(context.Background(), tt.webhook); !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestFeedService_queueWebhooks(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	feeds := []repository.Feed{{ID: 1, CategoryID: 2, FeedTitle: "Feed"}}
+	entries := []repository.Entry{
+		{ID: 10, FeedID: 1, Title: "Golang release"},
+		{ID: 11, FeedID: 1, Title: "Other news"},
+		{ID: 12, FeedID: 1, Title: "Hidden golang", DeletedAt: repository.NewNullTime(now)},
+	}
+	rule := repository.Rule{ID: 5, Field: repository.RuleFieldTitle, MatchType: repository.RuleMatchKeyword,
+		Pattern: "golang", Action: repository.RuleActionStar}
+	tests := []struct {
+		name    string
+		webhook repository.Webhook
+
+		// entry id => delivery status
+		expectedDeliveries map[int64]string
+	}{{
+		name:               "without filters",
+		webhook:            repository.Webhook{ID: 1},
+		expectedDeliveries: map[int64]string{10: repository.WebhookDeliveryPending, 11: repository.WebhookDeliveryPending},
+	}, {
+		name:               "matching feed and category",
+		webhook:            repository.Webhook{ID: 1, FeedID: repository.NewNullInt64(1), CategoryID: repository.NewNullInt64(2)},
+		expectedDeliveries: map[int64]string{10: repository.WebhookDeliveryPending, 11: repository.WebhookDeliveryPending},
+	}, {
+		name:               "other feed",
+		webhook:            repository.Webhook{ID: 1, FeedID: repository.NewNullInt64(2)},
+		expectedDeliveries: map[int64]string{},
+	}, {
+		name:               "other category",
+		webhook:            repository.Webhook{ID: 1, CategoryID: repository.NewNullInt64(3)},
+		expectedDeliveries: map[int64]string{},
+	}, {
+		name:               "matching rule",
+		webhook:            repository.Webhook{ID: 1, RuleID: repository.NewNullInt64(5)},
+		expectedDeliveries: map[int64]string{10: repository.WebhookDeliveryPending},
+	}, {
+		name:               "rule not applied to feed",
+		webhook:            repository.Webhook{ID: 1, RuleID: repository.NewNullInt64(6)},
+		expectedDeliveries: map[int64]string{},
+	}, {
+		name: "payload failing to render",
+		webhook: repository.Webhook{ID: 1,
+			PayloadTemplate: repository.NewNullString(`{"title": "{{ .Entry.Title }}`)},
+		expectedDeliveries: map[int64]string{10: repository.WebhookDeliveryFailed, 11: repository.WebhookDeliveryFailed},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveryRepository := &webhookDeliveryRepositoryMock{}
+			s := WebRSSService{
+				nowFn:                     func() time.Time { return now },
+				logger:                    log.New(ioutil.Discard, "", 0),
+				feedRepository:            &feedRepositoryMock{feeds: feeds},
+				webhookDeliveryRepository: deliveryRepository,
+			}
+			matchers := s.newRuleMatchers([]repository.Rule{rule})
+			err := s.queueWebhooks(context.Background(), 1, []repository.Webhook{tt.webhook}, matchers, entries)
+			if err != nil {
+				t.Fatalf("Expected err to be nil, but got '%v'", err)
+			}
+			deliveries := map[int64]string{}
+			for _, delivery := range deliveryRepository.createdDeliveries {
+				deliveries[delivery.EntryID] = delivery.Status
+				if delivery.Status == repository.WebhookDeliveryFailed {
+					if !delivery.Error.Valid || delivery.NextAttemptAt.Valid {
+						t.Errorf("Expected failed delivery to have error and no next attempt, but got '%v'", delivery)
+					}
+					continue
+				}
+				if !json.Valid([]byte(delivery.Payload)) {
+					t.Errorf("Expected payload to be valid JSON, but got '%s'", delivery.Payload)
+				}
+				if !delivery.NextAttemptAt.Valid || !delivery.NextAttemptAt.Time.Equal(now) {
+					t.Errorf("Expected next attempt to be '%v', but got '%v'", now, delivery.NextAttemptAt)
+				}
+			}
+			if !reflect.DeepEqual(deliveries, tt.expectedDeliveries) {
+				t.Errorf("Expected deliveries to be '%v', but got '%v'", tt.expectedDeliveries, deliveries)
+			}
+		})
+	}
+}
+
+func TestFeedService_RedeliverWebhook(t *testing.T) {
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	deliveries := []repository.WebhookDelivery{
+		{ID: 1, WebhookID: 1, Payload: `{}`, Status: repository.WebhookDeliveryFailed, Attempts: 6},
+		{ID: 2, WebhookID: 1, Status: repository.WebhookDeliveryFailed, Attempts: 0},
+	}
+	tests := []struct {
+		name      string
+		webhookID int64
+		id        int64
+
+		expectedErr error
+	}{{
+		name:      "failed delivery",
+		webhookID: 1,
+		id:        1,
+	}, {
+		name:        "delivery without payload",
+		webhookID:   1,
+		id:          2,
+		expectedErr: ErrInvalidWebhook,
+	}, {
+		name:        "delivery of other webhook",
+		webhookID:   2,
+		id:          1,
+		expectedErr: sql.ErrNoRows,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveryRepository := &webhookDeliveryRepositoryMock{deliveries: deliveries}
+			s := WebRSSService{
+				nowFn:                     func() time.Time { return now },
+				webhookDeliveryRepository: deliveryRepository,
+			}
+			err := s.RedeliverWebhook(context.Background(), tt.webhookID, tt.id)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected err to be '%v', but got '%v'", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if len(deliveryRepository.updatedDeliveries) != 1 {
+				t.Fatalf("Expected '1' updated delivery, but got '%d'", len(deliveryRepository.updatedDeliveries))
+			}
+			delivery := deliveryRepository.updatedDeliveries[0]
+			if delivery.Status != repository.WebhookDeliveryPending || delivery.Attempts != 0 {
+				t.Errorf("Expected delivery to be pending without attempts, but got '%s' with '%d'", delivery.Status, delivery.Attempts)
+			}
+			if !delivery.NextAttemptAt.Valid || !delivery.NextAttemptAt.Time.Equal(now) {
+				t.Errorf("Expected next attempt to be '%v', but got '%v'", now, delivery.NextAttemptAt)
+			}
+		})
+	}
+}